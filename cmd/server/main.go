@@ -5,8 +5,15 @@ package main
 import (
 	"context"
 	"encoding/json"
+	"errors"
+	"flag"
 	"log"
 	"net/http" // Needed for http.Dir and handler funcs
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+	_ "time/tzdata" // Embedded zoneinfo for TIMEZONE/tz in minimal images
 
 	"bp-tracker/internal/database"
 	"bp-tracker/internal/handlers"
@@ -29,21 +36,8 @@ func ginSecurityHeaders() gin.HandlerFunc {
 	}
 }
 
-func init() {
-	log.Println("Initializing Lambda handler...")
-
-	// Initialize database
-	db, err := database.New()
-	if err != nil {
-		log.Fatalf("FATAL: Failed to initialize database: %v", err)
-	}
-
-	// Initialize handlers (needed for handler methods)
-	h, err := handlers.New(db)
-	if err != nil {
-		log.Fatalf("FATAL: Failed to initialize handlers: %v", err)
-	}
-
+// newRouter builds the Gin engine shared by the Lambda and HTTP server modes
+func newRouter(h *handlers.Handler) *gin.Engine {
 	// Create a Gin engine - Define routes directly in Gin
 	gin.SetMode(gin.ReleaseMode)
 	router := gin.New()
@@ -53,10 +47,25 @@ func init() {
 	// Define routes using gin.WrapF for the http.HandlerFunc methods
 	router.GET("/", gin.WrapF(h.HomeHandler))
 	router.POST("/submit", gin.WrapF(h.SubmitReadingHandler))
+	router.GET("/export", gin.WrapF(h.ExportHandler)) // ?format= or Accept, see internal/export
 	router.GET("/export/csv", gin.WrapF(h.ExportCSVHandler))
+	router.GET("/export/hl7", gin.WrapF(h.ExportHL7Handler)) // ORU^R01 batch, ?from=&to=
+	router.GET("/export/hl7/:id", gin.WrapF(h.ExportHL7ReadingHandler))
+	router.GET("/report", gin.WrapF(h.ReportHandler)) // Printable HTML, or ?format=pdf
+	router.GET("/chart/trend.svg", gin.WrapF(h.TrendChartHandler))
+	router.POST("/import/csv", gin.WrapF(h.ImportCSVHandler)) // Loads files written by /export/csv
+	router.POST("/import", gin.WrapF(h.ImportHandler))        // Vendor CSV exports, format detected or ?format=
+	router.GET("/import/formats", gin.WrapF(h.ImportFormatsHandler))
+	router.POST("/import/apple-health", gin.WrapF(h.ImportAppleHealthHandler))
+	router.POST("/import/fhir", gin.WrapF(h.ImportFHIRHandler))
+
+	// FHIR R4 read-only access for clinical systems
+	router.GET("/fhir/Observation", gin.WrapF(h.FHIRObservationSearchHandler))
+	router.GET("/fhir/Observation/:id", gin.WrapF(h.FHIRObservationReadHandler))
 
 	// Use POST for potentially state-changing operation
 	router.POST("/migrate", gin.WrapF(h.MigrateHandler))
+	router.POST("/reclassify", gin.WrapF(h.ReclassifyHandler))
 
 	// --- API Endpoints for external clients (e.g., iOS app) ---
 	apiGroup := router.Group("/api")
@@ -66,11 +75,17 @@ func init() {
 		// Add other future API endpoints here
 		// Endpoint to get statistics as JSON
 		apiGroup.GET("/stats", gin.WrapF(h.GetStatsHandler))
+		// Endpoint to list the classification guidelines
+		apiGroup.GET("/guidelines", gin.WrapF(h.GuidelinesHandler))
 		// Add other future API endpoints here
 		// Endpoint to delete a specific reading by ID
 		// NOTE: The path parameter :id needs to be handled by the handler logic
 		// since we are wrapping http.HandlerFunc. A full Gin handler would use c.Param("id").
 		apiGroup.DELETE("/readings/:id", gin.WrapF(h.DeleteReadingHandler))
+		// Endpoints to fetch and edit a single reading (optimistic concurrency via ETag/If-Match)
+		apiGroup.GET("/readings/:id", gin.WrapF(h.GetReadingHandler))
+		apiGroup.PUT("/readings/:id", gin.WrapF(h.UpdateReadingHandler))
+		apiGroup.PATCH("/readings/:id", gin.WrapF(h.UpdateReadingHandler))
 
 		// --- Development/Testing Endpoints ---
 		devGroup := apiGroup.Group("/dev")
@@ -86,9 +101,7 @@ func init() {
 	// The second path is the filesystem path *inside the container*
 	router.StaticFS("/static", http.Dir("web/static"))
 
-	// Create the Gin Lambda adapter with the Gin router
-	ginLambda = ginadapter.New(router)
-	log.Println("Lambda handler initialized successfully (using direct Gin routing)")
+	return router
 }
 
 // LambdaHandler is the main handler function for AWS Lambda
@@ -111,7 +124,99 @@ func LambdaHandler(ctx context.Context, req events.APIGatewayProxyRequest) (even
 	return resp, err
 }
 
+// serveHTTP runs the router as a plain net/http server until SIGINT or
+// SIGTERM, then drains in-flight requests before returning.
+// Only headers have a fixed deadline; writeTimeout (0 for none) bounds each
+// response, and import and export handlers extend it for large files.
+func serveHTTP(router http.Handler, addr string, writeTimeout time.Duration) error {
+	srv := &http.Server{
+		Addr:              addr,
+		Handler:           router,
+		ReadHeaderTimeout: 10 * time.Second,
+		WriteTimeout:      writeTimeout,
+		IdleTimeout:       60 * time.Second,
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	errCh := make(chan error, 1)
+	go func() {
+		log.Printf("HTTP server listening on %s\n", addr)
+		errCh <- srv.ListenAndServe()
+	}()
+
+	select {
+	case err := <-errCh:
+		// Server failed to start (e.g. address already in use)
+		return err
+	case <-ctx.Done():
+		log.Println("Shutdown signal received, draining connections...")
+	}
+
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	if err := srv.Shutdown(shutdownCtx); err != nil {
+		return err
+	}
+	if err := <-errCh; !errors.Is(err, http.ErrServerClosed) {
+		return err
+	}
+
+	log.Println("HTTP server stopped")
+	return nil
+}
+
+// envOr returns the environment variable key, or fallback when it is unset
+func envOr(key, fallback string) string {
+	if v := os.Getenv(key); v != "" {
+		return v
+	}
+	return fallback
+}
+
 func main() {
+	mode := flag.String("mode", envOr("SERVER_MODE", "lambda"), "Run mode: lambda or http (env SERVER_MODE)")
+	addr := flag.String("addr", envOr("LISTEN_ADDR", ":32401"), "Listen address in http mode (env LISTEN_ADDR)")
+	writeTimeout := flag.String("write-timeout", envOr("HTTP_WRITE_TIMEOUT", "30s"), "Response timeout in http mode, 0 for none (env HTTP_WRITE_TIMEOUT)")
+	flag.Parse()
+
+	if *mode != "lambda" && *mode != "http" {
+		log.Fatalf("FATAL: Unknown mode %q (expected lambda or http)", *mode)
+	}
+	writeTimeoutDuration, err := time.ParseDuration(*writeTimeout)
+	if err != nil {
+		log.Fatalf("FATAL: Invalid write timeout %q: %v", *writeTimeout, err)
+	}
+
+	log.Printf("Initializing %s handler...", *mode)
+
+	// Initialize the storage backend selected by DB_DRIVER (PostgreSQL by default)
+	db, err := database.NewStore()
+	if err != nil {
+		log.Fatalf("FATAL: Failed to initialize database: %v", err)
+	}
+	defer db.Close()
+
+	// Initialize handlers (needed for handler methods)
+	h, err := handlers.New(db)
+	if err != nil {
+		log.Fatalf("FATAL: Failed to initialize handlers: %v", err)
+	}
+
+	router := newRouter(h)
+
+	if *mode == "http" {
+		if err := serveHTTP(router, *addr, writeTimeoutDuration); err != nil {
+			log.Fatalf("FATAL: HTTP server error: %v", err)
+		}
+		return
+	}
+
+	// Create the Gin Lambda adapter with the Gin router
+	ginLambda = ginadapter.New(router)
+	log.Println("Lambda handler initialized successfully (using direct Gin routing)")
+
 	// Start the Lambda listener
 	lambda.Start(LambdaHandler)
 }
@@ -10,13 +10,14 @@ require (
 	github.com/awslabs/aws-lambda-go-api-proxy v0.16.2
 	github.com/gin-gonic/gin v1.10.0
 	github.com/jackc/pgx/v5 v5.7.4
-// github.com/mattn/go-sqlite3 v1.14.17 // Removed
+	github.com/mattn/go-sqlite3 v1.14.17
 // Add any other existing direct dependencies here if they were present
 )
 
 require (
 	github.com/aws/aws-sdk-go-v2/config v1.29.13
 	github.com/aws/aws-sdk-go-v2/service/secretsmanager v1.35.3
+	github.com/go-pdf/fpdf v0.9.0
 )
 
 require (
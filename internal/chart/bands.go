@@ -0,0 +1,104 @@
+// File: internal/chart/bands.go
+
+package chart
+
+import (
+	"fmt"
+	"time"
+
+	"bp-tracker/internal/utils"
+)
+
+// Measure names the value whose category thresholds are shaded
+type Measure string
+
+const (
+	MeasureSystolic  Measure = "systolic"
+	MeasureDiastolic Measure = "diastolic"
+)
+
+// Band is a shaded value range [From, To) behind the lines
+type Band struct {
+	From, To float64
+	Label    string
+	Color    string
+}
+
+// bandColors are light tints for each category risk level, from low to
+// severe, so every guideline is shaded alike
+var bandColors = map[string]string{
+	"low":       "#e6f4ea",
+	"moderate":  "#fdf6d8",
+	"high":      "#fde7cf",
+	"very high": "#fbd5d5",
+	"severe":    "#ecd5ee",
+}
+
+// bandLimit is the highest value scanned for thresholds, above any valid reading
+const bandLimit = 300
+
+// CategoryBands returns the category ranges of a guideline for one measure,
+// found by asking the classifier so they always match the stored
+// classifications. The other measure is held at its hypotension threshold,
+// the lowest value that is neither low nor high, so each band shows the
+// category that measure alone would give; a reading's category is the
+// higher of the two. Bands are colored by the category's risk level, not
+// a subtype's: one measure alone gives an isolated subtype throughout, and
+// the tiers must still be told apart. Any other measure has no bands.
+func CategoryBands(c utils.Classifier, m Measure) []Band {
+	var classify func(v int) utils.BPCategory
+	switch m {
+	case MeasureSystolic:
+		classify = func(v int) utils.BPCategory { return c.Classify(v, utils.HypotensionDiastolic) }
+	case MeasureDiastolic:
+		classify = func(v int) utils.BPCategory { return c.Classify(utils.HypotensionSystolic, v) }
+	default:
+		return nil
+	}
+
+	risks := make(map[string]string)
+	for _, category := range c.Categories() {
+		risks[category.Name] = category.Risk
+	}
+
+	var bands []Band
+	for v := 0; v <= bandLimit; v++ {
+		category := classify(v)
+		if n := len(bands); n > 0 && bands[n-1].Label == category.Name {
+			bands[n-1].To = float64(v + 1)
+			continue
+		}
+		bands = append(bands, Band{From: float64(v), To: float64(v + 1), Label: category.Name, Color: bandColors[risks[category.Name]]})
+	}
+	return bands
+}
+
+// MovingAverage returns a dashed series of the trailing average of s over
+// window: at each point, the mean of the points in (t-window, t]
+func MovingAverage(s Series, window time.Duration) Series {
+	avg := Series{
+		Name:   fmt.Sprintf("%s %s avg", s.Name, windowLabel(window)),
+		Color:  s.Color,
+		Dashed: true,
+		Points: make([]Point, len(s.Points)),
+	}
+
+	start, sum := 0, 0.0
+	for i, pt := range s.Points {
+		sum += pt.Value
+		for start < i && !s.Points[start].Time.After(pt.Time.Add(-window)) {
+			sum -= s.Points[start].Value
+			start++
+		}
+		avg.Points[i] = Point{Time: pt.Time, Value: sum / float64(i-start+1)}
+	}
+	return avg
+}
+
+// windowLabel names a window, e.g. "7-day"
+func windowLabel(window time.Duration) string {
+	if window%(24*time.Hour) == 0 {
+		return fmt.Sprintf("%d-day", window/(24*time.Hour))
+	}
+	return window.String()
+}
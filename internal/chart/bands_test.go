@@ -0,0 +1,79 @@
+// File: internal/chart/bands_test.go
+
+package chart
+
+import (
+	"testing"
+
+	"bp-tracker/internal/utils"
+)
+
+// TestCategoryBandColors checks that each tier's band has the tint of the
+// tier's own risk level, so tiers of different risk are told apart even
+// though one measure alone gives an isolated subtype throughout
+func TestCategoryBandColors(t *testing.T) {
+	for _, c := range utils.Classifiers() {
+		tiered, ok := c.(*utils.TieredClassifier)
+		if !ok {
+			continue
+		}
+		risks := map[string]string{tiered.Hypotension().Name: tiered.Hypotension().Risk}
+		for _, tier := range tiered.Tiers() {
+			risks[tier.Category.Name] = tier.Category.Risk
+		}
+
+		for _, m := range []Measure{MeasureSystolic, MeasureDiastolic} {
+			bands := CategoryBands(c, m)
+			colors := make(map[string]string) // Color to the label first shaded with it
+			for _, b := range bands {
+				want, ok := bandColors[risks[b.Label]]
+				if !ok {
+					t.Fatalf("%s %s band %q has no tint for risk %q", c.ID(), m, b.Label, risks[b.Label])
+				}
+				if b.Color != want {
+					t.Errorf("%s %s band %q color = %s, want %s (%s)", c.ID(), m, b.Label, b.Color, want, risks[b.Label])
+				}
+				if other, ok := colors[b.Color]; ok && risks[other] != risks[b.Label] {
+					t.Errorf("%s %s bands %q and %q share color %s", c.ID(), m, other, b.Label, b.Color)
+				}
+				colors[b.Color] = b.Label
+			}
+
+			// Hypertension tiers, where the subtypes are, are each their own color
+			seen := make(map[string]string)
+			for _, tier := range tiered.Tiers() {
+				if !tier.Isolated {
+					continue
+				}
+				for _, b := range bands {
+					if b.Label != tier.Category.Name {
+						continue
+					}
+					if other, ok := seen[b.Color]; ok {
+						t.Errorf("%s %s bands %q and %q share color %s", c.ID(), m, other, b.Label, b.Color)
+					}
+					seen[b.Color] = b.Label
+				}
+			}
+		}
+	}
+}
+
+func TestCategoryBandsCover(t *testing.T) {
+	for _, c := range utils.Classifiers() {
+		for _, m := range []Measure{MeasureSystolic, MeasureDiastolic} {
+			bands := CategoryBands(c, m)
+			if len(bands) == 0 || bands[0].From != 0 || bands[len(bands)-1].To != bandLimit+1 {
+				t.Fatalf("%s %s bands do not cover 0 to %d: %+v", c.ID(), m, bandLimit, bands)
+			}
+			for i := 1; i < len(bands); i++ {
+				if bands[i].From != bands[i-1].To || bands[i].Label == bands[i-1].Label {
+					t.Errorf("%s %s bands %+v and %+v do not meet", c.ID(), m, bands[i-1], bands[i])
+				}
+			}
+		}
+	}
+	if bands := CategoryBands(utils.AHA2017, "pulse"); bands != nil {
+		t.Errorf("pulse bands = %+v, want none", bands)
+	}
+}
@@ -0,0 +1,101 @@
+// File: internal/chart/canvas.go
+
+package chart
+
+import (
+	"bytes"
+	"fmt"
+	"html"
+	"math"
+	"strconv"
+	"strings"
+)
+
+// Anchor is the horizontal alignment of text at its x coordinate
+type Anchor string
+
+const (
+	AnchorStart  Anchor = "start"
+	AnchorMiddle Anchor = "middle"
+	AnchorEnd    Anchor = "end"
+)
+
+// Stroke describes how a line is drawn
+type Stroke struct {
+	Color  string  // "#rrggbb"
+	Width  float64 // In canvas units
+	Dashed bool
+}
+
+// Canvas is a drawing surface with the origin at the top left. A chart draws
+// itself onto a Canvas, so the same chart can be written as SVG or into a
+// PDF page.
+type Canvas interface {
+	Line(x1, y1, x2, y2 float64, s Stroke)
+	Polyline(xs, ys []float64, s Stroke)
+	Rect(x, y, w, h float64, fill string)
+	Dot(x, y, r float64, fill string)
+	Text(x, y float64, text string, size float64, color string, anchor Anchor) // y is the baseline
+}
+
+// SVGCanvas collects drawing operations as SVG elements
+type SVGCanvas struct {
+	Width, Height float64
+	Title         string // Accessible name of the image
+	body          bytes.Buffer
+}
+
+// NewSVGCanvas returns an empty width x height canvas
+func NewSVGCanvas(width, height float64, title string) *SVGCanvas {
+	return &SVGCanvas{Width: width, Height: height, Title: title}
+}
+
+func (c *SVGCanvas) Line(x1, y1, x2, y2 float64, s Stroke) {
+	fmt.Fprintf(&c.body, `<line x1="%s" y1="%s" x2="%s" y2="%s"%s/>`+"\n", num(x1), num(y1), num(x2), num(y2), strokeAttrs(s))
+}
+
+func (c *SVGCanvas) Polyline(xs, ys []float64, s Stroke) {
+	points := make([]string, len(xs))
+	for i := range xs {
+		points[i] = num(xs[i]) + "," + num(ys[i])
+	}
+	fmt.Fprintf(&c.body, `<polyline points="%s" fill="none" stroke-linejoin="round"%s/>`+"\n", strings.Join(points, " "), strokeAttrs(s))
+}
+
+func (c *SVGCanvas) Rect(x, y, w, h float64, fill string) {
+	fmt.Fprintf(&c.body, `<rect x="%s" y="%s" width="%s" height="%s" fill="%s"/>`+"\n", num(x), num(y), num(w), num(h), fill)
+}
+
+func (c *SVGCanvas) Dot(x, y, r float64, fill string) {
+	fmt.Fprintf(&c.body, `<circle cx="%s" cy="%s" r="%s" fill="%s"/>`+"\n", num(x), num(y), num(r), fill)
+}
+
+func (c *SVGCanvas) Text(x, y float64, text string, size float64, color string, anchor Anchor) {
+	fmt.Fprintf(&c.body, `<text x="%s" y="%s" font-size="%s" fill="%s" text-anchor="%s">%s</text>`+"\n", num(x), num(y), num(size), color, anchor, html.EscapeString(text))
+}
+
+// Bytes returns the complete SVG document. It scales to the width of its
+// container and needs no external stylesheet.
+func (c *SVGCanvas) Bytes() []byte {
+	var b bytes.Buffer
+	fmt.Fprintf(&b, `<svg xmlns="http://www.w3.org/2000/svg" viewBox="0 0 %s %s" width="%s" height="%s" role="img" aria-label="%s" font-family="Helvetica, Arial, sans-serif">`+"\n",
+		num(c.Width), num(c.Height), num(c.Width), num(c.Height), html.EscapeString(c.Title))
+	fmt.Fprintf(&b, "<title>%s</title>\n", html.EscapeString(c.Title))
+	b.Write(c.body.Bytes())
+	b.WriteString("</svg>\n")
+	return b.Bytes()
+}
+
+// strokeAttrs returns the SVG attributes for s
+func strokeAttrs(s Stroke) string {
+	attrs := fmt.Sprintf(` stroke="%s" stroke-width="%s"`, s.Color, num(s.Width))
+	if s.Dashed {
+		attrs += fmt.Sprintf(` stroke-dasharray="%s %s"`, num(s.Width*4), num(s.Width*3))
+	}
+	return attrs
+}
+
+// num formats a coordinate with at most two decimals, keeping the SVG small
+func num(v float64) string {
+	return strconv.FormatFloat(math.Round(v*100)/100, 'f', -1, 64)
+}
@@ -0,0 +1,77 @@
+// File: internal/chart/readings.go
+
+package chart
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+
+	"bp-tracker/internal/models"
+	"bp-tracker/internal/utils"
+)
+
+// SeriesNames are the reading values that can be charted, in legend order
+var SeriesNames = []string{"systolic", "diastolic", "pulse"}
+
+// ReadingOptions selects what a readings chart shows
+type ReadingOptions struct {
+	Series        []string         // Names from SeriesNames; all when empty
+	Bands         Measure          // Category bands to shade; none when empty
+	Guideline     utils.Classifier // Guideline of the bands; utils.AHA2017 when nil
+	MovingAverage time.Duration    // Trailing average window; none when 0
+}
+
+// ReadingSeries returns systolic, diastolic and pulse series for readings,
+// which must be sorted oldest first
+func ReadingSeries(readings []*models.Reading) []Series {
+	series := []Series{
+		{Name: "Systolic", Color: SystolicColor},
+		{Name: "Diastolic", Color: DiastolicColor},
+		{Name: "Pulse", Color: PulseColor},
+	}
+	for _, r := range readings {
+		for i, v := range []int{r.Systolic, r.Diastolic, r.Pulse} {
+			series[i].Points = append(series[i].Points, Point{Time: r.Timestamp, Value: float64(v)})
+		}
+	}
+	return series
+}
+
+// NewReadingTrend returns a chart of readings (oldest first). The caller
+// sets the size, range and timezone.
+func NewReadingTrend(readings []*models.Reading, opts ReadingOptions) (*Trend, error) {
+	all := ReadingSeries(readings)
+	names := opts.Series
+	if len(names) == 0 {
+		names = SeriesNames
+	}
+
+	for _, name := range names {
+		if !slices.Contains(SeriesNames, name) {
+			return nil, fmt.Errorf("invalid series %q (expected %s)", name, strings.Join(SeriesNames, ", "))
+		}
+	}
+	if opts.Bands != "" && opts.Bands != MeasureSystolic && opts.Bands != MeasureDiastolic {
+		return nil, fmt.Errorf("invalid bands %q (expected systolic or diastolic)", opts.Bands)
+	}
+
+	guideline := opts.Guideline
+	if guideline == nil {
+		guideline = utils.AHA2017
+	}
+	t := &Trend{Bands: CategoryBands(guideline, opts.Bands)}
+	for i, name := range SeriesNames {
+		if slices.Contains(names, name) {
+			t.Series = append(t.Series, all[i])
+		}
+	}
+
+	if opts.MovingAverage > 0 {
+		for _, s := range t.Series {
+			t.Series = append(t.Series, MovingAverage(s, opts.MovingAverage))
+		}
+	}
+	return t, nil
+}
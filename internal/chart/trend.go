@@ -0,0 +1,272 @@
+// File: internal/chart/trend.go
+
+package chart
+
+import (
+	"math"
+	"strconv"
+	"time"
+)
+
+// Colors of the reading series
+const (
+	SystolicColor  = "#c0392b"
+	DiastolicColor = "#2c6fbb"
+	PulseColor     = "#7f8c8d"
+
+	gridColor  = "#e3e3e3"
+	axisColor  = "#999999"
+	labelColor = "#555555"
+
+	bandLabelColor = "#8a8a8a"
+)
+
+// Plot margins, leaving room for the axis labels and the legend
+const (
+	marginLeft   = 34.0
+	marginRight  = 10.0
+	marginTop    = 24.0
+	marginBottom = 22.0
+	labelSize    = 9.0
+)
+
+// Point is one value at one time
+type Point struct {
+	Time  time.Time
+	Value float64
+}
+
+// Series is one line of a trend chart
+type Series struct {
+	Name   string
+	Color  string
+	Dashed bool    // Drawn dashed and without dots, e.g. a moving average
+	Points []Point // Oldest first
+}
+
+// Trend is a line chart of values over time
+type Trend struct {
+	Title         string
+	Width, Height float64
+	From, To      time.Time      // X axis range; the span of the points when zero
+	Location      *time.Location // Timezone of the axis labels, default UTC
+	Series        []Series
+	Bands         []Band // Shaded behind the lines, e.g. CategoryBands
+}
+
+// SVG renders the chart as a standalone SVG document
+func (t *Trend) SVG() []byte {
+	c := NewSVGCanvas(t.Width, t.Height, t.Title)
+	t.Draw(c)
+	return c.Bytes()
+}
+
+// Draw draws the chart onto c within (0, 0) to (Width, Height)
+func (t *Trend) Draw(c Canvas) {
+	p := t.plot()
+
+	if p.empty {
+		c.Text(t.Width/2, t.Height/2, "No readings in this range", labelSize+2, labelColor, AnchorMiddle)
+		return
+	}
+
+	t.drawBands(c, p)
+
+	// Horizontal grid lines with the value scale
+	for v := p.yMin; v <= p.yMax; v += p.yStep {
+		y := p.y(v)
+		c.Line(p.left, y, p.right, y, Stroke{Color: gridColor, Width: 0.5})
+		c.Text(p.left-4, y+3, strconv.FormatFloat(v, 'f', -1, 64), labelSize, labelColor, AnchorEnd)
+	}
+
+	// Time scale
+	for _, tick := range p.xTicks(t.location()) {
+		x := p.x(tick.at)
+		c.Line(x, p.bottom, x, p.bottom+3, Stroke{Color: axisColor, Width: 0.5})
+		c.Text(x, p.bottom+13, tick.label, labelSize, labelColor, AnchorMiddle)
+	}
+	c.Line(p.left, p.bottom, p.right, p.bottom, Stroke{Color: axisColor, Width: 0.75})
+	c.Line(p.left, p.top, p.left, p.bottom, Stroke{Color: axisColor, Width: 0.75})
+
+	for _, s := range t.Series {
+		drawSeries(c, p, s, Stroke{Color: s.Color, Width: 1.5, Dashed: s.Dashed})
+	}
+
+	t.drawLegend(c)
+}
+
+// drawSeries draws one line, with dots on the points when they are sparse
+// enough to tell apart
+func drawSeries(c Canvas, p plot, s Series, stroke Stroke) {
+	xs := make([]float64, len(s.Points))
+	ys := make([]float64, len(s.Points))
+	for i, pt := range s.Points {
+		xs[i], ys[i] = p.x(pt.Time), p.y(pt.Value)
+	}
+	if len(xs) > 1 {
+		c.Polyline(xs, ys, stroke)
+	}
+	if len(xs) == 1 || (len(xs) <= 60 && !stroke.Dashed) {
+		for i := range xs {
+			c.Dot(xs[i], ys[i], stroke.Width+0.75, stroke.Color)
+		}
+	}
+}
+
+// drawBands shades the bands within the value axis, labelling those tall
+// enough to hold text
+func (t *Trend) drawBands(c Canvas, p plot) {
+	for _, b := range t.Bands {
+		from, to := math.Max(b.From, p.yMin), math.Min(b.To, p.yMax)
+		if from >= to {
+			continue
+		}
+		top, bottom := p.y(to), p.y(from)
+		c.Rect(p.left, top, p.right-p.left, bottom-top, b.Color)
+		if bottom-top >= labelSize+4 {
+			c.Text(p.right-4, top+labelSize+1, b.Label, labelSize-1, bandLabelColor, AnchorEnd)
+		}
+	}
+}
+
+// drawLegend names the series along the top edge
+func (t *Trend) drawLegend(c Canvas) {
+	x := marginLeft
+	for _, s := range t.Series {
+		c.Line(x, 12, x+14, 12, Stroke{Color: s.Color, Width: 2, Dashed: s.Dashed})
+		c.Text(x+18, 15, s.Name, labelSize, labelColor, AnchorStart)
+		x += 26 + float64(len(s.Name))*labelSize*0.55
+	}
+}
+
+func (t *Trend) location() *time.Location {
+	if t.Location == nil {
+		return time.UTC
+	}
+	return t.Location
+}
+
+// plot maps times and values to canvas coordinates
+type plot struct {
+	left, right, top, bottom float64
+	from, to                 time.Time
+	yMin, yMax, yStep        float64
+	empty                    bool
+}
+
+// plot computes the scales for the chart's data
+func (t *Trend) plot() plot {
+	p := plot{
+		left:   marginLeft,
+		right:  t.Width - marginRight,
+		top:    marginTop,
+		bottom: t.Height - marginBottom,
+		from:   t.From,
+		to:     t.To,
+		empty:  true,
+	}
+
+	lo, hi := math.Inf(1), math.Inf(-1)
+	var first, last time.Time
+	for _, s := range t.Series {
+		for _, pt := range s.Points {
+			lo, hi = math.Min(lo, pt.Value), math.Max(hi, pt.Value)
+			if first.IsZero() || pt.Time.Before(first) {
+				first = pt.Time
+			}
+			if pt.Time.After(last) {
+				last = pt.Time
+			}
+			p.empty = false
+		}
+	}
+	if p.empty {
+		return p
+	}
+
+	if p.from.IsZero() {
+		p.from = first
+	}
+	if p.to.IsZero() {
+		p.to = last
+	}
+	if !p.to.After(p.from) {
+		p.from, p.to = p.from.Add(-12*time.Hour), p.from.Add(12*time.Hour)
+	}
+
+	p.yStep = niceStep((hi - lo) / 5)
+	p.yMin = math.Floor(lo/p.yStep) * p.yStep
+	p.yMax = math.Ceil(hi/p.yStep) * p.yStep
+	if p.yMax == p.yMin {
+		p.yMax += p.yStep
+	}
+	return p
+}
+
+// x returns the horizontal position of t
+func (p plot) x(t time.Time) float64 {
+	return p.left + (p.right-p.left)*float64(t.Sub(p.from))/float64(p.to.Sub(p.from))
+}
+
+// y returns the vertical position of v
+func (p plot) y(v float64) float64 {
+	return p.bottom - (p.bottom-p.top)*(v-p.yMin)/(p.yMax-p.yMin)
+}
+
+// niceStep rounds a raw step up to 5, 10, 20, 25 or 50
+func niceStep(raw float64) float64 {
+	for _, step := range []float64{5, 10, 20, 25, 50} {
+		if raw <= step {
+			return step
+		}
+	}
+	return 100
+}
+
+type tick struct {
+	at    time.Time
+	label string
+}
+
+// xTicks returns up to about seven labelled times: every few hours for short
+// ranges, the first of every few months for long ones, otherwise local
+// midnights a whole number of days apart
+func (p plot) xTicks(loc *time.Location) []tick {
+	span := p.to.Sub(p.from)
+	from := p.from.In(loc)
+
+	var ticks []tick
+	if span <= 48*time.Hour {
+		hours := 6
+		if span <= 12*time.Hour {
+			hours = 2
+		}
+		at := time.Date(from.Year(), from.Month(), from.Day(), from.Hour()-from.Hour()%hours, 0, 0, 0, loc)
+		for ; !at.After(p.to); at = at.Add(time.Duration(hours) * time.Hour) {
+			if !at.Before(p.from) {
+				ticks = append(ticks, tick{at, at.Format("15:04")})
+			}
+		}
+		return ticks
+	}
+
+	if span > 92*24*time.Hour {
+		months := int(math.Ceil(span.Hours() / 24 / 30.44 / 6))
+		at := time.Date(from.Year(), from.Month(), 1, 0, 0, 0, 0, loc)
+		for ; !at.After(p.to); at = at.AddDate(0, months, 0) {
+			if !at.Before(p.from) {
+				ticks = append(ticks, tick{at, at.Format("Jan 2006")})
+			}
+		}
+		return ticks
+	}
+
+	days := int(math.Ceil(span.Hours() / 24 / 6))
+	at := time.Date(from.Year(), from.Month(), from.Day(), 0, 0, 0, 0, loc)
+	for ; !at.After(p.to); at = at.AddDate(0, 0, days) {
+		if !at.Before(p.from) {
+			ticks = append(ticks, tick{at, at.Format("Jan 2")})
+		}
+	}
+	return ticks
+}
@@ -0,0 +1,104 @@
+// File: internal/database/cache.go
+
+package database
+
+import (
+	"sync"
+	"time"
+
+	"bp-tracker/internal/models"
+)
+
+// DefaultStatsCacheTTL bounds how stale cached stats can get: the 7- and
+// 30-day windows slide with time, and writes made by another Lambda
+// instance or process do not invalidate this one's cache.
+const DefaultStatsCacheTTL = time.Minute
+
+// CachedStore wraps a ReadingStore and caches GetStats. The cache is cleared
+// whenever readings are saved, updated or deleted through the store.
+// Cached stats are shared between callers and must be treated as read-only.
+type CachedStore struct {
+	ReadingStore
+
+	ttl time.Duration
+
+	mu         sync.Mutex
+	stats      *models.Stats
+	expires    time.Time
+	generation uint64 // Incremented on every invalidation
+}
+
+// NewCachedStore wraps store with a stats cache that expires after ttl
+func NewCachedStore(store ReadingStore, ttl time.Duration) *CachedStore {
+	return &CachedStore{ReadingStore: store, ttl: ttl}
+}
+
+// Unwrap returns the underlying store
+func (c *CachedStore) Unwrap() ReadingStore {
+	return c.ReadingStore
+}
+
+// GetStats returns cached stats when fresh, otherwise queries the store
+func (c *CachedStore) GetStats() (*models.Stats, error) {
+	c.mu.Lock()
+	if c.stats != nil && time.Now().Before(c.expires) {
+		stats := c.stats
+		c.mu.Unlock()
+		return stats, nil
+	}
+	generation := c.generation
+	c.mu.Unlock()
+
+	stats, err := c.ReadingStore.GetStats()
+	if err != nil {
+		return nil, err
+	}
+
+	// Don't cache a result computed before a concurrent write
+	c.mu.Lock()
+	if c.generation == generation {
+		c.stats = stats
+		c.expires = time.Now().Add(c.ttl)
+	}
+	c.mu.Unlock()
+
+	return stats, nil
+}
+
+// invalidate drops the cached stats
+func (c *CachedStore) invalidate() {
+	c.mu.Lock()
+	c.stats = nil
+	c.generation++
+	c.mu.Unlock()
+}
+
+// SaveReading saves through to the store and invalidates the cache
+func (c *CachedStore) SaveReading(r *models.Reading) error {
+	defer c.invalidate()
+	return c.ReadingStore.SaveReading(r)
+}
+
+// UpdateReading updates through to the store and invalidates the cache
+func (c *CachedStore) UpdateReading(r *models.Reading, expectedVersion int) error {
+	defer c.invalidate()
+	return c.ReadingStore.UpdateReading(r, expectedVersion)
+}
+
+// DeleteReading deletes through to the store and invalidates the cache
+func (c *CachedStore) DeleteReading(id int64) error {
+	defer c.invalidate()
+	return c.ReadingStore.DeleteReading(id)
+}
+
+// SeedReadings seeds through to the store and invalidates the cache
+func (c *CachedStore) SeedReadings(readings []*models.Reading) error {
+	defer c.invalidate()
+	return c.ReadingStore.SeedReadings(readings)
+}
+
+// ClearAllReadings clears the store and invalidates the cache
+func (c *CachedStore) ClearAllReadings() error {
+	defer c.invalidate()
+	return c.ReadingStore.ClearAllReadings()
+}
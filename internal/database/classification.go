@@ -0,0 +1,105 @@
+// File: internal/database/classification.go
+
+package database
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+)
+
+// ClassificationUpdate sets the stored classification of one reading
+type ClassificationUpdate struct {
+	ID      int64
+	Version int // The reading's version when it was classified
+
+	Classification        string
+	ClassificationVersion string
+}
+
+// UpdateClassifications applies updates in one transaction
+func (db *DB) UpdateClassifications(updates []ClassificationUpdate) (int, error) {
+	return updateClassifications(db.DB, postgresQueryDialect, updates)
+}
+
+// UpdateClassifications applies updates in one transaction
+func (db *SQLiteDB) UpdateClassifications(updates []ClassificationUpdate) (int, error) {
+	return updateClassifications(db.DB, sqliteQueryDialect, updates)
+}
+
+// updateClassifications runs one conditional UPDATE per reading within a
+// single transaction and counts the rows that still had their version
+func updateClassifications(db *sql.DB, d queryDialect, updates []ClassificationUpdate) (int, error) {
+	if len(updates) == 0 {
+		return 0, nil
+	}
+
+	ctx := context.Background()
+	tx, err := db.BeginTx(ctx, nil)
+	if err != nil {
+		return 0, fmt.Errorf("error starting transaction for classifications: %w", err)
+	}
+	defer tx.Rollback() // Rollback is a no-op if Commit succeeds
+
+	stmt, err := tx.PrepareContext(ctx, fmt.Sprintf(`
+        UPDATE readings
+        SET classification = %s, classification_version = %s, version = version + 1
+        WHERE id = %s AND version = %s
+    `, d.placeholder(1), d.placeholder(2), d.placeholder(3), d.placeholder(4)))
+	if err != nil {
+		return 0, fmt.Errorf("error preparing classification update: %w", err)
+	}
+	defer stmt.Close()
+
+	updated := 0
+	for _, u := range updates {
+		result, err := stmt.ExecContext(ctx, u.Classification, u.ClassificationVersion, u.ID, u.Version)
+		if err != nil {
+			return 0, fmt.Errorf("error updating classification of reading %d: %w", u.ID, err)
+		}
+		n, err := result.RowsAffected()
+		if err != nil {
+			return 0, fmt.Errorf("error updating classification of reading %d: %w", u.ID, err)
+		}
+		updated += int(n)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return 0, fmt.Errorf("error committing classifications: %w", err)
+	}
+	return updated, nil
+}
+
+// UpdateClassifications applies updates to the readings whose version
+// still matches
+func (m *MemoryStore) UpdateClassifications(updates []ClassificationUpdate) (int, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	byID := make(map[int64]ClassificationUpdate, len(updates))
+	for _, u := range updates {
+		byID[u.ID] = u
+	}
+
+	updated := 0
+	for i, stored := range m.readings {
+		u, ok := byID[stored.ID]
+		if !ok || stored.Version != u.Version {
+			continue
+		}
+		r := copyReading(stored)
+		r.Classification = u.Classification
+		r.ClassificationVersion = u.ClassificationVersion
+		r.Version++
+		m.readings[i] = r
+		updated++
+	}
+	return updated, nil
+}
+
+// UpdateClassifications updates through to the store and invalidates the
+// cache, since the last reading's classification may change
+func (c *CachedStore) UpdateClassifications(updates []ClassificationUpdate) (int, error) {
+	defer c.invalidate()
+	return c.ReadingStore.UpdateClassifications(updates)
+}
@@ -52,7 +52,9 @@ func getSecret(secretARN string) (string, error) {
 	return *result.SecretString, nil
 }
 
-// New creates a new PostgreSQL database connection using environment variables and Secrets Manager
+// New creates a new PostgreSQL database connection using environment variables.
+// The password comes from Secrets Manager when SECRET_ARN is set (Lambda),
+// otherwise from DB_PASSWORD (docker-compose and self-hosted servers).
 func New() (*DB, error) {
 	// Read connection details from environment variables
 	dbHost := os.Getenv("DB_HOST")
@@ -63,14 +65,20 @@ func New() (*DB, error) {
 	dbSSLMode := os.Getenv("DB_SSLMODE")
 
 	// Basic validation
-	if dbHost == "" || dbPort == "" || dbUser == "" || secretARN == "" || dbName == "" || dbSSLMode == "" {
-		return nil, fmt.Errorf("missing required environment variables (DB_HOST, DB_PORT, DB_USER, SECRET_ARN, DB_NAME, DB_SSLMODE)")
+	if dbHost == "" || dbPort == "" || dbUser == "" || dbName == "" || dbSSLMode == "" {
+		return nil, fmt.Errorf("missing required environment variables (DB_HOST, DB_PORT, DB_USER, DB_NAME, DB_SSLMODE)")
 	}
 
-	// Fetch the password from Secrets Manager
-	dbPassword, err := getSecret(secretARN)
-	if err != nil {
-		return nil, fmt.Errorf("failed to retrieve DB password from Secrets Manager (ARN: %s): %w", secretARN, err)
+	dbPassword, passwordSet := os.LookupEnv("DB_PASSWORD")
+	if secretARN != "" {
+		// Fetch the password from Secrets Manager
+		var err error
+		dbPassword, err = getSecret(secretARN)
+		if err != nil {
+			return nil, fmt.Errorf("failed to retrieve DB password from Secrets Manager (ARN: %s): %w", secretARN, err)
+		}
+	} else if !passwordSet {
+		return nil, fmt.Errorf("missing database password: set SECRET_ARN or DB_PASSWORD")
 	}
 
 	// Construct the DSN (Data Source Name)
@@ -97,120 +105,169 @@ func New() (*DB, error) {
 	return &DB{db}, nil
 }
 
-// SaveReading stores a new blood pressure reading using PostgreSQL syntax
+// SaveReading stores a new blood pressure reading and its individual
+// measurements in one transaction, and sets r.ID to the new row's ID
 func (db *DB) SaveReading(r *models.Reading) error {
+	ctx := context.Background()
+	tx, err := db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("error starting transaction for reading: %w", err)
+	}
+	defer tx.Rollback() // Rollback is a no-op if Commit succeeds
+
+	if err := insertReadingPostgres(ctx, tx, r); err != nil {
+		return fmt.Errorf("error saving reading: %w", err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("error committing reading: %w", err)
+	}
+
+	return nil
+}
+
+// insertReadingPostgres inserts r and its measurements within tx
+func insertReadingPostgres(ctx context.Context, tx *sql.Tx, r *models.Reading) error {
 	query := `
-        INSERT INTO readings (timestamp, systolic, diastolic, pulse, classification)
-        VALUES ($1, $2, $3, $4, $5)
-    ` // Changed placeholders, removed strftime
+        INSERT INTO readings (timestamp, systolic, diastolic, pulse, classification, irregular_heartbeat, source, classification_version)
+        VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
+        RETURNING id, version
+    `
 
 	// Pass the time.Time directly, pgx handles it
-	_, err := db.Exec(query, r.Timestamp, r.Systolic, r.Diastolic, r.Pulse, r.Classification)
-	if err != nil {
-		return fmt.Errorf("error saving reading: %w", err)
+	if err := tx.QueryRowContext(ctx, query, r.Timestamp, r.Systolic, r.Diastolic, r.Pulse, r.Classification, r.IrregularHeartbeat, r.Source, r.ClassificationVersion).Scan(&r.ID, &r.Version); err != nil {
+		return err
+	}
+
+	for _, m := range r.Measurements {
+		_, err := tx.ExecContext(ctx, `
+            INSERT INTO reading_measurements (reading_id, seq, systolic, diastolic, pulse, excluded)
+            VALUES ($1, $2, $3, $4, $5, $6)
+        `, r.ID, m.Seq, m.Systolic, m.Diastolic, m.Pulse, m.Excluded)
+		if err != nil {
+			return fmt.Errorf("error saving measurement %d: %w", m.Seq, err)
+		}
 	}
 
 	return nil
 }
 
-// GetStats retrieves blood pressure statistics using PostgreSQL syntax
+// GetStats retrieves the last reading and the 7-day, 30-day and all-time
+// averages in a single round trip (see buildStatsQuery)
 func (db *DB) GetStats() (*models.Stats, error) {
 	stats := &models.Stats{}
 
-	// Get last reading - select timestamp directly, use $ placeholders if needed (none here)
-	lastReadingQuery := `
-        SELECT id, timestamp as ts, systolic, diastolic, pulse, classification
-        FROM readings
-        ORDER BY timestamp DESC
-        LIMIT 1
-    `
-
-	stats.LastReading = &models.Reading{}
-	// Scan directly into time.Time
-	err := db.QueryRow(lastReadingQuery).Scan(
-		&stats.LastReading.ID,
-		&stats.LastReading.Timestamp, // Scan directly into time.Time
-		&stats.LastReading.Systolic,
-		&stats.LastReading.Diastolic,
-		&stats.LastReading.Pulse,
-		&stats.LastReading.Classification,
+	var (
+		id                         sql.NullInt64
+		ts                         sql.NullTime
+		systolic, diastolic, pulse sql.NullInt64
+		classification             sql.NullString
+		version                    sql.NullInt64
+		irregular                  sql.NullBool
+		source                     sql.NullString
+		classificationVersion      sql.NullString
+		windows                    statsWindows
 	)
-	if err == sql.ErrNoRows {
-		stats.LastReading = nil
-		return stats, nil // Return empty stats if no data
-	} else if err != nil {
-		return nil, fmt.Errorf("error getting last reading: %w", err)
-	}
-
-	// Time ranges for averages
-	now := time.Now() // Use standard time.Now() unless timezone logic is critical
-	sevenDaysAgo := now.AddDate(0, 0, -7)
-	thirtyDaysAgo := now.AddDate(0, 0, -30)
-
-	// Helper function for getting averages within a specific time range using PostgreSQL syntax
-	getAverageWithRange := func(start, end time.Time) (*models.Reading, int, error) {
-		query := `
-            SELECT
-                COALESCE(ROUND(AVG(systolic)), 0)::int as avg_systolic,
-                COALESCE(ROUND(AVG(diastolic)), 0)::int as avg_diastolic,
-                COALESCE(ROUND(AVG(pulse)), 0)::int as avg_pulse,
-                COUNT(*) as reading_count
-            FROM readings
-            WHERE timestamp >= $1 AND timestamp < $2
-        ` // Changed placeholders, timestamp comparison, added ::int cast for Scan
+	dest := append([]interface{}{&id, &ts, &systolic, &diastolic, &pulse, &classification, &version, &irregular, &source, &classificationVersion}, windows.dest()...)
 
-		r := &models.Reading{}
-		var count int
-		// Pass time.Time directly
-		err := db.QueryRow(query, start, end).Scan(&r.Systolic, &r.Diastolic, &r.Pulse, &count)
-		if err != nil {
-			if err == sql.ErrNoRows && count == 0 {
-				return nil, 0, nil
-			}
-			return nil, 0, fmt.Errorf("error executing average query for range %v to %v: %w", start, end, err)
-		}
-		if count == 0 {
-			return nil, 0, nil
-		}
+	query := buildStatsQuery(postgresQueryDialect)
+	if err := db.QueryRow(query, statsWindowArgs(postgresQueryDialect, time.Now())...).Scan(dest...); err != nil {
+		return nil, fmt.Errorf("error getting stats: %w", err)
+	}
+	if !id.Valid {
+		return stats, nil // Return empty stats if no data
+	}
 
-		return r, count, nil
+	stats.LastReading = &models.Reading{
+		ID:                    id.Int64,
+		Timestamp:             ts.Time,
+		Systolic:              int(systolic.Int64),
+		Diastolic:             int(diastolic.Int64),
+		Pulse:                 int(pulse.Int64),
+		Classification:        classification.String,
+		Version:               int(version.Int64),
+		IrregularHeartbeat:    irregular.Bool,
+		Source:                source.String,
+		ClassificationVersion: classificationVersion.String,
 	}
+	windows.apply(stats)
 
-	// Get averages for different time periods
-	stats.SevenDayAvg, stats.SevenDayCount, err = getAverageWithRange(sevenDaysAgo, now)
-	if err != nil {
-		log.Printf("Error getting 7-day average: %v\n", err)
-		return nil, fmt.Errorf("error calculating 7-day average: %w", err)
+	return stats, nil
+}
+
+// summaryColumns aggregates systolic, diastolic and pulse in the column
+// order scanned by summaryDest
+const summaryColumns = `
+            COUNT(*),
+            COALESCE(ROUND(AVG(systolic)), 0)::int, COALESCE(MIN(systolic), 0), COALESCE(MAX(systolic), 0),
+            COALESCE(ROUND(AVG(diastolic)), 0)::int, COALESCE(MIN(diastolic), 0), COALESCE(MAX(diastolic), 0),
+            COALESCE(ROUND(AVG(pulse)), 0)::int, COALESCE(MIN(pulse), 0), COALESCE(MAX(pulse), 0)`
+
+// summaryDest returns Scan destinations for summaryColumns
+func summaryDest(s *models.Summary) []interface{} {
+	return []interface{}{
+		&s.Count,
+		&s.Systolic.Avg, &s.Systolic.Min, &s.Systolic.Max,
+		&s.Diastolic.Avg, &s.Diastolic.Min, &s.Diastolic.Max,
+		&s.Pulse.Avg, &s.Pulse.Min, &s.Pulse.Max,
 	}
+}
 
-	stats.ThirtyDayAvg, stats.ThirtyDayCount, err = getAverageWithRange(thirtyDaysAgo, now)
+// GetRangeStats aggregates the range in a single query. Buckets are computed
+// with date_trunc on the local time in q.Location, and GROUPING SETS adds
+// the overall summary as the row with a NULL bucket.
+func (db *DB) GetRangeStats(q StatsQuery) (*models.RangeStats, error) {
+	q, err := q.normalize()
 	if err != nil {
-		log.Printf("Error getting 30-day average: %v\n", err)
-		return nil, fmt.Errorf("error calculating 30-day average: %w", err)
+		return nil, err
 	}
+	stats := newRangeStats(q)
 
-	// All time average using PostgreSQL syntax
-	allTimeQuery := `
-        SELECT
-            COALESCE(ROUND(AVG(systolic)), 0)::int as avg_systolic,
-            COALESCE(ROUND(AVG(diastolic)), 0)::int as avg_diastolic,
-            COALESCE(ROUND(AVG(pulse)), 0)::int as avg_pulse,
-            COUNT(*) as reading_count
+	// time.Time{} is year 1, which PostgreSQL handles as "all time"
+	if q.GroupBy == GroupByNone {
+		query := `SELECT` + summaryColumns + `
         FROM readings
-    `
-	r := &models.Reading{}
-	var count int
-	err = db.QueryRow(allTimeQuery).Scan(&r.Systolic, &r.Diastolic, &r.Pulse, &count)
+        WHERE timestamp >= $1 AND timestamp < $2`
+		if err := db.QueryRow(query, q.From, q.To).Scan(summaryDest(&stats.Summary)...); err != nil {
+			return nil, fmt.Errorf("error calculating range stats: %w", err)
+		}
+		return stats, nil
+	}
+
+	query := `
+        WITH bucketed AS (
+            SELECT date_trunc($1, timestamp AT TIME ZONE $2) AS bucket, systolic, diastolic, pulse
+            FROM readings
+            WHERE timestamp >= $3 AND timestamp < $4
+        )
+        SELECT bucket,` + summaryColumns + `
+        FROM bucketed
+        GROUP BY GROUPING SETS ((bucket), ())
+        ORDER BY bucket NULLS FIRST`
+
+	rows, err := db.Query(query, string(q.GroupBy), q.Location.String(), q.From, q.To)
 	if err != nil {
-		if err == sql.ErrNoRows && count == 0 {
-			// No data, do nothing
-		} else {
-			return nil, fmt.Errorf("error getting all-time average: %w", err)
+		return nil, fmt.Errorf("error calculating grouped range stats: %w", err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var bucket sql.NullTime
+		var s models.Summary
+		if err := rows.Scan(append([]interface{}{&bucket}, summaryDest(&s)...)...); err != nil {
+			return nil, fmt.Errorf("error scanning range stats: %w", err)
 		}
+		if !bucket.Valid {
+			stats.Summary = s
+			continue
+		}
+		// The bucket is a local wall-clock time without a zone
+		b := bucket.Time
+		start := time.Date(b.Year(), b.Month(), b.Day(), 0, 0, 0, 0, q.Location)
+		stats.Buckets = append(stats.Buckets, models.StatsBucket{Start: start, End: q.GroupBy.bucketEnd(start), Summary: s})
 	}
-	if count > 0 {
-		stats.AllTimeAvg = r
-		stats.AllTimeCount = count
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating range stats: %w", err)
 	}
 
 	return stats, nil
@@ -219,7 +276,7 @@ func (db *DB) GetStats() (*models.Stats, error) {
 // GetAllReadings retrieves all readings using PostgreSQL syntax
 func (db *DB) GetAllReadings() ([]*models.Reading, error) {
 	query := `
-        SELECT id, timestamp as ts, systolic, diastolic, pulse, classification
+        SELECT id, timestamp as ts, systolic, diastolic, pulse, classification, version, irregular_heartbeat, source, classification_version
         FROM readings
         ORDER BY timestamp DESC
     ` // Removed datetime(), select timestamp directly
@@ -234,7 +291,7 @@ func (db *DB) GetAllReadings() ([]*models.Reading, error) {
 	for rows.Next() {
 		r := &models.Reading{}
 		// Scan directly into time.Time
-		err := rows.Scan(&r.ID, &r.Timestamp, &r.Systolic, &r.Diastolic, &r.Pulse, &r.Classification)
+		err := rows.Scan(&r.ID, &r.Timestamp, &r.Systolic, &r.Diastolic, &r.Pulse, &r.Classification, &r.Version, &r.IrregularHeartbeat, &r.Source, &r.ClassificationVersion)
 		if err != nil {
 			return nil, fmt.Errorf("error scanning reading: %w", err)
 		}
@@ -245,9 +302,159 @@ func (db *DB) GetAllReadings() ([]*models.Reading, error) {
 		return nil, fmt.Errorf("error iterating readings: %w", err)
 	}
 
+	measurements, err := db.getMeasurements()
+	if err != nil {
+		return nil, err
+	}
+	attachMeasurements(readings, measurements)
+
 	return readings, nil
 }
 
+// QueryReadings returns one page of filtered readings with their measurements
+func (db *DB) QueryReadings(q ReadingQuery) (*ReadingPage, error) {
+	q, c, err := q.normalize()
+	if err != nil {
+		return nil, err
+	}
+
+	query, args := buildReadingsQuery(q, c, postgresQueryDialect)
+	rows, err := db.Query(query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("error querying readings: %w", err)
+	}
+	defer rows.Close()
+
+	var readings []*models.Reading
+	for rows.Next() {
+		r, err := scanPostgresReading(rows)
+		if err != nil {
+			return nil, fmt.Errorf("error scanning reading: %w", err)
+		}
+		readings = append(readings, r)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating readings: %w", err)
+	}
+
+	page := newPage(readings, q)
+	if err := attachMeasurementsFor(db.DB, postgresQueryDialect, page.Readings); err != nil {
+		return nil, err
+	}
+	return page, nil
+}
+
+// scanPostgresReading scans the columns selected by buildReadingsQuery
+func scanPostgresReading(row rowScanner) (*models.Reading, error) {
+	r := &models.Reading{}
+	if err := row.Scan(&r.ID, &r.Timestamp, &r.Systolic, &r.Diastolic, &r.Pulse, &r.Classification, &r.Version, &r.IrregularHeartbeat, &r.Source, &r.ClassificationVersion); err != nil {
+		return nil, err
+	}
+	return r, nil
+}
+
+// GetReading retrieves a single reading and its measurements by ID
+func (db *DB) GetReading(id int64) (*models.Reading, error) {
+	r := &models.Reading{}
+	err := db.QueryRow(`
+        SELECT id, timestamp, systolic, diastolic, pulse, classification, version, irregular_heartbeat, source, classification_version
+        FROM readings
+        WHERE id = $1
+    `, id).Scan(&r.ID, &r.Timestamp, &r.Systolic, &r.Diastolic, &r.Pulse, &r.Classification, &r.Version, &r.IrregularHeartbeat, &r.Source, &r.ClassificationVersion)
+	if err == sql.ErrNoRows {
+		return nil, fmt.Errorf("no reading found with id %d: %w", id, ErrNotFound)
+	} else if err != nil {
+		return nil, fmt.Errorf("error getting reading %d: %w", id, err)
+	}
+
+	rows, err := db.Query(`
+        SELECT reading_id, seq, systolic, diastolic, pulse, excluded
+        FROM reading_measurements
+        WHERE reading_id = $1
+        ORDER BY seq
+    `, id)
+	if err != nil {
+		return nil, fmt.Errorf("error querying measurements for reading %d: %w", id, err)
+	}
+	defer rows.Close()
+
+	measurements, err := scanMeasurements(rows)
+	if err != nil {
+		return nil, err
+	}
+	attachMeasurements([]*models.Reading{r}, measurements)
+
+	return r, nil
+}
+
+// UpdateReading overwrites a reading if its version still matches
+// expectedVersion, replacing its measurements when r.Measurements is non-nil
+func (db *DB) UpdateReading(r *models.Reading, expectedVersion int) error {
+	ctx := context.Background()
+	tx, err := db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("error starting transaction for update: %w", err)
+	}
+	defer tx.Rollback() // Rollback is a no-op if Commit succeeds
+
+	err = tx.QueryRowContext(ctx, `
+        UPDATE readings
+        SET timestamp = $1, systolic = $2, diastolic = $3, pulse = $4, classification = $5, classification_version = $6, version = version + 1
+        WHERE id = $7 AND version = $8
+        RETURNING version
+    `, r.Timestamp, r.Systolic, r.Diastolic, r.Pulse, r.Classification, r.ClassificationVersion, r.ID, expectedVersion).Scan(&r.Version)
+	if err == sql.ErrNoRows {
+		// Distinguish a missing row from a stale version
+		var current int
+		err := tx.QueryRowContext(ctx, `SELECT version FROM readings WHERE id = $1`, r.ID).Scan(&current)
+		if err == sql.ErrNoRows {
+			return fmt.Errorf("no reading found with id %d: %w", r.ID, ErrNotFound)
+		} else if err != nil {
+			return fmt.Errorf("error checking version of reading %d: %w", r.ID, err)
+		}
+		return fmt.Errorf("reading %d is at version %d, not %d: %w", r.ID, current, expectedVersion, ErrVersionConflict)
+	} else if err != nil {
+		return fmt.Errorf("error updating reading %d: %w", r.ID, err)
+	}
+
+	if r.Measurements != nil {
+		if _, err := tx.ExecContext(ctx, `DELETE FROM reading_measurements WHERE reading_id = $1`, r.ID); err != nil {
+			return fmt.Errorf("error replacing measurements for reading %d: %w", r.ID, err)
+		}
+		for _, m := range r.Measurements {
+			_, err := tx.ExecContext(ctx, `
+                INSERT INTO reading_measurements (reading_id, seq, systolic, diastolic, pulse, excluded)
+                VALUES ($1, $2, $3, $4, $5, $6)
+            `, r.ID, m.Seq, m.Systolic, m.Diastolic, m.Pulse, m.Excluded)
+			if err != nil {
+				return fmt.Errorf("error saving measurement %d: %w", m.Seq, err)
+			}
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("error committing update: %w", err)
+	}
+
+	log.Printf("Successfully updated reading with id %d (now version %d)\n", r.ID, r.Version)
+	return nil
+}
+
+// getMeasurements loads every stored measurement keyed by reading ID
+func (db *DB) getMeasurements() (map[int64][]models.Measurement, error) {
+	rows, err := db.Query(`
+        SELECT reading_id, seq, systolic, diastolic, pulse, excluded
+        FROM reading_measurements
+        ORDER BY reading_id, seq
+    `)
+	if err != nil {
+		return nil, fmt.Errorf("error querying measurements: %w", err)
+	}
+	defer rows.Close()
+
+	return scanMeasurements(rows)
+}
+
 // ClearAllReadings deletes all entries from the readings table.
 // WARNING: Use with caution, typically only for testing/development.
 func (db *DB) ClearAllReadings() error {
@@ -271,19 +478,8 @@ func (db *DB) SeedReadings(readings []*models.Reading) error {
 	// Defer rollback in case of error
 	defer tx.Rollback() // Rollback is a no-op if Commit succeeds
 
-	query := `
-        INSERT INTO readings (timestamp, systolic, diastolic, pulse, classification)
-        VALUES ($1, $2, $3, $4, $5)
-    `
-	stmt, err := tx.PrepareContext(ctx, query)
-	if err != nil {
-		return fmt.Errorf("error preparing statement for seeding: %w", err)
-	}
-	defer stmt.Close()
-
 	for _, r := range readings {
-		_, err := stmt.ExecContext(ctx, r.Timestamp, r.Systolic, r.Diastolic, r.Pulse, r.Classification)
-		if err != nil {
+		if err := insertReadingPostgres(ctx, tx, r); err != nil {
 			// Error occurred, transaction will be rolled back by defer
 			return fmt.Errorf("error inserting seed reading (timestamp %v): %w", r.Timestamp, err)
 		}
@@ -313,7 +509,7 @@ func (db *DB) DeleteReading(id int64) error {
 	}
 
 	if rowsAffected == 0 {
-		return fmt.Errorf("no reading found with id %d to delete", id)
+		return fmt.Errorf("no reading found with id %d to delete: %w", id, ErrNotFound)
 	}
 
 	log.Printf("Successfully deleted reading with id %d (%d rows affected)\n", id, rowsAffected)
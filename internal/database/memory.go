@@ -0,0 +1,296 @@
+// File: internal/database/memory.go
+
+package database
+
+import (
+	"fmt"
+	"log"
+	"sort"
+	"sync"
+	"time"
+
+	"bp-tracker/internal/models"
+)
+
+// MemoryStore is an in-memory ReadingStore for local development and tests.
+// Data is kept in process memory only and is lost on restart.
+type MemoryStore struct {
+	mu       sync.RWMutex
+	readings []*models.Reading
+	nextID   int64
+}
+
+// NewMemoryStore creates an empty in-memory store
+func NewMemoryStore() *MemoryStore {
+	return &MemoryStore{nextID: 1}
+}
+
+// SaveReading stores a copy of the reading and sets r.ID
+func (m *MemoryStore) SaveReading(r *models.Reading) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.insertLocked(r)
+	return nil
+}
+
+// insertLocked appends a copy of r and sets r.ID. Callers must hold the write lock.
+func (m *MemoryStore) insertLocked(r *models.Reading) {
+	r.ID = m.nextID
+	r.Version = 1
+	m.nextID++
+	m.readings = append(m.readings, copyReading(r))
+}
+
+// copyReading returns a deep copy so callers cannot mutate stored data
+func copyReading(r *models.Reading) *models.Reading {
+	c := *r
+	if r.Measurements != nil {
+		c.Measurements = append([]models.Measurement(nil), r.Measurements...)
+	}
+	return &c
+}
+
+// GetStats computes the same statistics as the SQL backends:
+// the most recent reading and rounded averages over 7 days, 30 days and all time.
+func (m *MemoryStore) GetStats() (*models.Stats, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	return StatsAsOf(m.sortedLocked(), time.Now()), nil
+}
+
+// StatsAsOf computes GetStats for readings (newest first) as if it were now:
+// the most recent reading and rounded averages over the 7 and 30 days
+// before now and over all of readings
+func StatsAsOf(readings []*models.Reading, now time.Time) *models.Stats {
+	stats := &models.Stats{}
+	if len(readings) == 0 {
+		return stats
+	}
+	stats.LastReading = copyReading(readings[0])
+
+	sevenDaysAgo := now.AddDate(0, 0, -7)
+	thirtyDaysAgo := now.AddDate(0, 0, -30)
+
+	var sevenDay, thirtyDay []*models.Reading
+	for _, r := range readings {
+		// Same half-open [start, now) windows as the SQL queries
+		if r.Timestamp.Before(now) && !r.Timestamp.Before(sevenDaysAgo) {
+			sevenDay = append(sevenDay, r)
+		}
+		if r.Timestamp.Before(now) && !r.Timestamp.Before(thirtyDaysAgo) {
+			thirtyDay = append(thirtyDay, r)
+		}
+	}
+
+	stats.SevenDayAvg, stats.SevenDayCount = averageOf(sevenDay)
+	stats.ThirtyDayAvg, stats.ThirtyDayCount = averageOf(thirtyDay)
+	stats.AllTimeAvg, stats.AllTimeCount = averageOf(readings)
+
+	return stats
+}
+
+// GetRangeStats aggregates the readings in the range using the same
+// bucketing and rounding as the SQL backends
+func (m *MemoryStore) GetRangeStats(q StatsQuery) (*models.RangeStats, error) {
+	q, err := q.normalize()
+	if err != nil {
+		return nil, err
+	}
+
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	var readings []*models.Reading
+	sorted := m.sortedLocked()
+	for i := len(sorted) - 1; i >= 0; i-- { // oldest first
+		r := sorted[i]
+		if (q.From.IsZero() || !r.Timestamp.Before(q.From)) && r.Timestamp.Before(q.To) {
+			readings = append(readings, r)
+		}
+	}
+
+	return groupReadings(readings, q), nil
+}
+
+// GetAllReadings returns copies of all readings, newest first
+func (m *MemoryStore) GetAllReadings() ([]*models.Reading, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	var readings []*models.Reading
+	for _, r := range m.sortedLocked() {
+		readings = append(readings, copyReading(r))
+	}
+	return readings, nil
+}
+
+// QueryReadings returns one page of filtered readings, matching the SQL
+// backends' (timestamp, id) ordering and cursor semantics
+func (m *MemoryStore) QueryReadings(q ReadingQuery) (*ReadingPage, error) {
+	q, c, err := q.normalize()
+	if err != nil {
+		return nil, err
+	}
+
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	// before reports whether a sorts ahead of b in the requested order
+	before := func(a, b *models.Reading) bool {
+		if !a.Timestamp.Equal(b.Timestamp) {
+			return a.Timestamp.Before(b.Timestamp) == (q.Order == SortAsc)
+		}
+		if a.ID == b.ID {
+			return false
+		}
+		return (a.ID < b.ID) == (q.Order == SortAsc)
+	}
+
+	var matched []*models.Reading
+	for _, r := range m.readings {
+		if !q.From.IsZero() && r.Timestamp.Before(q.From) {
+			continue
+		}
+		if !q.To.IsZero() && !r.Timestamp.Before(q.To) {
+			continue
+		}
+		if len(q.Classifications) > 0 && !containsString(q.Classifications, r.Classification) {
+			continue
+		}
+		if c != nil && !before(&models.Reading{ID: c.ID, Timestamp: c.Timestamp}, r) {
+			continue
+		}
+		matched = append(matched, r)
+	}
+	sort.Slice(matched, func(i, j int) bool { return before(matched[i], matched[j]) })
+
+	if len(matched) > q.Limit+1 {
+		matched = matched[:q.Limit+1]
+	}
+	readings := make([]*models.Reading, len(matched))
+	for i, r := range matched {
+		readings[i] = copyReading(r)
+	}
+	return newPage(readings, q), nil
+}
+
+// containsString reports whether s is in list
+func containsString(list []string, s string) bool {
+	for _, v := range list {
+		if v == s {
+			return true
+		}
+	}
+	return false
+}
+
+// GetReading returns a copy of the reading with the given ID
+func (m *MemoryStore) GetReading(id int64) (*models.Reading, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	for _, r := range m.readings {
+		if r.ID == id {
+			return copyReading(r), nil
+		}
+	}
+	return nil, fmt.Errorf("no reading found with id %d: %w", id, ErrNotFound)
+}
+
+// UpdateReading overwrites a reading if its version still matches
+// expectedVersion, replacing its measurements when r.Measurements is non-nil
+func (m *MemoryStore) UpdateReading(r *models.Reading, expectedVersion int) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	for i, stored := range m.readings {
+		if stored.ID != r.ID {
+			continue
+		}
+		if stored.Version != expectedVersion {
+			return fmt.Errorf("reading %d is at version %d, not %d: %w", r.ID, stored.Version, expectedVersion, ErrVersionConflict)
+		}
+
+		updated := copyReading(r)
+		updated.Version = stored.Version + 1
+		if r.Measurements == nil {
+			updated.Measurements = stored.Measurements
+		}
+		m.readings[i] = updated
+		r.Version = updated.Version
+		return nil
+	}
+	return fmt.Errorf("no reading found with id %d: %w", r.ID, ErrNotFound)
+}
+
+// DeleteReading removes the reading with the given ID
+func (m *MemoryStore) DeleteReading(id int64) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	for i, r := range m.readings {
+		if r.ID == id {
+			m.readings = append(m.readings[:i], m.readings[i+1:]...)
+			log.Printf("Successfully deleted reading with id %d from memory store\n", id)
+			return nil
+		}
+	}
+	return fmt.Errorf("no reading found with id %d to delete: %w", id, ErrNotFound)
+}
+
+// SeedReadings inserts all readings under a single lock so the batch is atomic
+func (m *MemoryStore) SeedReadings(readings []*models.Reading) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	for _, r := range readings {
+		m.insertLocked(r)
+	}
+	log.Printf("Successfully seeded %d readings into the memory store.\n", len(readings))
+	return nil
+}
+
+// ClearAllReadings deletes all readings
+func (m *MemoryStore) ClearAllReadings() error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.readings = nil
+	log.Println("Successfully cleared all readings from the memory store.")
+	return nil
+}
+
+// Close is a no-op for the memory store
+func (m *MemoryStore) Close() error {
+	return nil
+}
+
+// sortedLocked returns the stored readings ordered by timestamp, newest first.
+// Callers must hold at least the read lock.
+func (m *MemoryStore) sortedLocked() []*models.Reading {
+	sorted := make([]*models.Reading, len(m.readings))
+	copy(sorted, m.readings)
+	sort.SliceStable(sorted, func(i, j int) bool {
+		return sorted[i].Timestamp.After(sorted[j].Timestamp)
+	})
+	return sorted
+}
+
+// averageOf returns the rounded average of the readings and their count,
+// or nil and 0 when there are none (matching the SQL backends).
+func averageOf(readings []*models.Reading) (*models.Reading, int) {
+	if len(readings) == 0 {
+		return nil, 0
+	}
+
+	ms := make([]models.Measurement, len(readings))
+	for i, r := range readings {
+		ms[i] = models.Measurement{Systolic: r.Systolic, Diastolic: r.Diastolic, Pulse: r.Pulse}
+	}
+
+	avg := models.AverageMeasurements(ms)
+	avg.Measurements = nil
+	return avg, len(readings)
+}
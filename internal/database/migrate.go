@@ -0,0 +1,442 @@
+// File: internal/database/migrate.go
+
+package database
+
+import (
+	"context"
+	"database/sql"
+	"embed"
+	"fmt"
+	"io/fs"
+	"log"
+	"path"
+	"regexp"
+	"sort"
+	"strconv"
+	"time"
+)
+
+// Migrations live in migrations/<dialect>/NNNN_name.up.sql and
+// NNNN_name.down.sql and are embedded so the binary needs no schema files.
+//
+//go:embed migrations/postgres/*.sql migrations/sqlite/*.sql
+var migrationFiles embed.FS
+
+var migrationFileRe = regexp.MustCompile(`^(\d+)_(.+)\.(up|down)\.sql$`)
+
+// postgresMigrationLockID is the pg_advisory_lock key that serializes
+// concurrent migration runs (e.g. two Lambda instances hitting /migrate).
+const postgresMigrationLockID int64 = 0x62702d7472616b // "bp-trak"
+
+// Migration is a single numbered schema change
+type Migration struct {
+	Version int64
+	Name    string
+	UpSQL   string
+	DownSQL string
+}
+
+// MigrationState describes a migration and whether it has been applied
+type MigrationState struct {
+	Version   int64      `json:"version"`
+	Name      string     `json:"name"`
+	Applied   bool       `json:"applied"`
+	AppliedAt *time.Time `json:"applied_at,omitempty"`
+}
+
+// MigrationDirection selects whether migrations are applied or rolled back
+type MigrationDirection string
+
+const (
+	MigrateUp   MigrationDirection = "up"
+	MigrateDown MigrationDirection = "down"
+)
+
+// MigrateOptions controls a migration run
+type MigrateOptions struct {
+	Direction MigrationDirection
+	// Steps limits how many migrations run. Zero means all pending
+	// migrations when going up, and a single migration when going down.
+	Steps int
+	// DryRun reports what would run without changing the database
+	DryRun bool
+}
+
+// MigrationReport summarizes a migration run (or planned run, for dry runs)
+type MigrationReport struct {
+	Direction    MigrationDirection `json:"direction"`
+	DryRun       bool               `json:"dry_run"`
+	StartVersion int64              `json:"start_version"`
+	EndVersion   int64              `json:"end_version"`
+	Migrations   []MigrationState   `json:"migrations"`
+}
+
+// Migratable is implemented by stores whose schema is managed by migrations
+type Migratable interface {
+	Migrator() (*Migrator, error)
+}
+
+// migrationDialect holds the SQL that differs between database engines
+type migrationDialect struct {
+	dir           string
+	createTable   string
+	tableExists   string
+	insertVersion string
+	deleteVersion string
+	lock          func(ctx context.Context, conn *sql.Conn) error
+	unlock        func(ctx context.Context, conn *sql.Conn) error
+}
+
+var postgresDialect = migrationDialect{
+	dir: "migrations/postgres",
+	createTable: `
+        CREATE TABLE IF NOT EXISTS schema_migrations (
+            version BIGINT PRIMARY KEY,
+            name VARCHAR NOT NULL,
+            applied_at TIMESTAMPTZ NOT NULL DEFAULT CURRENT_TIMESTAMP
+        )`,
+	tableExists:   `SELECT to_regclass('schema_migrations') IS NOT NULL`,
+	insertVersion: `INSERT INTO schema_migrations (version, name) VALUES ($1, $2)`,
+	deleteVersion: `DELETE FROM schema_migrations WHERE version = $1`,
+	lock: func(ctx context.Context, conn *sql.Conn) error {
+		_, err := conn.ExecContext(ctx, `SELECT pg_advisory_lock($1)`, postgresMigrationLockID)
+		return err
+	},
+	unlock: func(ctx context.Context, conn *sql.Conn) error {
+		_, err := conn.ExecContext(ctx, `SELECT pg_advisory_unlock($1)`, postgresMigrationLockID)
+		return err
+	},
+}
+
+// SQLite has no advisory locks; it allows a single writer per file, and the
+// schema_migrations primary key rejects a migration applied twice.
+var sqliteDialect = migrationDialect{
+	dir: "migrations/sqlite",
+	createTable: `
+        CREATE TABLE IF NOT EXISTS schema_migrations (
+            version INTEGER PRIMARY KEY,
+            name TEXT NOT NULL,
+            applied_at INTEGER NOT NULL DEFAULT (strftime('%s', 'now'))
+        )`,
+	tableExists:   `SELECT COUNT(*) > 0 FROM sqlite_master WHERE type = 'table' AND name = 'schema_migrations'`,
+	insertVersion: `INSERT INTO schema_migrations (version, name) VALUES (?, ?)`,
+	deleteVersion: `DELETE FROM schema_migrations WHERE version = ?`,
+	lock:          func(ctx context.Context, conn *sql.Conn) error { return nil },
+	unlock:        func(ctx context.Context, conn *sql.Conn) error { return nil },
+}
+
+// Migrator applies the embedded migrations for one database engine
+type Migrator struct {
+	db         *sql.DB
+	dialect    migrationDialect
+	migrations []Migration
+}
+
+// NewMigrator creates a Migrator for an open connection pool.
+// driver must be "postgres" or "sqlite".
+func NewMigrator(db *sql.DB, driver string) (*Migrator, error) {
+	var d migrationDialect
+	switch driver {
+	case "postgres":
+		d = postgresDialect
+	case "sqlite":
+		d = sqliteDialect
+	default:
+		return nil, fmt.Errorf("migrations are not available for driver %q", driver)
+	}
+
+	migrations, err := loadMigrations(d.dir)
+	if err != nil {
+		return nil, err
+	}
+	return &Migrator{db: db, dialect: d, migrations: migrations}, nil
+}
+
+// Migrator returns a Migrator for the PostgreSQL database
+func (db *DB) Migrator() (*Migrator, error) {
+	return NewMigrator(db.DB, "postgres")
+}
+
+// Migrator returns a Migrator for the SQLite database
+func (db *SQLiteDB) Migrator() (*Migrator, error) {
+	return NewMigrator(db.DB, "sqlite")
+}
+
+// loadMigrations reads and pairs the up/down files in dir, ordered by version
+func loadMigrations(dir string) ([]Migration, error) {
+	entries, err := fs.ReadDir(migrationFiles, dir)
+	if err != nil {
+		return nil, fmt.Errorf("error reading embedded migrations in %s: %w", dir, err)
+	}
+
+	byVersion := map[int64]*Migration{}
+	for _, entry := range entries {
+		match := migrationFileRe.FindStringSubmatch(entry.Name())
+		if match == nil {
+			return nil, fmt.Errorf("invalid migration file name %q (want NNNN_name.up.sql or NNNN_name.down.sql)", entry.Name())
+		}
+		version, _ := strconv.ParseInt(match[1], 10, 64)
+
+		content, err := fs.ReadFile(migrationFiles, path.Join(dir, entry.Name()))
+		if err != nil {
+			return nil, fmt.Errorf("error reading migration %s: %w", entry.Name(), err)
+		}
+
+		m, ok := byVersion[version]
+		if !ok {
+			m = &Migration{Version: version, Name: match[2]}
+			byVersion[version] = m
+		} else if m.Name != match[2] {
+			return nil, fmt.Errorf("migration version %d has conflicting names %q and %q", version, m.Name, match[2])
+		}
+
+		if match[3] == "up" {
+			m.UpSQL = string(content)
+		} else {
+			m.DownSQL = string(content)
+		}
+	}
+
+	migrations := make([]Migration, 0, len(byVersion))
+	for _, m := range byVersion {
+		if m.UpSQL == "" || m.DownSQL == "" {
+			return nil, fmt.Errorf("migration %04d_%s must have both up and down files", m.Version, m.Name)
+		}
+		migrations = append(migrations, *m)
+	}
+	sort.Slice(migrations, func(i, j int) bool { return migrations[i].Version < migrations[j].Version })
+
+	return migrations, nil
+}
+
+// Status lists every known migration and whether it has been applied
+func (m *Migrator) Status(ctx context.Context) ([]MigrationState, error) {
+	conn, err := m.db.Conn(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("error acquiring connection for migration status: %w", err)
+	}
+	defer conn.Close()
+
+	applied, err := m.appliedVersions(ctx, conn)
+	if err != nil {
+		return nil, err
+	}
+	return m.states(applied), nil
+}
+
+// Migrate applies or rolls back migrations according to opts. Each
+// migration runs in its own transaction while a database-wide lock is held.
+func (m *Migrator) Migrate(ctx context.Context, opts MigrateOptions) (*MigrationReport, error) {
+	if opts.Direction == "" {
+		opts.Direction = MigrateUp
+	}
+	if opts.Direction != MigrateUp && opts.Direction != MigrateDown {
+		return nil, fmt.Errorf("invalid migration direction %q", opts.Direction)
+	}
+	if opts.Steps < 0 {
+		return nil, fmt.Errorf("migration steps must not be negative")
+	}
+
+	conn, err := m.db.Conn(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("error acquiring connection for migrations: %w", err)
+	}
+	defer conn.Close()
+
+	if err := m.dialect.lock(ctx, conn); err != nil {
+		return nil, fmt.Errorf("error acquiring migration lock: %w", err)
+	}
+	defer func() {
+		if err := m.dialect.unlock(context.Background(), conn); err != nil {
+			log.Printf("Warning: failed to release migration lock: %v", err)
+		}
+	}()
+
+	if !opts.DryRun {
+		if _, err := conn.ExecContext(ctx, m.dialect.createTable); err != nil {
+			return nil, fmt.Errorf("error creating schema_migrations table: %w", err)
+		}
+	}
+
+	applied, err := m.appliedVersions(ctx, conn)
+	if err != nil {
+		return nil, err
+	}
+
+	plan := m.plan(applied, opts)
+	report := &MigrationReport{
+		Direction:    opts.Direction,
+		DryRun:       opts.DryRun,
+		StartVersion: currentVersion(applied),
+		Migrations:   []MigrationState{},
+	}
+
+	for _, mig := range plan {
+		state := MigrationState{Version: mig.Version, Name: mig.Name}
+		if !opts.DryRun {
+			if err := m.run(ctx, conn, mig, opts.Direction); err != nil {
+				return nil, err
+			}
+			if opts.Direction == MigrateUp {
+				now := time.Now()
+				applied[mig.Version] = now
+				state.Applied = true
+				state.AppliedAt = &now
+			} else {
+				delete(applied, mig.Version)
+			}
+		}
+		report.Migrations = append(report.Migrations, state)
+	}
+
+	report.EndVersion = currentVersion(applied)
+	if opts.DryRun {
+		report.EndVersion = plannedVersion(report.StartVersion, plan, opts.Direction, applied)
+	}
+
+	return report, nil
+}
+
+// plan returns the migrations to run, in execution order
+func (m *Migrator) plan(applied map[int64]time.Time, opts MigrateOptions) []Migration {
+	var plan []Migration
+	limit := opts.Steps
+	if opts.Direction == MigrateUp {
+		for _, mig := range m.migrations {
+			if _, ok := applied[mig.Version]; !ok {
+				plan = append(plan, mig)
+			}
+		}
+	} else {
+		if limit == 0 {
+			limit = 1
+		}
+		for i := len(m.migrations) - 1; i >= 0; i-- {
+			if _, ok := applied[m.migrations[i].Version]; ok {
+				plan = append(plan, m.migrations[i])
+			}
+		}
+	}
+
+	if limit > 0 && len(plan) > limit {
+		plan = plan[:limit]
+	}
+	return plan
+}
+
+// run executes one migration and records it in schema_migrations atomically
+func (m *Migrator) run(ctx context.Context, conn *sql.Conn, mig Migration, direction MigrationDirection) error {
+	tx, err := conn.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("error starting transaction for migration %04d_%s: %w", mig.Version, mig.Name, err)
+	}
+	defer tx.Rollback() // Rollback is a no-op if Commit succeeds
+
+	script, record, args := mig.UpSQL, m.dialect.insertVersion, []interface{}{mig.Version, mig.Name}
+	if direction == MigrateDown {
+		script, record, args = mig.DownSQL, m.dialect.deleteVersion, []interface{}{mig.Version}
+	}
+
+	if _, err := tx.ExecContext(ctx, script); err != nil {
+		return fmt.Errorf("error running %s migration %04d_%s: %w", direction, mig.Version, mig.Name, err)
+	}
+	if _, err := tx.ExecContext(ctx, record, args...); err != nil {
+		return fmt.Errorf("error recording %s migration %04d_%s: %w", direction, mig.Version, mig.Name, err)
+	}
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("error committing migration %04d_%s: %w", mig.Version, mig.Name, err)
+	}
+
+	log.Printf("Migration %04d_%s %s applied successfully\n", mig.Version, mig.Name, direction)
+	return nil
+}
+
+// appliedVersions reads schema_migrations. A missing table means nothing
+// has been applied yet.
+func (m *Migrator) appliedVersions(ctx context.Context, conn *sql.Conn) (map[int64]time.Time, error) {
+	applied := map[int64]time.Time{}
+
+	var exists bool
+	if err := conn.QueryRowContext(ctx, m.dialect.tableExists).Scan(&exists); err != nil {
+		return nil, fmt.Errorf("error checking for schema_migrations table: %w", err)
+	}
+	if !exists {
+		return applied, nil
+	}
+
+	rows, err := conn.QueryContext(ctx, `SELECT version, applied_at FROM schema_migrations`)
+	if err != nil {
+		return nil, fmt.Errorf("error querying schema_migrations: %w", err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var version int64
+		var appliedAt interface{}
+		if err := rows.Scan(&version, &appliedAt); err != nil {
+			return nil, fmt.Errorf("error scanning schema_migrations: %w", err)
+		}
+		// PostgreSQL returns a time.Time; SQLite stores Unix seconds
+		switch v := appliedAt.(type) {
+		case time.Time:
+			applied[version] = v
+		case int64:
+			applied[version] = time.Unix(v, 0)
+		default:
+			applied[version] = time.Time{}
+		}
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating schema_migrations: %w", err)
+	}
+
+	return applied, nil
+}
+
+// states merges the embedded migrations with the applied set
+func (m *Migrator) states(applied map[int64]time.Time) []MigrationState {
+	states := make([]MigrationState, 0, len(m.migrations))
+	for _, mig := range m.migrations {
+		state := MigrationState{Version: mig.Version, Name: mig.Name}
+		if at, ok := applied[mig.Version]; ok {
+			at := at
+			state.Applied = true
+			state.AppliedAt = &at
+		}
+		states = append(states, state)
+	}
+	return states
+}
+
+// currentVersion returns the highest applied version, or 0 if none
+func currentVersion(applied map[int64]time.Time) int64 {
+	var current int64
+	for v := range applied {
+		if v > current {
+			current = v
+		}
+	}
+	return current
+}
+
+// plannedVersion computes the version a dry run would end at
+func plannedVersion(start int64, plan []Migration, direction MigrationDirection, applied map[int64]time.Time) int64 {
+	if len(plan) == 0 {
+		return start
+	}
+	if direction == MigrateUp {
+		if last := plan[len(plan)-1].Version; last > start {
+			return last
+		}
+		return start
+	}
+
+	remaining := map[int64]time.Time{}
+	for v, at := range applied {
+		remaining[v] = at
+	}
+	for _, mig := range plan {
+		delete(remaining, mig.Version)
+	}
+	return currentVersion(remaining)
+}
@@ -0,0 +1,4 @@
+-- File: internal/database/migrations/postgres/0001_create_readings.down.sql
+
+DROP INDEX IF EXISTS idx_readings_timestamp;
+DROP TABLE IF EXISTS readings;
@@ -1,17 +1,18 @@
--- File: internal/database/schema.sql (Modified for PostgreSQL)
+-- File: internal/database/migrations/postgres/0001_create_readings.up.sql
+-- Baseline schema. IF NOT EXISTS lets databases created from the old
+-- schema.sql adopt versioned migrations without changes.
 
 CREATE TABLE IF NOT EXISTS readings (
-    id SERIAL PRIMARY KEY, -- Changed from AUTOINCREMENT
-    timestamp TIMESTAMPTZ NOT NULL DEFAULT CURRENT_TIMESTAMP, -- Changed type and default
+    id SERIAL PRIMARY KEY,
+    timestamp TIMESTAMPTZ NOT NULL DEFAULT CURRENT_TIMESTAMP,
     systolic INTEGER NOT NULL,
     diastolic INTEGER NOT NULL,
     pulse INTEGER NOT NULL,
-    classification VARCHAR NOT NULL, -- Changed from TEXT (VARCHAR is fine too)
+    classification VARCHAR NOT NULL,
     CONSTRAINT valid_systolic CHECK (systolic BETWEEN 60 AND 250),
     CONSTRAINT valid_diastolic CHECK (diastolic BETWEEN 40 AND 150),
     CONSTRAINT valid_pulse CHECK (pulse BETWEEN 40 AND 200)
 );
 
 -- Index for faster querying of recent readings
--- Syntax is the same for PostgreSQL
 CREATE INDEX IF NOT EXISTS idx_readings_timestamp ON readings(timestamp);
@@ -0,0 +1,3 @@
+-- File: internal/database/migrations/postgres/0002_reading_measurements.down.sql
+
+DROP TABLE IF EXISTS reading_measurements;
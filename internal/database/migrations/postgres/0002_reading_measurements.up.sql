@@ -0,0 +1,16 @@
+-- File: internal/database/migrations/postgres/0002_reading_measurements.up.sql
+-- Individual cuff measurements taken during a session. The readings row keeps
+-- the (rounded) session average; these rows keep the raw values.
+
+CREATE TABLE IF NOT EXISTS reading_measurements (
+    id SERIAL PRIMARY KEY,
+    reading_id INTEGER NOT NULL REFERENCES readings(id) ON DELETE CASCADE,
+    seq SMALLINT NOT NULL, -- 1-based order within the session
+    systolic INTEGER NOT NULL,
+    diastolic INTEGER NOT NULL,
+    pulse INTEGER NOT NULL,
+    CONSTRAINT uq_reading_measurements_seq UNIQUE (reading_id, seq),
+    CONSTRAINT valid_measurement_systolic CHECK (systolic BETWEEN 60 AND 250),
+    CONSTRAINT valid_measurement_diastolic CHECK (diastolic BETWEEN 40 AND 150),
+    CONSTRAINT valid_measurement_pulse CHECK (pulse BETWEEN 40 AND 200)
+);
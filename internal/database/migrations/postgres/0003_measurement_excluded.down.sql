@@ -0,0 +1,3 @@
+-- File: internal/database/migrations/postgres/0003_measurement_excluded.down.sql
+
+ALTER TABLE reading_measurements DROP COLUMN excluded;
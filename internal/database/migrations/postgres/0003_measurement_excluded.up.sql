@@ -0,0 +1,5 @@
+-- File: internal/database/migrations/postgres/0003_measurement_excluded.up.sql
+-- Marks measurements kept for the record but left out of the session
+-- average (e.g. the first reading under a "discard first reading" policy).
+
+ALTER TABLE reading_measurements ADD COLUMN excluded BOOLEAN NOT NULL DEFAULT FALSE;
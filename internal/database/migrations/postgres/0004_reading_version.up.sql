@@ -0,0 +1,5 @@
+-- File: internal/database/migrations/postgres/0004_reading_version.up.sql
+-- Row version for optimistic concurrency on edits. Incremented by every
+-- update; clients send the version they last saw (If-Match / "version").
+
+ALTER TABLE readings ADD COLUMN version INTEGER NOT NULL DEFAULT 1;
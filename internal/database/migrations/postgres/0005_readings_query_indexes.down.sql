@@ -0,0 +1,4 @@
+-- File: internal/database/migrations/postgres/0005_readings_query_indexes.down.sql
+
+DROP INDEX IF EXISTS idx_readings_classification_timestamp_id;
+DROP INDEX IF EXISTS idx_readings_timestamp_id;
@@ -0,0 +1,6 @@
+-- File: internal/database/migrations/postgres/0005_readings_query_indexes.up.sql
+-- Indexes for the filtered, paginated readings API. Pages are ordered by
+-- (timestamp, id) so the cursor is unique even when timestamps collide.
+
+CREATE INDEX IF NOT EXISTS idx_readings_timestamp_id ON readings (timestamp, id);
+CREATE INDEX IF NOT EXISTS idx_readings_classification_timestamp_id ON readings (classification, timestamp, id);
@@ -0,0 +1,3 @@
+-- File: internal/database/migrations/postgres/0006_irregular_heartbeat.down.sql
+
+ALTER TABLE readings DROP COLUMN irregular_heartbeat;
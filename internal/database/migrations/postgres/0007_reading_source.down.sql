@@ -0,0 +1,3 @@
+-- File: internal/database/migrations/postgres/0007_reading_source.down.sql
+
+ALTER TABLE readings DROP COLUMN source;
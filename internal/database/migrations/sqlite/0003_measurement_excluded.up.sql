@@ -0,0 +1,5 @@
+-- File: internal/database/migrations/sqlite/0003_measurement_excluded.up.sql
+-- Marks measurements kept for the record but left out of the session
+-- average (e.g. the first reading under a "discard first reading" policy).
+
+ALTER TABLE reading_measurements ADD COLUMN excluded INTEGER NOT NULL DEFAULT 0; -- 0/1 boolean
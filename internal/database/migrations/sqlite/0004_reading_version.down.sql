@@ -0,0 +1,3 @@
+-- File: internal/database/migrations/sqlite/0004_reading_version.down.sql
+
+ALTER TABLE readings DROP COLUMN version;
@@ -0,0 +1,4 @@
+-- File: internal/database/migrations/sqlite/0006_irregular_heartbeat.up.sql
+-- Irregular heartbeat flag reported by the cuff, set by vendor imports.
+
+ALTER TABLE readings ADD COLUMN irregular_heartbeat INTEGER NOT NULL DEFAULT 0;
@@ -0,0 +1,5 @@
+-- File: internal/database/migrations/sqlite/0007_reading_source.up.sql
+-- Where an imported reading came from (e.g. "fhir:Organization/123").
+-- Empty for readings entered in the app.
+
+ALTER TABLE readings ADD COLUMN source TEXT NOT NULL DEFAULT '';
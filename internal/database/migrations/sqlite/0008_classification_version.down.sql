@@ -0,0 +1,3 @@
+-- File: internal/database/migrations/sqlite/0008_classification_version.down.sql
+
+ALTER TABLE readings DROP COLUMN classification_version;
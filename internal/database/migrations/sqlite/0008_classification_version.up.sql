@@ -0,0 +1,6 @@
+-- File: internal/database/migrations/sqlite/0008_classification_version.up.sql
+-- The guideline thresholds a reading's classification was computed with
+-- (e.g. "aha2017-0e4063e3"). Empty for readings classified before this
+-- was recorded; the reclassification job fills it in.
+
+ALTER TABLE readings ADD COLUMN classification_version TEXT NOT NULL DEFAULT '';
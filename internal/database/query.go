@@ -0,0 +1,235 @@
+// File: internal/database/query.go
+
+package database
+
+import (
+	"database/sql"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"bp-tracker/internal/models"
+)
+
+// SortOrder is the timestamp order of a readings page
+type SortOrder string
+
+const (
+	SortDesc SortOrder = "desc" // Newest first (default)
+	SortAsc  SortOrder = "asc"  // Oldest first
+)
+
+// Page size limits for QueryReadings
+const (
+	DefaultPageSize = 100
+	MaxPageSize     = 1000
+)
+
+// ErrInvalidCursor means a cursor token could not be decoded or was issued
+// for a different sort order
+var ErrInvalidCursor = errors.New("invalid cursor")
+
+// ReadingQuery filters and pages readings. Zero values mean "no filter".
+type ReadingQuery struct {
+	From            time.Time // Inclusive lower bound on timestamp
+	To              time.Time // Exclusive upper bound on timestamp
+	Classifications []string  // Match any of these classifications
+	Order           SortOrder // SortDesc when empty
+	Limit           int       // DefaultPageSize when 0, capped at MaxPageSize
+	Cursor          string    // NextCursor from the previous page
+}
+
+// ReadingPage is one page of readings plus the token for the next page
+type ReadingPage struct {
+	Readings   []*models.Reading `json:"readings"`
+	NextCursor string            `json:"next_cursor,omitempty"`
+}
+
+// cursor is the position after the last reading of a page. Readings are
+// ordered by (timestamp, id) so the position is unique.
+type cursor struct {
+	Timestamp time.Time `json:"t"`
+	ID        int64     `json:"id"`
+	Order     SortOrder `json:"o"`
+}
+
+// encode returns the cursor as an opaque URL-safe token
+func (c cursor) encode() string {
+	b, _ := json.Marshal(c)
+	return base64.RawURLEncoding.EncodeToString(b)
+}
+
+// decodeCursor parses a token produced by cursor.encode
+func decodeCursor(token string, order SortOrder) (*cursor, error) {
+	b, err := base64.RawURLEncoding.DecodeString(token)
+	if err != nil {
+		return nil, ErrInvalidCursor
+	}
+	var c cursor
+	if err := json.Unmarshal(b, &c); err != nil || c.Order != order {
+		return nil, ErrInvalidCursor
+	}
+	return &c, nil
+}
+
+// normalize fills in defaults and validates q
+func (q ReadingQuery) normalize() (ReadingQuery, *cursor, error) {
+	switch q.Order {
+	case "":
+		q.Order = SortDesc
+	case SortDesc, SortAsc:
+	default:
+		return q, nil, fmt.Errorf("invalid sort order %q", q.Order)
+	}
+
+	if q.Limit <= 0 {
+		q.Limit = DefaultPageSize
+	} else if q.Limit > MaxPageSize {
+		q.Limit = MaxPageSize
+	}
+
+	var c *cursor
+	if q.Cursor != "" {
+		var err error
+		if c, err = decodeCursor(q.Cursor, q.Order); err != nil {
+			return q, nil, err
+		}
+	}
+	return q, c, nil
+}
+
+// queryDialect holds the per-engine differences in the readings queries
+type queryDialect struct {
+	placeholder func(n int) string            // nth bind parameter, 1-based
+	timeArg     func(t time.Time) interface{} // how timestamps are stored
+	roundInt    func(expr string) string      // ROUND(expr) as an integer
+}
+
+var (
+	postgresQueryDialect = queryDialect{
+		placeholder: func(n int) string { return fmt.Sprintf("$%d", n) },
+		timeArg:     func(t time.Time) interface{} { return t },
+		roundInt:    func(expr string) string { return "ROUND(" + expr + ")::int" },
+	}
+	sqliteQueryDialect = queryDialect{
+		placeholder: func(n int) string { return fmt.Sprintf("?%d", n) },
+		timeArg:     func(t time.Time) interface{} { return t.Unix() },
+		// ROUND in SQLite returns a REAL, so cast back to INTEGER for Scan
+		roundInt: func(expr string) string { return "CAST(ROUND(" + expr + ") AS INTEGER)" },
+	}
+)
+
+// buildReadingsQuery returns the SQL and arguments for one page of q. It
+// selects one row more than the limit so newPage can tell if another page
+// exists; a zero limit selects every row, for streaming. The WHERE and
+// ORDER BY clauses match idx_readings_timestamp_id and
+// idx_readings_classification_timestamp_id.
+func buildReadingsQuery(q ReadingQuery, c *cursor, d queryDialect) (string, []interface{}) {
+	var where []string
+	var args []interface{}
+	arg := func(v interface{}) string {
+		args = append(args, v)
+		return d.placeholder(len(args))
+	}
+
+	if !q.From.IsZero() {
+		where = append(where, "timestamp >= "+arg(d.timeArg(q.From)))
+	}
+	if !q.To.IsZero() {
+		where = append(where, "timestamp < "+arg(d.timeArg(q.To)))
+	}
+	if len(q.Classifications) > 0 {
+		ps := make([]string, len(q.Classifications))
+		for i, name := range q.Classifications {
+			ps[i] = arg(name)
+		}
+		where = append(where, "classification IN ("+strings.Join(ps, ", ")+")")
+	}
+
+	cmp, dir := "<", "DESC"
+	if q.Order == SortAsc {
+		cmp, dir = ">", "ASC"
+	}
+	if c != nil {
+		where = append(where, fmt.Sprintf("(timestamp, id) %s (%s, %s)", cmp, arg(d.timeArg(c.Timestamp)), arg(c.ID)))
+	}
+
+	query := "SELECT id, timestamp, systolic, diastolic, pulse, classification, version, irregular_heartbeat, source, classification_version FROM readings"
+	if len(where) > 0 {
+		query += " WHERE " + strings.Join(where, " AND ")
+	}
+	query += fmt.Sprintf(" ORDER BY timestamp %s, id %s", dir, dir)
+	if q.Limit > 0 {
+		query += fmt.Sprintf(" LIMIT %d", q.Limit+1)
+	}
+
+	return query, args
+}
+
+// newPage trims readings (fetched with limit+1) to the page size and sets
+// NextCursor when more readings follow
+func newPage(readings []*models.Reading, q ReadingQuery) *ReadingPage {
+	page := &ReadingPage{Readings: readings}
+	if page.Readings == nil {
+		page.Readings = []*models.Reading{}
+	}
+	if len(readings) > q.Limit {
+		page.Readings = readings[:q.Limit]
+		last := page.Readings[q.Limit-1]
+		page.NextCursor = cursor{Timestamp: last.Timestamp, ID: last.ID, Order: q.Order}.encode()
+	}
+	return page
+}
+
+// QueryAllReadings follows NextCursor from q.Cursor to the last page and
+// returns every matching reading, for exports that need the whole range
+func QueryAllReadings(store ReadingStore, q ReadingQuery) ([]*models.Reading, error) {
+	q.Limit = MaxPageSize
+	var readings []*models.Reading
+	for {
+		page, err := store.QueryReadings(q)
+		if err != nil {
+			return nil, err
+		}
+		readings = append(readings, page.Readings...)
+		if page.NextCursor == "" {
+			return readings, nil
+		}
+		q.Cursor = page.NextCursor
+	}
+}
+
+// attachMeasurementsFor loads the measurements of the given readings only
+func attachMeasurementsFor(db *sql.DB, d queryDialect, readings []*models.Reading) error {
+	if len(readings) == 0 {
+		return nil
+	}
+
+	ps := make([]string, len(readings))
+	args := make([]interface{}, len(readings))
+	for i, r := range readings {
+		ps[i] = d.placeholder(i + 1)
+		args[i] = r.ID
+	}
+
+	rows, err := db.Query(`
+        SELECT reading_id, seq, systolic, diastolic, pulse, excluded
+        FROM reading_measurements
+        WHERE reading_id IN (`+strings.Join(ps, ", ")+`)
+        ORDER BY reading_id, seq
+    `, args...)
+	if err != nil {
+		return fmt.Errorf("error querying measurements: %w", err)
+	}
+	defer rows.Close()
+
+	measurements, err := scanMeasurements(rows)
+	if err != nil {
+		return err
+	}
+	attachMeasurements(readings, measurements)
+	return nil
+}
@@ -0,0 +1,174 @@
+// File: internal/database/rangestats.go
+
+package database
+
+import (
+	"fmt"
+	"math"
+	"time"
+
+	"bp-tracker/internal/models"
+)
+
+// GroupBy selects the bucket size for range statistics
+type GroupBy string
+
+const (
+	GroupByNone  GroupBy = ""      // Summary only
+	GroupByDay   GroupBy = "day"   // Local calendar days
+	GroupByWeek  GroupBy = "week"  // ISO weeks starting Monday
+	GroupByMonth GroupBy = "month" // Calendar months
+)
+
+// StatsQuery selects readings in [From, To) and optionally groups them into
+// buckets aligned to local midnight in Location
+type StatsQuery struct {
+	From     time.Time      // Inclusive; zero means all time
+	To       time.Time      // Exclusive; zero means now
+	GroupBy  GroupBy        // GroupByNone for a single summary
+	Location *time.Location // Bucket alignment; UTC when nil
+}
+
+// normalize fills in defaults and validates q
+func (q StatsQuery) normalize() (StatsQuery, error) {
+	switch q.GroupBy {
+	case GroupByNone, GroupByDay, GroupByWeek, GroupByMonth:
+	default:
+		return q, fmt.Errorf("invalid group_by %q", q.GroupBy)
+	}
+	if q.Location == nil {
+		q.Location = time.UTC
+	}
+	if q.To.IsZero() {
+		q.To = time.Now()
+	}
+	if !q.From.IsZero() && !q.From.Before(q.To) {
+		return q, fmt.Errorf("from must be before to")
+	}
+	return q, nil
+}
+
+// bucketStart returns the start of the bucket containing t, at local
+// midnight in loc
+func (g GroupBy) bucketStart(t time.Time, loc *time.Location) time.Time {
+	t = t.In(loc)
+	switch g {
+	case GroupByWeek:
+		// time.Weekday counts from Sunday; ISO weeks start on Monday
+		offset := (int(t.Weekday()) + 6) % 7
+		return time.Date(t.Year(), t.Month(), t.Day()-offset, 0, 0, 0, 0, loc)
+	case GroupByMonth:
+		return time.Date(t.Year(), t.Month(), 1, 0, 0, 0, 0, loc)
+	default:
+		return time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, loc)
+	}
+}
+
+// bucketEnd returns the start of the bucket after the one starting at start.
+// AddDate keeps buckets aligned to midnight across DST changes.
+func (g GroupBy) bucketEnd(start time.Time) time.Time {
+	switch g {
+	case GroupByWeek:
+		return start.AddDate(0, 0, 7)
+	case GroupByMonth:
+		return start.AddDate(0, 1, 0)
+	default:
+		return start.AddDate(0, 0, 1)
+	}
+}
+
+// newRangeStats returns an empty result describing q
+func newRangeStats(q StatsQuery) *models.RangeStats {
+	stats := &models.RangeStats{
+		To:       q.To,
+		GroupBy:  string(q.GroupBy),
+		Timezone: q.Location.String(),
+	}
+	if !q.From.IsZero() {
+		from := q.From
+		stats.From = &from
+	}
+	return stats
+}
+
+// summaryBuilder accumulates systolic, diastolic and pulse values
+type summaryBuilder struct {
+	count         int
+	sum, min, max [3]int
+}
+
+// add includes one reading
+func (b *summaryBuilder) add(r *models.Reading) {
+	values := [3]int{r.Systolic, r.Diastolic, r.Pulse}
+	for i, v := range values {
+		if b.count == 0 || v < b.min[i] {
+			b.min[i] = v
+		}
+		if b.count == 0 || v > b.max[i] {
+			b.max[i] = v
+		}
+		b.sum[i] += v
+	}
+	b.count++
+}
+
+// summary returns the aggregates, rounding averages half away from zero
+// like ROUND(AVG(x)) in SQL
+func (b *summaryBuilder) summary() models.Summary {
+	s := models.Summary{Count: b.count}
+	if b.count == 0 {
+		return s
+	}
+	ranges := []*models.ValueRange{&s.Systolic, &s.Diastolic, &s.Pulse}
+	for i, vr := range ranges {
+		vr.Avg = int(math.Round(float64(b.sum[i]) / float64(b.count)))
+		vr.Min = b.min[i]
+		vr.Max = b.max[i]
+	}
+	return s
+}
+
+// Summarize returns the count, rounded averages and min/max of readings,
+// computed the same way as GetRangeStats
+func Summarize(readings []*models.Reading) models.Summary {
+	var b summaryBuilder
+	for _, r := range readings {
+		b.add(r)
+	}
+	return b.summary()
+}
+
+// groupReadings computes range statistics in Go. readings must already be
+// limited to [q.From, q.To) and sorted by timestamp, oldest first.
+func groupReadings(readings []*models.Reading, q StatsQuery) *models.RangeStats {
+	stats := newRangeStats(q)
+
+	var total, bucket summaryBuilder
+	var start time.Time
+	flush := func() {
+		if bucket.count > 0 {
+			stats.Buckets = append(stats.Buckets, models.StatsBucket{
+				Start:   start,
+				End:     q.GroupBy.bucketEnd(start),
+				Summary: bucket.summary(),
+			})
+		}
+		bucket = summaryBuilder{}
+	}
+
+	for _, r := range readings {
+		total.add(r)
+		if q.GroupBy == GroupByNone {
+			continue
+		}
+		if s := q.GroupBy.bucketStart(r.Timestamp, q.Location); !s.Equal(start) {
+			flush()
+			start = s
+		}
+		bucket.add(r)
+	}
+	flush()
+
+	stats.Summary = total.summary()
+	return stats
+}
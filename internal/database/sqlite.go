@@ -0,0 +1,448 @@
+// File: internal/database/sqlite.go
+
+package database
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"log"
+	"time"
+
+	"bp-tracker/internal/models"
+
+	_ "github.com/mattn/go-sqlite3"
+)
+
+// SQLiteDB wraps a single-file SQLite database for self-hosted deployments
+type SQLiteDB struct {
+	*sql.DB
+}
+
+// NewSQLite opens (creating if necessary) the SQLite database at path and
+// applies any pending migrations, so a self-hosted install needs no separate
+// migration step. Timestamps are stored as Unix seconds, matching databases
+// created by earlier SQLite versions of the tracker.
+func NewSQLite(path string) (*SQLiteDB, error) {
+	db, err := OpenSQLite(path)
+	if err != nil {
+		return nil, err
+	}
+
+	migrator, err := db.Migrator()
+	if err != nil {
+		db.Close()
+		return nil, err
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+	if _, err := migrator.Migrate(ctx, MigrateOptions{Direction: MigrateUp}); err != nil {
+		db.Close()
+		return nil, fmt.Errorf("error migrating sqlite database %s: %w", path, err)
+	}
+
+	return db, nil
+}
+
+// OpenSQLite opens the SQLite database at path without touching its schema
+func OpenSQLite(path string) (*SQLiteDB, error) {
+	// Foreign keys are off by default in SQLite; WAL lets readers and the
+	// single writer work concurrently, and busy_timeout avoids spurious
+	// "database is locked" errors.
+	dsn := fmt.Sprintf("file:%s?_foreign_keys=on&_journal_mode=WAL&_busy_timeout=5000", path)
+
+	db, err := sql.Open("sqlite3", dsn)
+	if err != nil {
+		return nil, fmt.Errorf("error opening sqlite database %s: %w", path, err)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	if err := db.PingContext(ctx); err != nil {
+		db.Close()
+		return nil, fmt.Errorf("error opening sqlite database %s: %w", path, err)
+	}
+
+	log.Printf("Successfully opened SQLite database at %s\n", path)
+
+	return &SQLiteDB{db}, nil
+}
+
+// SaveReading stores a new blood pressure reading and its individual
+// measurements in one transaction, and sets r.ID to the new row's ID
+func (db *SQLiteDB) SaveReading(r *models.Reading) error {
+	ctx := context.Background()
+	tx, err := db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("error starting transaction for reading: %w", err)
+	}
+	defer tx.Rollback() // Rollback is a no-op if Commit succeeds
+
+	if err := insertReadingSQLite(ctx, tx, r); err != nil {
+		return fmt.Errorf("error saving reading: %w", err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("error committing reading: %w", err)
+	}
+
+	return nil
+}
+
+// insertReadingSQLite inserts r and its measurements within tx
+func insertReadingSQLite(ctx context.Context, tx *sql.Tx, r *models.Reading) error {
+	result, err := tx.ExecContext(ctx, `
+        INSERT INTO readings (timestamp, systolic, diastolic, pulse, classification, irregular_heartbeat, source, classification_version)
+        VALUES (?, ?, ?, ?, ?, ?, ?, ?)
+    `, r.Timestamp.Unix(), r.Systolic, r.Diastolic, r.Pulse, r.Classification, r.IrregularHeartbeat, r.Source, r.ClassificationVersion)
+	if err != nil {
+		return err
+	}
+	if r.ID, err = result.LastInsertId(); err != nil {
+		return err
+	}
+	r.Version = 1
+
+	for _, m := range r.Measurements {
+		_, err := tx.ExecContext(ctx, `
+            INSERT INTO reading_measurements (reading_id, seq, systolic, diastolic, pulse, excluded)
+            VALUES (?, ?, ?, ?, ?, ?)
+        `, r.ID, m.Seq, m.Systolic, m.Diastolic, m.Pulse, m.Excluded)
+		if err != nil {
+			return fmt.Errorf("error saving measurement %d: %w", m.Seq, err)
+		}
+	}
+
+	return nil
+}
+
+// GetStats retrieves the last reading and the 7-day, 30-day and all-time
+// averages in a single query, with the same windows and rounding as the
+// PostgreSQL implementation
+func (db *SQLiteDB) GetStats() (*models.Stats, error) {
+	stats := &models.Stats{}
+
+	var (
+		id, ts                     sql.NullInt64
+		systolic, diastolic, pulse sql.NullInt64
+		classification             sql.NullString
+		version                    sql.NullInt64
+		irregular                  sql.NullBool
+		source                     sql.NullString
+		classificationVersion      sql.NullString
+		windows                    statsWindows
+	)
+	dest := append([]interface{}{&id, &ts, &systolic, &diastolic, &pulse, &classification, &version, &irregular, &source, &classificationVersion}, windows.dest()...)
+
+	query := buildStatsQuery(sqliteQueryDialect)
+	if err := db.QueryRow(query, statsWindowArgs(sqliteQueryDialect, time.Now())...).Scan(dest...); err != nil {
+		return nil, fmt.Errorf("error getting stats: %w", err)
+	}
+	if !id.Valid {
+		return stats, nil // Return empty stats if no data
+	}
+
+	stats.LastReading = &models.Reading{
+		ID:                    id.Int64,
+		Timestamp:             time.Unix(ts.Int64, 0),
+		Systolic:              int(systolic.Int64),
+		Diastolic:             int(diastolic.Int64),
+		Pulse:                 int(pulse.Int64),
+		Classification:        classification.String,
+		Version:               int(version.Int64),
+		IrregularHeartbeat:    irregular.Bool,
+		Source:                source.String,
+		ClassificationVersion: classificationVersion.String,
+	}
+	windows.apply(stats)
+
+	return stats, nil
+}
+
+// GetRangeStats loads the readings in the range and aggregates them in Go,
+// since SQLite cannot align buckets to an arbitrary timezone
+func (db *SQLiteDB) GetRangeStats(q StatsQuery) (*models.RangeStats, error) {
+	q, err := q.normalize()
+	if err != nil {
+		return nil, err
+	}
+
+	rows, err := db.Query(`
+        SELECT timestamp, systolic, diastolic, pulse
+        FROM readings
+        WHERE timestamp >= ? AND timestamp < ?
+        ORDER BY timestamp
+    `, q.From.Unix(), q.To.Unix())
+	if err != nil {
+		return nil, fmt.Errorf("error querying readings for range stats: %w", err)
+	}
+	defer rows.Close()
+
+	var readings []*models.Reading
+	for rows.Next() {
+		r := &models.Reading{}
+		var ts int64
+		if err := rows.Scan(&ts, &r.Systolic, &r.Diastolic, &r.Pulse); err != nil {
+			return nil, fmt.Errorf("error scanning reading: %w", err)
+		}
+		r.Timestamp = time.Unix(ts, 0)
+		readings = append(readings, r)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating readings: %w", err)
+	}
+
+	return groupReadings(readings, q), nil
+}
+
+// GetAllReadings retrieves all readings, newest first
+func (db *SQLiteDB) GetAllReadings() ([]*models.Reading, error) {
+	query := `
+        SELECT id, timestamp, systolic, diastolic, pulse, classification, version, irregular_heartbeat, source, classification_version
+        FROM readings
+        ORDER BY timestamp DESC
+    `
+
+	rows, err := db.Query(query)
+	if err != nil {
+		return nil, fmt.Errorf("error querying readings: %w", err)
+	}
+	defer rows.Close()
+
+	var readings []*models.Reading
+	for rows.Next() {
+		r, err := scanSQLiteReading(rows)
+		if err != nil {
+			return nil, fmt.Errorf("error scanning reading: %w", err)
+		}
+		readings = append(readings, r)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating readings: %w", err)
+	}
+
+	measurements, err := db.getMeasurements()
+	if err != nil {
+		return nil, err
+	}
+	attachMeasurements(readings, measurements)
+
+	return readings, nil
+}
+
+// QueryReadings returns one page of filtered readings with their measurements
+func (db *SQLiteDB) QueryReadings(q ReadingQuery) (*ReadingPage, error) {
+	q, c, err := q.normalize()
+	if err != nil {
+		return nil, err
+	}
+
+	query, args := buildReadingsQuery(q, c, sqliteQueryDialect)
+	rows, err := db.Query(query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("error querying readings: %w", err)
+	}
+	defer rows.Close()
+
+	var readings []*models.Reading
+	for rows.Next() {
+		r, err := scanSQLiteReading(rows)
+		if err != nil {
+			return nil, fmt.Errorf("error scanning reading: %w", err)
+		}
+		readings = append(readings, r)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating readings: %w", err)
+	}
+
+	page := newPage(readings, q)
+	if err := attachMeasurementsFor(db.DB, sqliteQueryDialect, page.Readings); err != nil {
+		return nil, err
+	}
+	return page, nil
+}
+
+// GetReading retrieves a single reading and its measurements by ID
+func (db *SQLiteDB) GetReading(id int64) (*models.Reading, error) {
+	r, err := scanSQLiteReading(db.QueryRow(`
+        SELECT id, timestamp, systolic, diastolic, pulse, classification, version, irregular_heartbeat, source, classification_version
+        FROM readings
+        WHERE id = ?
+    `, id))
+	if err == sql.ErrNoRows {
+		return nil, fmt.Errorf("no reading found with id %d: %w", id, ErrNotFound)
+	} else if err != nil {
+		return nil, fmt.Errorf("error getting reading %d: %w", id, err)
+	}
+
+	rows, err := db.Query(`
+        SELECT reading_id, seq, systolic, diastolic, pulse, excluded
+        FROM reading_measurements
+        WHERE reading_id = ?
+        ORDER BY seq
+    `, id)
+	if err != nil {
+		return nil, fmt.Errorf("error querying measurements for reading %d: %w", id, err)
+	}
+	defer rows.Close()
+
+	measurements, err := scanMeasurements(rows)
+	if err != nil {
+		return nil, err
+	}
+	attachMeasurements([]*models.Reading{r}, measurements)
+
+	return r, nil
+}
+
+// UpdateReading overwrites a reading if its version still matches
+// expectedVersion, replacing its measurements when r.Measurements is non-nil
+func (db *SQLiteDB) UpdateReading(r *models.Reading, expectedVersion int) error {
+	ctx := context.Background()
+	tx, err := db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("error starting transaction for update: %w", err)
+	}
+	defer tx.Rollback() // Rollback is a no-op if Commit succeeds
+
+	result, err := tx.ExecContext(ctx, `
+        UPDATE readings
+        SET timestamp = ?, systolic = ?, diastolic = ?, pulse = ?, classification = ?, classification_version = ?, version = version + 1
+        WHERE id = ? AND version = ?
+    `, r.Timestamp.Unix(), r.Systolic, r.Diastolic, r.Pulse, r.Classification, r.ClassificationVersion, r.ID, expectedVersion)
+	if err != nil {
+		return fmt.Errorf("error updating reading %d: %w", r.ID, err)
+	}
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("error updating reading %d: %w", r.ID, err)
+	}
+	if rowsAffected == 0 {
+		var current int
+		err := tx.QueryRowContext(ctx, `SELECT version FROM readings WHERE id = ?`, r.ID).Scan(&current)
+		if err == sql.ErrNoRows {
+			return fmt.Errorf("no reading found with id %d: %w", r.ID, ErrNotFound)
+		} else if err != nil {
+			return fmt.Errorf("error checking version of reading %d: %w", r.ID, err)
+		}
+		return fmt.Errorf("reading %d is at version %d, not %d: %w", r.ID, current, expectedVersion, ErrVersionConflict)
+	}
+
+	if r.Measurements != nil {
+		if _, err := tx.ExecContext(ctx, `DELETE FROM reading_measurements WHERE reading_id = ?`, r.ID); err != nil {
+			return fmt.Errorf("error replacing measurements for reading %d: %w", r.ID, err)
+		}
+		for _, m := range r.Measurements {
+			_, err := tx.ExecContext(ctx, `
+                INSERT INTO reading_measurements (reading_id, seq, systolic, diastolic, pulse, excluded)
+                VALUES (?, ?, ?, ?, ?, ?)
+            `, r.ID, m.Seq, m.Systolic, m.Diastolic, m.Pulse, m.Excluded)
+			if err != nil {
+				return fmt.Errorf("error saving measurement %d: %w", m.Seq, err)
+			}
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("error committing update: %w", err)
+	}
+
+	r.Version = expectedVersion + 1
+	log.Printf("Successfully updated reading with id %d (now version %d)\n", r.ID, r.Version)
+	return nil
+}
+
+// getMeasurements loads every stored measurement keyed by reading ID
+func (db *SQLiteDB) getMeasurements() (map[int64][]models.Measurement, error) {
+	rows, err := db.Query(`
+        SELECT reading_id, seq, systolic, diastolic, pulse, excluded
+        FROM reading_measurements
+        ORDER BY reading_id, seq
+    `)
+	if err != nil {
+		return nil, fmt.Errorf("error querying measurements: %w", err)
+	}
+	defer rows.Close()
+
+	return scanMeasurements(rows)
+}
+
+// ClearAllReadings deletes all entries from the readings table.
+// WARNING: Use with caution, typically only for testing/development.
+func (db *SQLiteDB) ClearAllReadings() error {
+	if _, err := db.Exec(`DELETE FROM readings`); err != nil {
+		return fmt.Errorf("error clearing readings table: %w", err)
+	}
+	log.Println("Successfully cleared all readings from the database.")
+	return nil
+}
+
+// SeedReadings inserts multiple readings in a single transaction
+func (db *SQLiteDB) SeedReadings(readings []*models.Reading) error {
+	ctx := context.Background()
+	tx, err := db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("error starting transaction for seeding: %w", err)
+	}
+	defer tx.Rollback() // Rollback is a no-op if Commit succeeds
+
+	for _, r := range readings {
+		if err := insertReadingSQLite(ctx, tx, r); err != nil {
+			return fmt.Errorf("error inserting seed reading (timestamp %v): %w", r.Timestamp, err)
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("error committing transaction for seeding: %w", err)
+	}
+
+	log.Printf("Successfully seeded %d readings into the database.\n", len(readings))
+	return nil
+}
+
+// DeleteReading deletes a specific reading by its ID
+func (db *SQLiteDB) DeleteReading(id int64) error {
+	result, err := db.Exec(`DELETE FROM readings WHERE id = ?`, id)
+	if err != nil {
+		return fmt.Errorf("error executing delete query for id %d: %w", id, err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		log.Printf("Warning: Could not get rows affected after delete for id %d: %v", id, err)
+	}
+
+	if rowsAffected == 0 {
+		return fmt.Errorf("no reading found with id %d to delete: %w", id, ErrNotFound)
+	}
+
+	log.Printf("Successfully deleted reading with id %d (%d rows affected)\n", id, rowsAffected)
+	return nil
+}
+
+// Close closes the database connection
+func (db *SQLiteDB) Close() error {
+	return db.DB.Close()
+}
+
+// rowScanner is satisfied by both *sql.Row and *sql.Rows
+type rowScanner interface {
+	Scan(dest ...interface{}) error
+}
+
+// scanSQLiteReading scans id, timestamp, systolic, diastolic, pulse,
+// classification, version, irregular_heartbeat, source and
+// classification_version, converting the stored Unix seconds back into
+// a time.Time
+func scanSQLiteReading(row rowScanner) (*models.Reading, error) {
+	r := &models.Reading{}
+	var ts int64
+	if err := row.Scan(&r.ID, &ts, &r.Systolic, &r.Diastolic, &r.Pulse, &r.Classification, &r.Version, &r.IrregularHeartbeat, &r.Source, &r.ClassificationVersion); err != nil {
+		return nil, err
+	}
+	r.Timestamp = time.Unix(ts, 0)
+	return r, nil
+}
@@ -0,0 +1,88 @@
+// File: internal/database/stats.go
+
+package database
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"bp-tracker/internal/models"
+)
+
+// buildStatsQuery returns the GetStats query for a SQL backend. One row
+// holds the most recent reading (all NULL when there are no readings)
+// followed by the count and rounded averages for the 7-day, 30-day and
+// all-time windows, computed in a single scan with FILTER clauses.
+// Parameters: 1 = seven days ago, 2 = thirty days ago, 3 = now.
+func buildStatsQuery(d queryDialect) string {
+	now := d.placeholder(3)
+	filters := []string{
+		fmt.Sprintf(" FILTER (WHERE timestamp >= %s AND timestamp < %s)", d.placeholder(1), now),
+		fmt.Sprintf(" FILTER (WHERE timestamp >= %s AND timestamp < %s)", d.placeholder(2), now),
+		"", // All time
+	}
+
+	var columns []string
+	for _, filter := range filters {
+		columns = append(columns, "COUNT(*)"+filter)
+		for _, col := range []string{"systolic", "diastolic", "pulse"} {
+			columns = append(columns, d.roundInt(fmt.Sprintf("COALESCE(AVG(%s)%s, 0)", col, filter)))
+		}
+	}
+
+	return `
+        SELECT
+            last.id, last.timestamp, last.systolic, last.diastolic, last.pulse, last.classification, last.version, last.irregular_heartbeat, last.source, last.classification_version,
+            agg.*
+        FROM (
+            SELECT
+                ` + strings.Join(columns, ",\n                ") + `
+            FROM readings
+        ) agg
+        LEFT JOIN (
+            SELECT id, timestamp, systolic, diastolic, pulse, classification, version, irregular_heartbeat, source, classification_version
+            FROM readings
+            ORDER BY timestamp DESC, id DESC
+            LIMIT 1
+        ) last ON 1 = 1
+    `
+}
+
+// statsWindowArgs returns the parameters for buildStatsQuery
+func statsWindowArgs(d queryDialect, now time.Time) []interface{} {
+	return []interface{}{
+		d.timeArg(now.AddDate(0, 0, -7)),
+		d.timeArg(now.AddDate(0, 0, -30)),
+		d.timeArg(now),
+	}
+}
+
+// statsWindows receives the aggregate columns of the stats query
+type statsWindows [3]struct {
+	count int
+	avg   models.Reading
+}
+
+// dest returns Scan destinations in buildStatsQuery column order
+func (w *statsWindows) dest() []interface{} {
+	var dest []interface{}
+	for i := range w {
+		dest = append(dest, &w[i].count, &w[i].avg.Systolic, &w[i].avg.Diastolic, &w[i].avg.Pulse)
+	}
+	return dest
+}
+
+// apply copies the windows into stats, leaving averages nil for empty windows
+func (w *statsWindows) apply(stats *models.Stats) {
+	avgs := []**models.Reading{&stats.SevenDayAvg, &stats.ThirtyDayAvg, &stats.AllTimeAvg}
+	counts := []*int{&stats.SevenDayCount, &stats.ThirtyDayCount, &stats.AllTimeCount}
+	for i := range w {
+		if w[i].count == 0 {
+			continue
+		}
+		avg := w[i].avg
+		*avgs[i] = &avg
+		*counts[i] = w[i].count
+	}
+}
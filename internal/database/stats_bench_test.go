@@ -0,0 +1,255 @@
+// File: internal/database/stats_bench_test.go
+
+package database
+
+import (
+	"database/sql"
+	"database/sql/driver"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"bp-tracker/internal/models"
+
+	"github.com/mattn/go-sqlite3"
+)
+
+// Run with:
+//
+//	go test ./internal/database -run '^$' -bench GetStats -benchmem
+//
+// Set BENCH_POSTGRES=1 with the usual DB_* variables to also benchmark a
+// PostgreSQL database (read-only), where each saved round trip matters most.
+
+const benchReadings = 2000
+
+// benchRoundTrip approximates one query round trip to RDS from Lambda
+const benchRoundTrip = time.Millisecond
+
+func init() {
+	sql.Register("sqlite3-remote", latencyDriver{Driver: &sqlite3.SQLiteDriver{}, delay: benchRoundTrip})
+}
+
+// latencyDriver delays every statement, so an in-process SQLite database
+// behaves like a database across the network
+type latencyDriver struct {
+	driver.Driver
+	delay time.Duration
+}
+
+func (d latencyDriver) Open(name string) (driver.Conn, error) {
+	conn, err := d.Driver.Open(name)
+	if err != nil {
+		return nil, err
+	}
+	return latencyConn{Conn: conn, delay: d.delay}, nil
+}
+
+// latencyConn hides the wrapped connection's fast paths so every query
+// goes through Prepare
+type latencyConn struct {
+	driver.Conn
+	delay time.Duration
+}
+
+func (c latencyConn) Prepare(query string) (driver.Stmt, error) {
+	time.Sleep(c.delay)
+	return c.Conn.Prepare(query)
+}
+
+// newBenchRemote returns a seeded SQLite store whose queries each pay
+// benchRoundTrip
+func newBenchRemote(b *testing.B) *SQLiteDB {
+	b.Helper()
+	local := newBenchSQLite(b)
+	var path string
+	if err := local.QueryRow(`SELECT file FROM pragma_database_list WHERE name = 'main'`).Scan(&path); err != nil {
+		b.Fatal(err)
+	}
+
+	db, err := sql.Open("sqlite3-remote", path)
+	if err != nil {
+		b.Fatal(err)
+	}
+	b.Cleanup(func() { db.Close() })
+	return &SQLiteDB{db}
+}
+
+// newBenchSQLite returns a SQLite store seeded with two years of readings
+func newBenchSQLite(b *testing.B) *SQLiteDB {
+	b.Helper()
+	db, err := NewSQLite(filepath.Join(b.TempDir(), "bench.db"))
+	if err != nil {
+		b.Fatal(err)
+	}
+	b.Cleanup(func() { db.Close() })
+
+	now := time.Now()
+	readings := make([]*models.Reading, benchReadings)
+	for i := range readings {
+		readings[i] = &models.Reading{
+			Timestamp:      now.Add(-time.Duration(i) * 9 * time.Hour),
+			Systolic:       110 + i%40,
+			Diastolic:      70 + i%20,
+			Pulse:          60 + i%30,
+			Classification: "Normal",
+		}
+	}
+	if err := db.SeedReadings(readings); err != nil {
+		b.Fatal(err)
+	}
+	return db
+}
+
+// newBenchPostgres connects to the database described by DB_* when
+// BENCH_POSTGRES=1, and skips the benchmark otherwise
+func newBenchPostgres(b *testing.B) *DB {
+	b.Helper()
+	if os.Getenv("BENCH_POSTGRES") != "1" {
+		b.Skip("set BENCH_POSTGRES=1 and DB_* to benchmark PostgreSQL")
+	}
+	db, err := New()
+	if err != nil {
+		b.Fatal(err)
+	}
+	b.Cleanup(func() { db.Close() })
+	return db
+}
+
+// legacyGetStats is the previous GetStats: one query for the last reading
+// and one per average window
+func legacyGetStats(db *sql.DB, d queryDialect) (*models.Stats, error) {
+	stats := &models.Stats{}
+
+	var id int64
+	var ts interface{}
+	r := &models.Reading{}
+	err := db.QueryRow(`
+        SELECT id, timestamp, systolic, diastolic, pulse, classification
+        FROM readings
+        ORDER BY timestamp DESC
+        LIMIT 1
+    `).Scan(&id, &ts, &r.Systolic, &r.Diastolic, &r.Pulse, &r.Classification)
+	if err == sql.ErrNoRows {
+		return stats, nil
+	} else if err != nil {
+		return nil, err
+	}
+	stats.LastReading = r
+
+	averageQuery := `
+        SELECT ` + d.roundInt("COALESCE(AVG(systolic), 0)") + `, ` + d.roundInt("COALESCE(AVG(diastolic), 0)") + `,
+            ` + d.roundInt("COALESCE(AVG(pulse), 0)") + `, COUNT(*)
+        FROM readings`
+	rangeClause := " WHERE timestamp >= " + d.placeholder(1) + " AND timestamp < " + d.placeholder(2)
+
+	getAverage := func(where string, args ...interface{}) (*models.Reading, int, error) {
+		r := &models.Reading{}
+		var count int
+		if err := db.QueryRow(averageQuery+where, args...).Scan(&r.Systolic, &r.Diastolic, &r.Pulse, &count); err != nil {
+			return nil, 0, err
+		}
+		if count == 0 {
+			return nil, 0, nil
+		}
+		return r, count, nil
+	}
+
+	now := time.Now()
+	if stats.SevenDayAvg, stats.SevenDayCount, err = getAverage(rangeClause, d.timeArg(now.AddDate(0, 0, -7)), d.timeArg(now)); err != nil {
+		return nil, err
+	}
+	if stats.ThirtyDayAvg, stats.ThirtyDayCount, err = getAverage(rangeClause, d.timeArg(now.AddDate(0, 0, -30)), d.timeArg(now)); err != nil {
+		return nil, err
+	}
+	if stats.AllTimeAvg, stats.AllTimeCount, err = getAverage(""); err != nil {
+		return nil, err
+	}
+	return stats, nil
+}
+
+func BenchmarkGetStatsSQLiteSingleQuery(b *testing.B) {
+	db := newBenchSQLite(b)
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		if _, err := db.GetStats(); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func BenchmarkGetStatsSQLiteFourQueries(b *testing.B) {
+	db := newBenchSQLite(b)
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		if _, err := legacyGetStats(db.DB, sqliteQueryDialect); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+// The Remote benchmarks add benchRoundTrip per query: saving three round
+// trips is what matters on a cold Lambda talking to RDS
+func BenchmarkGetStatsRemoteSingleQuery(b *testing.B) {
+	db := newBenchRemote(b)
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		if _, err := db.GetStats(); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func BenchmarkGetStatsRemoteFourQueries(b *testing.B) {
+	db := newBenchRemote(b)
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		if _, err := legacyGetStats(db.DB, sqliteQueryDialect); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func BenchmarkGetStatsSQLiteCached(b *testing.B) {
+	store := NewCachedStore(newBenchSQLite(b), DefaultStatsCacheTTL)
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		if _, err := store.GetStats(); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+// BenchmarkGetStatsSQLiteCachedWithWrites invalidates the cache before every
+// read, the worst case for the home page after a submit
+func BenchmarkGetStatsSQLiteCachedWithWrites(b *testing.B) {
+	store := NewCachedStore(newBenchSQLite(b), DefaultStatsCacheTTL)
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		store.invalidate()
+		if _, err := store.GetStats(); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func BenchmarkGetStatsPostgresSingleQuery(b *testing.B) {
+	db := newBenchPostgres(b)
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		if _, err := db.GetStats(); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func BenchmarkGetStatsPostgresFourQueries(b *testing.B) {
+	db := newBenchPostgres(b)
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		if _, err := legacyGetStats(db.DB, postgresQueryDialect); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
@@ -0,0 +1,158 @@
+// File: internal/database/stats_test.go
+
+package database
+
+import (
+	"path/filepath"
+	"testing"
+	"time"
+
+	"bp-tracker/internal/models"
+)
+
+// testStores returns an empty store of each backend that runs without a
+// server, by name: the memory store, SQLite (the single FILTER query of
+// buildStatsQuery) and the stats cache in front of SQLite
+func testStores(t *testing.T) map[string]ReadingStore {
+	t.Helper()
+	return map[string]ReadingStore{
+		"memory": NewMemoryStore(),
+		"sqlite": newTestSQLite(t),
+		"cached": NewCachedStore(newTestSQLite(t), DefaultStatsCacheTTL),
+	}
+}
+
+// newTestSQLite returns an empty, migrated SQLite store
+func newTestSQLite(t *testing.T) *SQLiteDB {
+	t.Helper()
+	db, err := NewSQLite(filepath.Join(t.TempDir(), "test.db"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { db.Close() })
+	return db
+}
+
+// statsFixture has readings well inside each GetStats window, one in the
+// future (the last reading, but outside the 7- and 30-day windows), and
+// averages that end in .5 to check rounding
+func statsFixture(now time.Time) []*models.Reading {
+	at := func(d time.Duration, systolic, diastolic, pulse int) *models.Reading {
+		return &models.Reading{Timestamp: now.Add(d), Systolic: systolic, Diastolic: diastolic, Pulse: pulse, Classification: "Normal"}
+	}
+	const day = 24 * time.Hour
+	return []*models.Reading{
+		at(-400*day, 150, 95, 90),
+		at(-45*day, 100, 60, 50),
+		at(-29*day, 131, 86, 61),
+		at(-10*day, 130, 85, 60),
+		at(-2*day, 122, 81, 71),
+		at(-time.Hour, 121, 80, 70),
+		at(2*time.Hour, 140, 90, 80),
+	}
+}
+
+// statsWant is GetStats for statsFixture
+var statsWant = struct {
+	last                  [3]int
+	sevenDay, thirtyDay   [3]int
+	allTime               [3]int
+	sevenN, thirtyN, allN int
+}{
+	last:      [3]int{140, 90, 80},
+	sevenDay:  [3]int{122, 81, 71}, // 121.5, 80.5, 70.5
+	thirtyDay: [3]int{126, 83, 66}, // 126, 83, 65.5
+	allTime:   [3]int{128, 82, 69}, // 127.7, 82.4, 68.9
+	sevenN:    2,
+	thirtyN:   4,
+	allN:      7,
+}
+
+func TestGetStats(t *testing.T) {
+	now := time.Now().Truncate(time.Second) // SQLite stores whole seconds
+	for name, store := range testStores(t) {
+		t.Run(name, func(t *testing.T) {
+			if err := store.SeedReadings(statsFixture(now)); err != nil {
+				t.Fatal(err)
+			}
+			stats, err := store.GetStats()
+			if err != nil {
+				t.Fatal(err)
+			}
+			checkStats(t, stats)
+		})
+	}
+}
+
+// TestGetStatsCached checks that cached stats match the store's and are
+// dropped by a write
+func TestGetStatsCached(t *testing.T) {
+	now := time.Now().Truncate(time.Second)
+	store := NewCachedStore(newTestSQLite(t), DefaultStatsCacheTTL)
+	if err := store.SeedReadings(statsFixture(now)); err != nil {
+		t.Fatal(err)
+	}
+	for i := 0; i < 2; i++ { // Queried, then cached
+		stats, err := store.GetStats()
+		if err != nil {
+			t.Fatal(err)
+		}
+		checkStats(t, stats)
+	}
+
+	if err := store.SaveReading(&models.Reading{Timestamp: now.Add(-2 * time.Hour), Systolic: 120, Diastolic: 80, Pulse: 70}); err != nil {
+		t.Fatal(err)
+	}
+	stats, err := store.GetStats()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if stats.SevenDayCount != statsWant.sevenN+1 || stats.AllTimeCount != statsWant.allN+1 {
+		t.Errorf("after a save, counts = %d and %d, want %d and %d", stats.SevenDayCount, stats.AllTimeCount, statsWant.sevenN+1, statsWant.allN+1)
+	}
+}
+
+func TestGetStatsEmpty(t *testing.T) {
+	for name, store := range testStores(t) {
+		t.Run(name, func(t *testing.T) {
+			stats, err := store.GetStats()
+			if err != nil {
+				t.Fatal(err)
+			}
+			if *stats != (models.Stats{}) {
+				t.Errorf("GetStats() = %+v, want empty stats", stats)
+			}
+		})
+	}
+}
+
+// checkStats compares stats with statsWant
+func checkStats(t *testing.T, stats *models.Stats) {
+	t.Helper()
+	values := func(r *models.Reading) [3]int {
+		if r == nil {
+			return [3]int{}
+		}
+		return [3]int{r.Systolic, r.Diastolic, r.Pulse}
+	}
+
+	if stats.LastReading == nil || values(stats.LastReading) != statsWant.last {
+		t.Errorf("LastReading = %+v, want %v", stats.LastReading, statsWant.last)
+	}
+	windows := []struct {
+		name      string
+		avg       *models.Reading
+		count     int
+		want      [3]int
+		wantCount int
+	}{
+		{"7-day", stats.SevenDayAvg, stats.SevenDayCount, statsWant.sevenDay, statsWant.sevenN},
+		{"30-day", stats.ThirtyDayAvg, stats.ThirtyDayCount, statsWant.thirtyDay, statsWant.thirtyN},
+		{"all-time", stats.AllTimeAvg, stats.AllTimeCount, statsWant.allTime, statsWant.allN},
+	}
+	for _, w := range windows {
+		if values(w.avg) != w.want || w.count != w.wantCount {
+			t.Errorf("%s average = %v over %d readings, want %v over %d", w.name, values(w.avg), w.count, w.want, w.wantCount)
+		}
+	}
+}
@@ -0,0 +1,157 @@
+// File: internal/database/store.go
+
+package database
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"time"
+
+	"bp-tracker/internal/models"
+)
+
+// ReadingStore is the storage abstraction used by the HTTP handlers.
+// Every backend (PostgreSQL, in-memory) must compute the same statistics
+// so the handlers behave identically regardless of where data lives.
+type ReadingStore interface {
+	// SaveReading stores a new blood pressure reading with its measurements
+	// and sets r.ID
+	SaveReading(r *models.Reading) error
+	// GetStats returns the last reading plus 7-day, 30-day and all-time averages
+	GetStats() (*models.Stats, error)
+	// GetRangeStats returns averages, min/max and counts for readings in a
+	// date range, optionally grouped into day, week or month buckets
+	GetRangeStats(q StatsQuery) (*models.RangeStats, error)
+	// GetAllReadings returns every reading with its measurements, newest first
+	GetAllReadings() ([]*models.Reading, error)
+	// QueryReadings returns one page of readings matching q, with measurements
+	QueryReadings(q ReadingQuery) (*ReadingPage, error)
+	// GetReading returns a single reading with its measurements, or ErrNotFound
+	GetReading(id int64) (*models.Reading, error)
+	// UpdateReading overwrites the reading with ID r.ID if its stored version
+	// still equals expectedVersion, then sets r.Version to the new version.
+	// Measurements are replaced only when r.Measurements is non-nil.
+	// Returns ErrNotFound or ErrVersionConflict on failure.
+	UpdateReading(r *models.Reading, expectedVersion int) error
+	// UpdateClassifications sets the classification of several readings in
+	// one transaction, skipping any whose version changed since, and
+	// returns how many were updated. Each update bumps the version.
+	UpdateClassifications(updates []ClassificationUpdate) (int, error)
+	// DeleteReading removes a single reading by ID
+	DeleteReading(id int64) error
+	// SeedReadings inserts a batch of readings atomically
+	SeedReadings(readings []*models.Reading) error
+	// ClearAllReadings deletes every reading
+	ClearAllReadings() error
+	// Close releases any resources held by the store
+	Close() error
+}
+
+// Errors returned by every ReadingStore implementation. Check with errors.Is.
+var (
+	// ErrNotFound means no reading exists with the requested ID
+	ErrNotFound = errors.New("reading not found")
+	// ErrVersionConflict means the reading changed since the caller read it
+	ErrVersionConflict = errors.New("reading was modified by another client")
+)
+
+// Compile-time checks that the backends satisfy ReadingStore
+var (
+	_ ReadingStore = (*DB)(nil)
+	_ ReadingStore = (*SQLiteDB)(nil)
+	_ ReadingStore = (*MemoryStore)(nil)
+
+	_ Migratable = (*DB)(nil)
+	_ Migratable = (*SQLiteDB)(nil)
+)
+
+// NewStore opens the storage backend selected by the DB_DRIVER environment
+// variable. Supported values are "postgres" (the default), "sqlite" and
+// "memory". The SQLite file location is read from SQLITE_PATH (default bp.db).
+// GetStats results are cached for STATS_CACHE_TTL (a Go duration, default
+// 1m); set it to 0 to disable the cache.
+func NewStore() (ReadingStore, error) {
+	ttl := DefaultStatsCacheTTL
+	if v := os.Getenv("STATS_CACHE_TTL"); v != "" {
+		var err error
+		if ttl, err = time.ParseDuration(v); err != nil {
+			return nil, fmt.Errorf("invalid STATS_CACHE_TTL %q: %w", v, err)
+		}
+	}
+
+	store, err := openStore(os.Getenv("DB_DRIVER"))
+	if err != nil || ttl <= 0 {
+		return store, err
+	}
+	return NewCachedStore(store, ttl), nil
+}
+
+// AsMigratable returns the store's migration support, looking through
+// wrappers such as CachedStore
+func AsMigratable(store ReadingStore) (Migratable, bool) {
+	for {
+		if m, ok := store.(Migratable); ok {
+			return m, true
+		}
+		w, ok := store.(interface{ Unwrap() ReadingStore })
+		if !ok {
+			return nil, false
+		}
+		store = w.Unwrap()
+	}
+}
+
+// openStore opens the backend for driver without any caching
+func openStore(driver string) (ReadingStore, error) {
+	switch driver {
+	case "", "postgres":
+		db, err := New()
+		if err != nil {
+			return nil, err
+		}
+		return db, nil
+	case "sqlite":
+		path := os.Getenv("SQLITE_PATH")
+		if path == "" {
+			path = "bp.db"
+		}
+		db, err := NewSQLite(path)
+		if err != nil {
+			return nil, err
+		}
+		return db, nil
+	case "memory":
+		log.Println("Using in-memory storage. Readings will be lost when the process exits.")
+		return NewMemoryStore(), nil
+	default:
+		return nil, fmt.Errorf("unsupported DB_DRIVER %q (expected postgres, sqlite or memory)", driver)
+	}
+}
+
+// scanMeasurements reads reading_id, seq, systolic, diastolic, pulse,
+// excluded rows into a map keyed by reading ID
+func scanMeasurements(rows *sql.Rows) (map[int64][]models.Measurement, error) {
+	byReading := map[int64][]models.Measurement{}
+	for rows.Next() {
+		var readingID int64
+		var m models.Measurement
+		if err := rows.Scan(&readingID, &m.Seq, &m.Systolic, &m.Diastolic, &m.Pulse, &m.Excluded); err != nil {
+			return nil, fmt.Errorf("error scanning measurement: %w", err)
+		}
+		byReading[readingID] = append(byReading[readingID], m)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating measurements: %w", err)
+	}
+	return byReading, nil
+}
+
+// attachMeasurements sets each reading's Measurements from byReading
+func attachMeasurements(readings []*models.Reading, byReading map[int64][]models.Measurement) {
+	for _, r := range readings {
+		r.Measurements = byReading[r.ID]
+	}
+}
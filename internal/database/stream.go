@@ -0,0 +1,105 @@
+// File: internal/database/stream.go
+
+package database
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+
+	"bp-tracker/internal/models"
+)
+
+// ReadingStreamer is implemented by stores that can read a whole range from
+// one database cursor instead of loading it into memory or page by page
+type ReadingStreamer interface {
+	// StreamReadings calls fn for each reading matching q, in q.Order,
+	// without measurements. q.Limit is ignored. It stops at the first error
+	// from fn or the database, or when ctx is cancelled.
+	StreamReadings(ctx context.Context, q ReadingQuery, fn func(*models.Reading) error) error
+}
+
+// Compile-time checks that the SQL backends stream
+var (
+	_ ReadingStreamer = (*DB)(nil)
+	_ ReadingStreamer = (*SQLiteDB)(nil)
+)
+
+// StreamReadings calls fn for each reading matching q, using the store's
+// cursor if it has one (looking through wrappers such as CachedStore) and
+// otherwise following QueryReadings pages. As with ReadingStreamer,
+// readings have no measurements and q.Limit is ignored.
+func StreamReadings(ctx context.Context, store ReadingStore, q ReadingQuery, fn func(*models.Reading) error) error {
+	for s := store; ; {
+		if streamer, ok := s.(ReadingStreamer); ok {
+			return streamer.StreamReadings(ctx, q, fn)
+		}
+		w, ok := s.(interface{ Unwrap() ReadingStore })
+		if !ok {
+			break
+		}
+		s = w.Unwrap()
+	}
+
+	q.Limit = MaxPageSize
+	for {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		page, err := store.QueryReadings(q)
+		if err != nil {
+			return err
+		}
+		for _, r := range page.Readings {
+			r.Measurements = nil
+			if err := fn(r); err != nil {
+				return err
+			}
+		}
+		if page.NextCursor == "" {
+			return nil
+		}
+		q.Cursor = page.NextCursor
+	}
+}
+
+// StreamReadings iterates the readings matching q from a single query
+func (db *DB) StreamReadings(ctx context.Context, q ReadingQuery, fn func(*models.Reading) error) error {
+	return streamReadings(ctx, db.DB, postgresQueryDialect, q, scanPostgresReading, fn)
+}
+
+// StreamReadings iterates the readings matching q from a single query
+func (db *SQLiteDB) StreamReadings(ctx context.Context, q ReadingQuery, fn func(*models.Reading) error) error {
+	return streamReadings(ctx, db.DB, sqliteQueryDialect, q, scanSQLiteReading, fn)
+}
+
+// streamReadings runs the readings query for q without a limit and calls fn
+// for each row as it is scanned
+func streamReadings(ctx context.Context, db *sql.DB, d queryDialect, q ReadingQuery, scan func(rowScanner) (*models.Reading, error), fn func(*models.Reading) error) error {
+	q, c, err := q.normalize()
+	if err != nil {
+		return err
+	}
+	q.Limit = 0
+
+	query, args := buildReadingsQuery(q, c, d)
+	rows, err := db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return fmt.Errorf("error querying readings: %w", err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		r, err := scan(rows)
+		if err != nil {
+			return fmt.Errorf("error scanning reading: %w", err)
+		}
+		if err := fn(r); err != nil {
+			return err
+		}
+	}
+	if err := rows.Err(); err != nil {
+		return fmt.Errorf("error iterating readings: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,76 @@
+// File: internal/database/update_test.go
+
+package database
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"bp-tracker/internal/models"
+)
+
+func TestUpdateReading(t *testing.T) {
+	ts := time.Now().Add(-time.Hour).Truncate(time.Second)
+	for name, store := range testStores(t) {
+		t.Run(name, func(t *testing.T) {
+			r := &models.Reading{
+				Timestamp: ts, Systolic: 121, Diastolic: 79, Pulse: 70, Classification: "Elevated",
+				Measurements: []models.Measurement{{Seq: 1, Systolic: 120, Diastolic: 78, Pulse: 70}, {Seq: 2, Systolic: 122, Diastolic: 80, Pulse: 70}},
+			}
+			if err := store.SaveReading(r); err != nil {
+				t.Fatal(err)
+			}
+			if r.Version != 1 {
+				t.Fatalf("saved version = %d, want 1", r.Version)
+			}
+
+			// Without measurements, the stored ones are kept
+			edit := *r
+			edit.Measurements = nil
+			edit.Timestamp = ts.Add(-time.Hour)
+			if err := store.UpdateReading(&edit, 1); err != nil {
+				t.Fatal(err)
+			}
+			if edit.Version != 2 {
+				t.Errorf("updated version = %d, want 2", edit.Version)
+			}
+			got, err := store.GetReading(r.ID)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got.Version != 2 || !got.Timestamp.Equal(edit.Timestamp) || len(got.Measurements) != 2 {
+				t.Errorf("after update: version %d, timestamp %v, %d measurements; want 2, %v, 2", got.Version, got.Timestamp, len(got.Measurements), edit.Timestamp)
+			}
+
+			// New measurements replace the stored ones
+			edit.Measurements = []models.Measurement{{Seq: 1, Systolic: 130, Diastolic: 85, Pulse: 72}}
+			if err := store.UpdateReading(&edit, 2); err != nil {
+				t.Fatal(err)
+			}
+			if got, err = store.GetReading(r.ID); err != nil {
+				t.Fatal(err)
+			}
+			if len(got.Measurements) != 1 || got.Measurements[0].Systolic != 130 {
+				t.Errorf("measurements = %+v, want the one sent", got.Measurements)
+			}
+
+			// A stale version conflicts and changes nothing
+			stale := *got
+			stale.Systolic = 200
+			if err := store.UpdateReading(&stale, 2); !errors.Is(err, ErrVersionConflict) {
+				t.Errorf("stale update error = %v, want ErrVersionConflict", err)
+			}
+			if got, err = store.GetReading(r.ID); err != nil || got.Systolic == 200 || got.Version != 3 {
+				t.Errorf("after a stale update: %+v, %v; want version 3 unchanged", got, err)
+			}
+
+			// A missing reading is not found, whatever the version
+			missing := *got
+			missing.ID = r.ID + 100
+			if err := store.UpdateReading(&missing, 3); !errors.Is(err, ErrNotFound) {
+				t.Errorf("missing update error = %v, want ErrNotFound", err)
+			}
+		})
+	}
+}
@@ -0,0 +1,173 @@
+// File: internal/diagnosis/diagnosis.go
+
+package diagnosis
+
+import (
+	"fmt"
+	"os"
+	"strconv"
+	"time"
+
+	"bp-tracker/internal/database"
+	"bp-tracker/internal/models"
+	"bp-tracker/internal/utils"
+)
+
+// Protocol is the home monitoring schedule a status is computed over
+type Protocol struct {
+	Days         int  `json:"days"`           // Calendar days in the window, ending today
+	SkipFirstDay bool `json:"skip_first_day"` // Leave out the first day with readings
+	MinReadings  int  `json:"min_readings"`   // Readings needed for a high confidence status
+}
+
+// DefaultProtocol follows the home monitoring guidelines: 7 days, the
+// first left out, with at least 12 readings (morning and evening)
+var DefaultProtocol = Protocol{Days: 7, SkipFirstDay: true, MinReadings: 12}
+
+// ProtocolFromEnv returns DefaultProtocol with any of STATUS_DAYS,
+// STATUS_SKIP_FIRST_DAY and STATUS_MIN_READINGS applied
+func ProtocolFromEnv() (Protocol, error) {
+	p := DefaultProtocol
+	if v := os.Getenv("STATUS_DAYS"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil {
+			return p, fmt.Errorf("invalid STATUS_DAYS %q: %w", v, err)
+		}
+		p.Days = n
+	}
+	if v := os.Getenv("STATUS_SKIP_FIRST_DAY"); v != "" {
+		skip, err := strconv.ParseBool(v)
+		if err != nil {
+			return p, fmt.Errorf("invalid STATUS_SKIP_FIRST_DAY %q: %w", v, err)
+		}
+		p.SkipFirstDay = skip
+	}
+	if v := os.Getenv("STATUS_MIN_READINGS"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil {
+			return p, fmt.Errorf("invalid STATUS_MIN_READINGS %q: %w", v, err)
+		}
+		p.MinReadings = n
+	}
+	return p, p.Validate()
+}
+
+// Validate reports a protocol that cannot produce a status
+func (p Protocol) Validate() error {
+	switch {
+	case p.Days < 1:
+		return fmt.Errorf("status window must be at least 1 day, got %d", p.Days)
+	case p.SkipFirstDay && p.Days < 2:
+		return fmt.Errorf("status window must be at least 2 days when the first is skipped")
+	case p.MinReadings < 1:
+		return fmt.Errorf("status minimum readings must be at least 1, got %d", p.MinReadings)
+	}
+	return nil
+}
+
+// Confidence says how far a status can be relied on
+type Confidence string
+
+const (
+	ConfidenceHigh Confidence = "high" // The protocol's minimum readings were met
+	ConfidenceLow  Confidence = "low"  // Some readings, but too few
+	ConfidenceNone Confidence = "none" // No readings; there is no status
+)
+
+// Status is the classification of the average over a protocol window,
+// rather than of one session
+type Status struct {
+	Protocol Protocol  `json:"protocol"`
+	From     time.Time `json:"from"` // Local midnight starting the window
+	To       time.Time `json:"to"`   // Exclusive; when the status was computed
+
+	// SkippedDay is the first day with readings, left out by the protocol
+	SkippedDay      string `json:"skipped_day,omitempty"`
+	SkippedReadings int    `json:"skipped_readings"`
+
+	Readings int `json:"readings"` // Readings averaged
+	Days     int `json:"days"`     // Days those readings were taken on
+
+	// Summary has the averages classified, rounded as elsewhere, and the
+	// lowest and highest values
+	Summary        *models.Summary    `json:"summary,omitempty"`
+	Category       *utils.BPCategory  `json:"category,omitempty"`
+	Classification string             `json:"classification,omitempty"` // As stored, e.g. "Elevated (AHA/ACC 2017)"
+	Explanation    *utils.Explanation `json:"explanation,omitempty"`
+	Recommendation string             `json:"recommendation,omitempty"`
+
+	Confidence Confidence `json:"confidence"`
+	// Reason explains a confidence below high, e.g. "8 of 12 readings"
+	Reason string `json:"reason,omitempty"`
+}
+
+// Current computes the status as of now from the readings in store
+func Current(store database.ReadingStore, p Protocol, c utils.Classifier, loc *time.Location, now time.Time) (*Status, error) {
+	from := p.windowStart(now, loc)
+	readings, err := database.QueryAllReadings(store, database.ReadingQuery{From: from, To: now, Order: database.SortAsc})
+	if err != nil {
+		return nil, fmt.Errorf("error fetching readings for status: %w", err)
+	}
+	return Compute(readings, p, c, loc, now), nil
+}
+
+// Compute classifies the average of the readings (oldest first) that fall
+// in the protocol window ending at now. Days start at midnight in loc.
+func Compute(readings []*models.Reading, p Protocol, c utils.Classifier, loc *time.Location, now time.Time) *Status {
+	s := &Status{Protocol: p, From: p.windowStart(now, loc), To: now.In(loc)}
+
+	var window []*models.Reading
+	for _, r := range readings {
+		if !r.Timestamp.Before(s.From) && r.Timestamp.Before(now) {
+			window = append(window, r)
+		}
+	}
+	if p.SkipFirstDay && len(window) > 0 {
+		s.SkippedDay = localDay(window[0].Timestamp, loc)
+		for len(window) > 0 && localDay(window[0].Timestamp, loc) == s.SkippedDay {
+			window = window[1:]
+			s.SkippedReadings++
+		}
+	}
+
+	days := make(map[string]bool)
+	for _, r := range window {
+		days[localDay(r.Timestamp, loc)] = true
+	}
+	s.Readings, s.Days = len(window), len(days)
+
+	if s.Readings == 0 {
+		s.Confidence = ConfidenceNone
+		s.Reason = fmt.Sprintf("No readings in the last %d days", p.Days)
+		if s.SkippedReadings > 0 {
+			s.Reason = fmt.Sprintf("No readings in the last %d days after the first day (%s)", p.Days, s.SkippedDay)
+		}
+		return s
+	}
+
+	summary := database.Summarize(window)
+	s.Summary = &summary
+	explanation := c.Explain(summary.Systolic.Avg, summary.Diastolic.Avg)
+	s.Category = &explanation.Category
+	s.Classification = explanation.Category.Classification()
+	s.Explanation = &explanation
+	s.Recommendation = c.Recommendation(explanation.Category)
+
+	s.Confidence = ConfidenceHigh
+	if s.Readings < p.MinReadings {
+		s.Confidence = ConfidenceLow
+		s.Reason = fmt.Sprintf("%d of %d readings", s.Readings, p.MinReadings)
+	}
+	return s
+}
+
+// windowStart returns local midnight Days-1 days before now's day
+func (p Protocol) windowStart(now time.Time, loc *time.Location) time.Time {
+	now = now.In(loc)
+	return time.Date(now.Year(), now.Month(), now.Day()-(p.Days-1), 0, 0, 0, 0, loc)
+}
+
+// localDay returns t's calendar date in loc, e.g. "2024-03-05"
+func localDay(t time.Time, loc *time.Location) string {
+	return t.In(loc).Format("2006-01-02")
+}
@@ -0,0 +1,228 @@
+// File: internal/diagnosis/diagnosis_test.go
+
+package diagnosis
+
+import (
+	"fmt"
+	"testing"
+	"time"
+
+	"bp-tracker/internal/models"
+	"bp-tracker/internal/utils"
+)
+
+var denver = mustLoadLocation("America/Denver")
+
+func mustLoadLocation(name string) *time.Location {
+	loc, err := time.LoadLocation(name)
+	if err != nil {
+		panic(err)
+	}
+	return loc
+}
+
+// at parses a local time in Denver, e.g. "2025-11-03 08:00"
+func at(local string) time.Time {
+	t, err := time.ParseInLocation("2006-01-02 15:04", local, denver)
+	if err != nil {
+		panic(err)
+	}
+	return t
+}
+
+func reading(local string, systolic, diastolic int) *models.Reading {
+	return &models.Reading{Timestamp: at(local), Systolic: systolic, Diastolic: diastolic, Pulse: 70}
+}
+
+// twiceDaily returns n readings at 06:00 and 07:00 local, one day after
+// another from first, oldest first
+func twiceDaily(first string, n int) []*models.Reading {
+	start := at(first + " 00:00")
+	var readings []*models.Reading
+	for i := 0; i < n; i++ {
+		day := start.AddDate(0, 0, i/2)
+		readings = append(readings, reading(day.Format("2006-01-02")+fmt.Sprintf(" %02d:00", 6+i%2), 124, 78))
+	}
+	return readings
+}
+
+func TestCompute(t *testing.T) {
+	// US daylight saving time ended on 2025-11-02 and began on 2025-03-09
+	fallBack := at("2025-11-05 09:00")
+	springForward := at("2025-03-12 09:00")
+	noSkip := Protocol{Days: 7, MinReadings: 2}
+
+	tests := []struct {
+		name     string
+		protocol Protocol
+		now      time.Time
+		readings []*models.Reading
+
+		from            time.Time
+		skippedDay      string
+		skippedReadings int
+		count, days     int
+		classification  string
+		confidence      Confidence
+		reason          string
+	}{
+		{
+			name:     "window starts at local midnight across the end of DST",
+			protocol: noSkip,
+			now:      fallBack,
+			readings: []*models.Reading{
+				reading("2025-10-29 23:59", 150, 95), // Before the window
+				reading("2025-10-30 00:00", 120, 78),
+				reading("2025-11-05 08:59", 122, 78),
+				reading("2025-11-05 09:00", 180, 110), // At now, which is exclusive
+			},
+			from:           at("2025-10-30 00:00"),
+			count:          2,
+			days:           2,
+			classification: "Elevated (AHA/ACC 2017)",
+			confidence:     ConfidenceHigh,
+		},
+		{
+			name:     "window starts at local midnight across the start of DST",
+			protocol: noSkip,
+			now:      springForward,
+			readings: []*models.Reading{
+				reading("2025-03-05 23:30", 150, 95),
+				reading("2025-03-06 00:00", 118, 76),
+				reading("2025-03-09 03:00", 116, 74),
+			},
+			from:           at("2025-03-06 00:00"),
+			count:          2,
+			days:           2,
+			classification: "Normal (AHA/ACC 2017)",
+			confidence:     ConfidenceHigh,
+		},
+		{
+			name:     "first day with readings is skipped",
+			protocol: Protocol{Days: 7, SkipFirstDay: true, MinReadings: 2},
+			now:      fallBack,
+			readings: []*models.Reading{
+				reading("2025-10-31 06:00", 150, 95),
+				reading("2025-10-31 23:59", 150, 95),
+				reading("2025-11-01 00:00", 118, 76),
+				reading("2025-11-03 07:00", 118, 76),
+			},
+			from:            at("2025-10-30 00:00"),
+			skippedDay:      "2025-10-31",
+			skippedReadings: 2,
+			count:           2,
+			days:            2,
+			classification:  "Normal (AHA/ACC 2017)",
+			confidence:      ConfidenceHigh,
+		},
+		{
+			name:     "every reading on the skipped first day",
+			protocol: DefaultProtocol,
+			now:      fallBack,
+			readings: []*models.Reading{
+				reading("2025-11-03 06:00", 150, 95),
+				reading("2025-11-03 07:00", 150, 95),
+				reading("2025-11-03 21:00", 150, 95),
+			},
+			from:            at("2025-10-30 00:00"),
+			skippedDay:      "2025-11-03",
+			skippedReadings: 3,
+			confidence:      ConfidenceNone,
+			reason:          "No readings in the last 7 days after the first day (2025-11-03)",
+		},
+		{
+			name:       "no readings in the window",
+			protocol:   DefaultProtocol,
+			now:        fallBack,
+			readings:   []*models.Reading{reading("2025-10-29 08:00", 150, 95)},
+			from:       at("2025-10-30 00:00"),
+			confidence: ConfidenceNone,
+			reason:     "No readings in the last 7 days",
+		},
+		{
+			name:       "no readings at all",
+			protocol:   DefaultProtocol,
+			now:        fallBack,
+			from:       at("2025-10-30 00:00"),
+			confidence: ConfidenceNone,
+			reason:     "No readings in the last 7 days",
+		},
+		{
+			name:            "minimum readings met",
+			protocol:        DefaultProtocol,
+			now:             fallBack,
+			readings:        append([]*models.Reading{reading("2025-10-30 20:00", 150, 95)}, twiceDaily("2025-10-31", 12)...),
+			from:            at("2025-10-30 00:00"),
+			skippedDay:      "2025-10-30",
+			skippedReadings: 1,
+			count:           12,
+			days:            6,
+			classification:  "Elevated (AHA/ACC 2017)",
+			confidence:      ConfidenceHigh,
+		},
+		{
+			name:            "one reading short of the minimum",
+			protocol:        DefaultProtocol,
+			now:             fallBack,
+			readings:        append([]*models.Reading{reading("2025-10-30 20:00", 150, 95)}, twiceDaily("2025-10-31", 11)...),
+			from:            at("2025-10-30 00:00"),
+			skippedDay:      "2025-10-30",
+			skippedReadings: 1,
+			count:           11,
+			days:            6,
+			classification:  "Elevated (AHA/ACC 2017)",
+			confidence:      ConfidenceLow,
+			reason:          "11 of 12 readings",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := Compute(tt.readings, tt.protocol, utils.AHA2017, denver, tt.now)
+
+			if !s.From.Equal(tt.from) || s.From.Location() != denver {
+				t.Errorf("From = %v, want %v", s.From, tt.from)
+			}
+			if !s.To.Equal(tt.now) {
+				t.Errorf("To = %v, want %v", s.To, tt.now)
+			}
+			if s.SkippedDay != tt.skippedDay || s.SkippedReadings != tt.skippedReadings {
+				t.Errorf("skipped %q with %d readings, want %q with %d", s.SkippedDay, s.SkippedReadings, tt.skippedDay, tt.skippedReadings)
+			}
+			if s.Readings != tt.count || s.Days != tt.days {
+				t.Errorf("Readings, Days = %d, %d; want %d, %d", s.Readings, s.Days, tt.count, tt.days)
+			}
+			if s.Classification != tt.classification {
+				t.Errorf("Classification = %q, want %q", s.Classification, tt.classification)
+			}
+			if s.Confidence != tt.confidence || s.Reason != tt.reason {
+				t.Errorf("Confidence, Reason = %q, %q; want %q, %q", s.Confidence, s.Reason, tt.confidence, tt.reason)
+			}
+
+			// A status exists exactly when there are readings to average
+			hasStatus := s.Summary != nil && s.Category != nil && s.Explanation != nil && s.Recommendation != ""
+			if hasStatus != (tt.count > 0) {
+				t.Errorf("status set = %t with %d readings", hasStatus, tt.count)
+			}
+		})
+	}
+}
+
+func TestProtocolValidate(t *testing.T) {
+	tests := []struct {
+		protocol Protocol
+		valid    bool
+	}{
+		{DefaultProtocol, true},
+		{Protocol{Days: 1, MinReadings: 1}, true},
+		{Protocol{Days: 0, MinReadings: 1}, false},
+		{Protocol{Days: 1, SkipFirstDay: true, MinReadings: 1}, false},
+		{Protocol{Days: 2, SkipFirstDay: true, MinReadings: 1}, true},
+		{Protocol{Days: 7, MinReadings: 0}, false},
+	}
+	for _, tt := range tests {
+		if err := tt.protocol.Validate(); (err == nil) != tt.valid {
+			t.Errorf("%+v.Validate() = %v, want valid %t", tt.protocol, err, tt.valid)
+		}
+	}
+}
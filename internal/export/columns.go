@@ -0,0 +1,92 @@
+// File: internal/export/columns.go
+
+package export
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"bp-tracker/internal/importer"
+	"bp-tracker/internal/models"
+)
+
+// Column is one column of the tabular formats (CSV and XLSX)
+type Column struct {
+	Name    string // Query value
+	Header  string
+	Numeric bool // Written as a number rather than text in XLSX
+
+	// Value formats a reading; t is its timestamp in the export timezone
+	Value func(r *models.Reading, t time.Time) string
+}
+
+// Columns are the available columns. The first six, in this order, are the
+// default and match importer.ExportHeader so /import/csv can read the file back.
+var Columns = []Column{
+	{"date", "Date", false, func(_ *models.Reading, t time.Time) string { return t.Format("2006-01-02") }},
+	{"time", "Time", false, func(_ *models.Reading, t time.Time) string { return t.Format("15:04:05") }},
+	{"systolic", "Systolic", true, func(r *models.Reading, _ time.Time) string { return strconv.Itoa(r.Systolic) }},
+	{"diastolic", "Diastolic", true, func(r *models.Reading, _ time.Time) string { return strconv.Itoa(r.Diastolic) }},
+	{"pulse", "Pulse", true, func(r *models.Reading, _ time.Time) string { return strconv.Itoa(r.Pulse) }},
+	{"classification", "Classification", false, func(r *models.Reading, _ time.Time) string { return r.Classification }},
+	{"id", "ID", true, func(r *models.Reading, _ time.Time) string { return strconv.FormatInt(r.ID, 10) }},
+	{"timestamp", "Timestamp", false, func(_ *models.Reading, t time.Time) string { return t.Format(time.RFC3339) }},
+	{"irregular_heartbeat", "Irregular Heartbeat", false, func(r *models.Reading, _ time.Time) string { return strconv.FormatBool(r.IrregularHeartbeat) }},
+	{"source", "Source", false, func(r *models.Reading, _ time.Time) string { return r.Source }},
+}
+
+// DefaultColumns returns the columns written when none are chosen
+func DefaultColumns() []Column {
+	return Columns[:len(importer.ExportHeader)]
+}
+
+// ParseColumns resolves a comma-separated list of column names. An empty
+// value selects DefaultColumns.
+func ParseColumns(value string) ([]Column, error) {
+	if value == "" {
+		return DefaultColumns(), nil
+	}
+
+	var columns []Column
+	for _, name := range strings.Split(value, ",") {
+		name = strings.ToLower(strings.TrimSpace(name))
+		found := false
+		for _, c := range Columns {
+			if c.Name == name {
+				columns = append(columns, c)
+				found = true
+				break
+			}
+		}
+		if !found {
+			names := make([]string, len(Columns))
+			for i, c := range Columns {
+				names[i] = c.Name
+			}
+			return nil, fmt.Errorf("unknown column %q (expected %s)", name, strings.Join(names, ", "))
+		}
+	}
+	return columns, nil
+}
+
+// delimiters maps the delimiter query values to CSV field separators
+var delimiters = map[string]rune{
+	"comma": ',', ",": ',',
+	"semicolon": ';', ";": ';',
+	"tab": '\t', "\t": '\t',
+	"pipe": '|', "|": '|',
+}
+
+// ParseDelimiter resolves a delimiter name (comma, semicolon, tab or pipe)
+// or the character itself. An empty value is a comma.
+func ParseDelimiter(value string) (rune, error) {
+	if value == "" {
+		return ',', nil
+	}
+	if d, ok := delimiters[strings.ToLower(value)]; ok {
+		return d, nil
+	}
+	return 0, fmt.Errorf("unknown delimiter %q (expected comma, semicolon, tab or pipe)", value)
+}
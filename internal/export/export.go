@@ -0,0 +1,205 @@
+// File: internal/export/export.go
+
+package export
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"bp-tracker/internal/fhir"
+	"bp-tracker/internal/models"
+)
+
+// Format is a file format readings can be exported in
+type Format interface {
+	Name() string  // Identifier used in the format query parameter
+	Label() string // Human-readable name, e.g. "Excel workbook"
+
+	// MediaTypes are matched against the Accept header; the first is the
+	// format's own type
+	MediaTypes() []string
+	ContentType() string // Content-Type header value, e.g. with a charset
+	Extension() string   // Download file extension, without the dot
+
+	// NewWriter starts a file on w. Readings are written one at a time, so
+	// a format must not need the whole set up front.
+	NewWriter(w io.Writer, opts Options) Writer
+}
+
+// Writer encodes readings into one file
+type Writer interface {
+	Write(r *models.Reading) error
+	// Close writes anything that follows the last reading and flushes. It
+	// does not close the underlying io.Writer.
+	Close() error
+}
+
+// Options are the settings shared by all formats. Each format uses the
+// ones that apply to it.
+type Options struct {
+	Location  *time.Location // Timezone of dates and times; UTC when nil
+	Columns   []Column       // CSV and XLSX columns; DefaultColumns when empty
+	Delimiter rune           // CSV field separator; ',' when 0
+
+	FHIRBase string         // Server's FHIR base URL, for Bundle entry fullUrls
+	Patient  fhir.Reference // Subject of FHIR Observations
+}
+
+// location returns the timezone to write in
+func (o Options) location() *time.Location {
+	if o.Location == nil {
+		return time.UTC
+	}
+	return o.Location
+}
+
+// columns returns the tabular columns to write
+func (o Options) columns() []Column {
+	if len(o.Columns) == 0 {
+		return DefaultColumns()
+	}
+	return o.Columns
+}
+
+// ErrUnknownFormat means a format query value names no registered format
+var ErrUnknownFormat = errors.New("unknown export format")
+
+// formats holds registered formats in registration order
+var formats []Format
+
+// The built-in formats
+func init() {
+	Register(csvFormat{})
+	Register(jsonFormat{})
+	Register(ndjsonFormat{})
+	Register(xlsxFormat{})
+	Register(fhirFormat{})
+}
+
+// Register adds a format. Every endpoint that negotiates exports offers it.
+func Register(f Format) {
+	if _, ok := Lookup(f.Name()); ok {
+		panic("export: format " + f.Name() + " registered twice")
+	}
+	formats = append(formats, f)
+}
+
+// Lookup returns the registered format with the given name
+func Lookup(name string) (Format, bool) {
+	for _, f := range formats {
+		if strings.EqualFold(f.Name(), name) {
+			return f, true
+		}
+	}
+	return nil, false
+}
+
+// Formats returns the registered formats sorted by name
+func Formats() []Format {
+	list := append([]Format(nil), formats...)
+	sort.Slice(list, func(i, j int) bool { return list[i].Name() < list[j].Name() })
+	return list
+}
+
+// Names returns the registered format names, sorted
+func Names() []string {
+	var names []string
+	for _, f := range Formats() {
+		names = append(names, f.Name())
+	}
+	return names
+}
+
+// Negotiate picks the format for a request: the one named by the format
+// query value if set, otherwise the best match for the Accept header.
+// Otherwise it returns fallback, which must be registered: Accept headers
+// that allow anything get the default, and so do ones that match no format
+// (HTTP allows ignoring Accept), so older clients keep working.
+func Negotiate(format, accept, fallback string) (Format, error) {
+	if format != "" {
+		f, ok := Lookup(format)
+		if !ok {
+			return nil, fmt.Errorf("%w %q (expected %s)", ErrUnknownFormat, format, strings.Join(Names(), ", "))
+		}
+		return f, nil
+	}
+
+	def, ok := Lookup(fallback)
+	if !ok {
+		panic("export: fallback format " + fallback + " is not registered")
+	}
+	if strings.TrimSpace(accept) == "" {
+		return def, nil
+	}
+
+	for _, mediaRange := range parseAccept(accept) {
+		if f := match(mediaRange, def); f != nil {
+			return f, nil
+		}
+	}
+	return def, nil
+}
+
+// match returns the format for one media range, preferring def for
+// wildcards, or nil
+func match(mediaRange string, def Format) Format {
+	matches := func(f Format) bool {
+		for _, t := range f.MediaTypes() {
+			if mediaRange == "*/*" || mediaRange == t ||
+				(strings.HasSuffix(mediaRange, "/*") && strings.HasPrefix(t, strings.TrimSuffix(mediaRange, "*"))) {
+				return true
+			}
+		}
+		return false
+	}
+	if matches(def) {
+		return def
+	}
+	for _, f := range formats {
+		if matches(f) {
+			return f
+		}
+	}
+	return nil
+}
+
+// parseAccept returns the media ranges of an Accept header with a non-zero
+// quality, most preferred first. Equal qualities keep the header's order.
+func parseAccept(accept string) []string {
+	type weighted struct {
+		mediaRange string
+		q          float64
+	}
+	var ranges []weighted
+	for _, part := range strings.Split(accept, ",") {
+		fields := strings.Split(part, ";")
+		mediaRange := strings.ToLower(strings.TrimSpace(fields[0]))
+		if mediaRange == "" {
+			continue
+		}
+		q := 1.0
+		for _, param := range fields[1:] {
+			name, value, _ := strings.Cut(strings.TrimSpace(param), "=")
+			if strings.EqualFold(name, "q") {
+				if v, err := strconv.ParseFloat(value, 64); err == nil {
+					q = v
+				}
+			}
+		}
+		if q > 0 {
+			ranges = append(ranges, weighted{mediaRange, q})
+		}
+	}
+	sort.SliceStable(ranges, func(i, j int) bool { return ranges[i].q > ranges[j].q })
+
+	list := make([]string, len(ranges))
+	for i, r := range ranges {
+		list[i] = r.mediaRange
+	}
+	return list
+}
@@ -0,0 +1,88 @@
+// File: internal/export/fhir.go
+
+package export
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"strings"
+
+	"bp-tracker/internal/fhir"
+	"bp-tracker/internal/models"
+)
+
+// fhirFormat writes a collection Bundle of blood pressure Observations, the
+// same resources GET /fhir/Observation serves
+type fhirFormat struct{}
+
+func (fhirFormat) Name() string  { return "fhir" }
+func (fhirFormat) Label() string { return "FHIR R4 Bundle" }
+func (fhirFormat) MediaTypes() []string {
+	return []string{"application/fhir+json", "application/json+fhir"} // The second is the pre-R4 name
+}
+func (fhirFormat) ContentType() string { return "application/fhir+json" }
+func (fhirFormat) Extension() string   { return "json" }
+
+func (fhirFormat) NewWriter(w io.Writer, opts Options) Writer {
+	return &fhirWriter{w: w, opts: opts, base: strings.TrimSuffix(opts.FHIRBase, "/")}
+}
+
+// fhirWriter streams the Bundle. FHIR JSON does not allow empty arrays, so
+// "entry" is only opened by the first reading.
+type fhirWriter struct {
+	w       io.Writer
+	opts    Options
+	base    string
+	buf     bytes.Buffer
+	started bool
+	count   int
+}
+
+func (f *fhirWriter) start() error {
+	if f.started {
+		return nil
+	}
+	f.started = true
+	_, err := io.WriteString(f.w, `{"resourceType":"Bundle","type":"collection"`)
+	return err
+}
+
+func (f *fhirWriter) Write(r *models.Reading) error {
+	if err := f.start(); err != nil {
+		return err
+	}
+	obs := fhir.BloodPressureObservation(r, f.opts.Patient, f.opts.location())
+	entry := fhir.BundleEntry{Resource: obs}
+	if f.base != "" {
+		entry.FullURL = f.base + "/Observation/" + obs.ID
+	}
+
+	f.buf.Reset()
+	sep := ",\n"
+	if f.count == 0 {
+		sep = ",\"entry\":[\n"
+	}
+	f.count++
+	f.buf.WriteString(sep)
+	enc := json.NewEncoder(&f.buf)
+	enc.SetEscapeHTML(false)
+	if err := enc.Encode(entry); err != nil {
+		return err
+	}
+	f.buf.Truncate(f.buf.Len() - 1) // Encode's newline
+	_, err := f.w.Write(f.buf.Bytes())
+	return err
+}
+
+func (f *fhirWriter) Close() error {
+	if err := f.start(); err != nil {
+		return err
+	}
+	end := "}\n"
+	if f.count > 0 {
+		end = "\n]}\n"
+	}
+	_, err := io.WriteString(f.w, end)
+	return err
+}
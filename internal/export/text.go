@@ -0,0 +1,147 @@
+// File: internal/export/text.go
+
+package export
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"io"
+
+	"bp-tracker/internal/models"
+)
+
+// csvFormat writes the chosen columns with a header row. With the default
+// columns the file can be loaded again through /import/csv.
+type csvFormat struct{}
+
+func (csvFormat) Name() string         { return "csv" }
+func (csvFormat) Label() string        { return "CSV" }
+func (csvFormat) MediaTypes() []string { return []string{"text/csv"} }
+func (csvFormat) ContentType() string  { return "text/csv; charset=utf-8" }
+func (csvFormat) Extension() string    { return "csv" }
+
+func (csvFormat) NewWriter(w io.Writer, opts Options) Writer {
+	cw := csv.NewWriter(w)
+	if opts.Delimiter != 0 {
+		cw.Comma = opts.Delimiter
+	}
+	columns := opts.columns()
+	return &csvWriter{w: cw, columns: columns, record: make([]string, len(columns)), opts: opts}
+}
+
+type csvWriter struct {
+	w           *csv.Writer
+	columns     []Column
+	record      []string
+	opts        Options
+	wroteHeader bool
+}
+
+func (c *csvWriter) header() error {
+	if c.wroteHeader {
+		return nil
+	}
+	c.wroteHeader = true
+	for i, col := range c.columns {
+		c.record[i] = col.Header
+	}
+	return c.w.Write(c.record)
+}
+
+func (c *csvWriter) Write(r *models.Reading) error {
+	if err := c.header(); err != nil {
+		return err
+	}
+	t := r.Timestamp.In(c.opts.location())
+	for i, col := range c.columns {
+		c.record[i] = col.Value(r, t)
+	}
+	return c.w.Write(c.record)
+}
+
+func (c *csvWriter) Close() error {
+	if err := c.header(); err != nil {
+		return err
+	}
+	c.w.Flush()
+	return c.w.Error()
+}
+
+// jsonFormat writes an array of readings in the /api/readings shape
+type jsonFormat struct{}
+
+func (jsonFormat) Name() string         { return "json" }
+func (jsonFormat) Label() string        { return "JSON" }
+func (jsonFormat) MediaTypes() []string { return []string{"application/json"} }
+func (jsonFormat) ContentType() string  { return "application/json" }
+func (jsonFormat) Extension() string    { return "json" }
+
+func (jsonFormat) NewWriter(w io.Writer, opts Options) Writer {
+	return &jsonWriter{w: w, opts: opts}
+}
+
+type jsonWriter struct {
+	w     io.Writer
+	opts  Options
+	count int
+}
+
+func (j *jsonWriter) Write(r *models.Reading) error {
+	b, err := json.Marshal(inLocation(r, j.opts))
+	if err != nil {
+		return err
+	}
+	sep := ",\n"
+	if j.count == 0 {
+		sep = "[\n"
+	}
+	j.count++
+	if _, err := io.WriteString(j.w, sep); err != nil {
+		return err
+	}
+	_, err = j.w.Write(b)
+	return err
+}
+
+func (j *jsonWriter) Close() error {
+	end := "\n]\n"
+	if j.count == 0 {
+		end = "[]\n"
+	}
+	_, err := io.WriteString(j.w, end)
+	return err
+}
+
+// ndjsonFormat writes one reading per line, for tools that process records
+// as they arrive
+type ndjsonFormat struct{}
+
+func (ndjsonFormat) Name() string  { return "ndjson" }
+func (ndjsonFormat) Label() string { return "Newline-delimited JSON" }
+func (ndjsonFormat) MediaTypes() []string {
+	return []string{"application/x-ndjson", "application/ndjson", "application/jsonl"}
+}
+func (ndjsonFormat) ContentType() string { return "application/x-ndjson" }
+func (ndjsonFormat) Extension() string   { return "ndjson" }
+
+func (ndjsonFormat) NewWriter(w io.Writer, opts Options) Writer {
+	return &ndjsonWriter{enc: json.NewEncoder(w), opts: opts}
+}
+
+type ndjsonWriter struct {
+	enc  *json.Encoder
+	opts Options
+}
+
+func (n *ndjsonWriter) Write(r *models.Reading) error {
+	return n.enc.Encode(inLocation(r, n.opts)) // Encode adds the newline
+}
+
+func (n *ndjsonWriter) Close() error { return nil }
+
+// inLocation returns a copy of r with its timestamp in the export timezone
+func inLocation(r *models.Reading, opts Options) *models.Reading {
+	c := *r
+	c.Timestamp = r.Timestamp.In(opts.location())
+	return &c
+}
@@ -0,0 +1,190 @@
+// File: internal/export/xlsx.go
+
+package export
+
+import (
+	"archive/zip"
+	"bytes"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+
+	"bp-tracker/internal/models"
+)
+
+// xlsxMaxRows is the worksheet row limit of Excel
+const xlsxMaxRows = 1 << 20
+
+// xlsxFormat writes an Office Open XML workbook with one "Readings" sheet.
+// The package is written by hand (no spreadsheet library): a zip of the
+// minimal parts, with the sheet streamed row by row using inline strings
+// so no shared string table has to be built up front.
+type xlsxFormat struct{}
+
+func (xlsxFormat) Name() string  { return "xlsx" }
+func (xlsxFormat) Label() string { return "Excel workbook" }
+func (xlsxFormat) MediaTypes() []string {
+	return []string{"application/vnd.openxmlformats-officedocument.spreadsheetml.sheet"}
+}
+func (xlsxFormat) ContentType() string {
+	return "application/vnd.openxmlformats-officedocument.spreadsheetml.sheet"
+}
+func (xlsxFormat) Extension() string { return "xlsx" }
+
+func (xlsxFormat) NewWriter(w io.Writer, opts Options) Writer {
+	return &xlsxWriter{zip: zip.NewWriter(w), opts: opts, columns: opts.columns()}
+}
+
+// The fixed parts of the package
+var xlsxParts = []struct{ name, content string }{
+	{"[Content_Types].xml", `<?xml version="1.0" encoding="UTF-8" standalone="yes"?>
+<Types xmlns="http://schemas.openxmlformats.org/package/2006/content-types">` +
+		`<Default Extension="rels" ContentType="application/vnd.openxmlformats-package.relationships+xml"/>` +
+		`<Default Extension="xml" ContentType="application/xml"/>` +
+		`<Override PartName="/xl/workbook.xml" ContentType="application/vnd.openxmlformats-officedocument.spreadsheetml.sheet.main+xml"/>` +
+		`<Override PartName="/xl/worksheets/sheet1.xml" ContentType="application/vnd.openxmlformats-officedocument.spreadsheetml.worksheet+xml"/>` +
+		`<Override PartName="/xl/styles.xml" ContentType="application/vnd.openxmlformats-officedocument.spreadsheetml.styles+xml"/>` +
+		`</Types>`},
+	{"_rels/.rels", `<?xml version="1.0" encoding="UTF-8" standalone="yes"?>
+<Relationships xmlns="http://schemas.openxmlformats.org/package/2006/relationships">` +
+		`<Relationship Id="rId1" Type="http://schemas.openxmlformats.org/officeDocument/2006/relationships/officeDocument" Target="xl/workbook.xml"/>` +
+		`</Relationships>`},
+	{"xl/workbook.xml", `<?xml version="1.0" encoding="UTF-8" standalone="yes"?>
+<workbook xmlns="http://schemas.openxmlformats.org/spreadsheetml/2006/main" xmlns:r="http://schemas.openxmlformats.org/officeDocument/2006/relationships">` +
+		`<sheets><sheet name="Readings" sheetId="1" r:id="rId1"/></sheets>` +
+		`</workbook>`},
+	{"xl/_rels/workbook.xml.rels", `<?xml version="1.0" encoding="UTF-8" standalone="yes"?>
+<Relationships xmlns="http://schemas.openxmlformats.org/package/2006/relationships">` +
+		`<Relationship Id="rId1" Type="http://schemas.openxmlformats.org/officeDocument/2006/relationships/worksheet" Target="worksheets/sheet1.xml"/>` +
+		`<Relationship Id="rId2" Type="http://schemas.openxmlformats.org/officeDocument/2006/relationships/styles" Target="styles.xml"/>` +
+		`</Relationships>`},
+	// Style 1 is the bold header
+	{"xl/styles.xml", `<?xml version="1.0" encoding="UTF-8" standalone="yes"?>
+<styleSheet xmlns="http://schemas.openxmlformats.org/spreadsheetml/2006/main">` +
+		`<fonts count="2"><font><sz val="11"/><name val="Calibri"/></font><font><b/><sz val="11"/><name val="Calibri"/></font></fonts>` +
+		`<fills count="2"><fill><patternFill patternType="none"/></fill><fill><patternFill patternType="gray125"/></fill></fills>` +
+		`<borders count="1"><border><left/><right/><top/><bottom/><diagonal/></border></borders>` +
+		`<cellStyleXfs count="1"><xf numFmtId="0" fontId="0" fillId="0" borderId="0"/></cellStyleXfs>` +
+		`<cellXfs count="2"><xf numFmtId="0" fontId="0" fillId="0" borderId="0" xfId="0"/><xf numFmtId="0" fontId="1" fillId="0" borderId="0" xfId="0" applyFont="1"/></cellXfs>` +
+		`</styleSheet>`},
+}
+
+// The worksheet around the rows; the header row is frozen
+const (
+	xlsxSheetStart = `<?xml version="1.0" encoding="UTF-8" standalone="yes"?>
+<worksheet xmlns="http://schemas.openxmlformats.org/spreadsheetml/2006/main">` +
+		`<sheetViews><sheetView workbookViewId="0"><pane ySplit="1" topLeftCell="A2" activePane="bottomLeft" state="frozen"/></sheetView></sheetViews>` +
+		`<sheetData>`
+	xlsxSheetEnd = `</sheetData></worksheet>`
+)
+
+type xlsxWriter struct {
+	zip     *zip.Writer
+	sheet   io.Writer // Nil until the fixed parts and header are written
+	opts    Options
+	columns []Column
+	row     int
+	buf     bytes.Buffer
+}
+
+// start writes the fixed parts and opens the sheet with its header row
+func (x *xlsxWriter) start() error {
+	if x.sheet != nil {
+		return nil
+	}
+	for _, part := range xlsxParts {
+		f, err := x.zip.Create(part.name)
+		if err != nil {
+			return err
+		}
+		if _, err := io.WriteString(f, part.content); err != nil {
+			return err
+		}
+	}
+
+	sheet, err := x.zip.Create("xl/worksheets/sheet1.xml")
+	if err != nil {
+		return err
+	}
+	x.sheet = sheet
+	if _, err := io.WriteString(sheet, xlsxSheetStart); err != nil {
+		return err
+	}
+
+	x.startRow()
+	for i, col := range x.columns {
+		x.textCell(i, col.Header, 1)
+	}
+	return x.endRow()
+}
+
+func (x *xlsxWriter) Write(r *models.Reading) error {
+	if err := x.start(); err != nil {
+		return err
+	}
+	if x.row >= xlsxMaxRows {
+		return fmt.Errorf("too many readings for one worksheet (limit %d rows)", xlsxMaxRows)
+	}
+
+	t := r.Timestamp.In(x.opts.location())
+	x.startRow()
+	for i, col := range x.columns {
+		value := col.Value(r, t)
+		if col.Numeric {
+			fmt.Fprintf(&x.buf, `<c r="%s"><v>%s</v></c>`, cellRef(i, x.row), value)
+		} else if value != "" {
+			x.textCell(i, value, 0)
+		}
+	}
+	return x.endRow()
+}
+
+func (x *xlsxWriter) Close() error {
+	if err := x.start(); err != nil {
+		return err
+	}
+	if _, err := io.WriteString(x.sheet, xlsxSheetEnd); err != nil {
+		return err
+	}
+	return x.zip.Close()
+}
+
+// startRow begins the next row in buf
+func (x *xlsxWriter) startRow() {
+	x.row++
+	x.buf.Reset()
+	fmt.Fprintf(&x.buf, `<row r="%d">`, x.row)
+}
+
+// endRow writes the buffered row to the sheet
+func (x *xlsxWriter) endRow() error {
+	x.buf.WriteString(`</row>`)
+	_, err := x.sheet.Write(x.buf.Bytes())
+	return err
+}
+
+// textCell adds an inline string cell with style s (0 for none) to the row
+func (x *xlsxWriter) textCell(col int, text string, s int) {
+	fmt.Fprintf(&x.buf, `<c r="%s" t="inlineStr"`, cellRef(col, x.row))
+	if s != 0 {
+		fmt.Fprintf(&x.buf, ` s="%d"`, s)
+	}
+	x.buf.WriteString(`><is><t`)
+	if strings.TrimSpace(text) != text {
+		x.buf.WriteString(` xml:space="preserve"`)
+	}
+	x.buf.WriteString(`>`)
+	xml.EscapeText(&x.buf, []byte(text)) // Also replaces characters XML cannot hold
+	x.buf.WriteString(`</t></is></c>`)
+}
+
+// cellRef returns an A1-style reference for a 0-based column and 1-based row
+func cellRef(col, row int) string {
+	name := ""
+	for col++; col > 0; col = (col - 1) / 26 {
+		name = string(rune('A'+(col-1)%26)) + name
+	}
+	return name + strconv.Itoa(row)
+}
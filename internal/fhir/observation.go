@@ -0,0 +1,93 @@
+// File: internal/fhir/observation.go
+
+package fhir
+
+import (
+	"strconv"
+	"time"
+
+	"bp-tracker/internal/models"
+)
+
+// Code systems and profiles
+const (
+	LOINCSystem            = "http://loinc.org"
+	UCUMSystem             = "http://unitsofmeasure.org"
+	ObservationCategorySys = "http://terminology.hl7.org/CodeSystem/observation-category"
+
+	// BloodPressureProfile is the vital-signs blood pressure profile
+	BloodPressureProfile = "http://hl7.org/fhir/StructureDefinition/bp"
+)
+
+// LOINC codes for a blood pressure panel
+const (
+	LOINCBloodPressurePanel = "85354-9"
+	LOINCBloodPressureOld   = "55284-4" // "Blood pressure systolic and diastolic", used by older systems
+	LOINCSystolic           = "8480-6"
+	LOINCDiastolic          = "8462-4"
+	LOINCHeartRate          = "8867-4"
+)
+
+// Codings used in blood pressure Observations
+var (
+	vitalSignsCategory = CodeableConcept{
+		Coding: []Coding{{System: ObservationCategorySys, Code: "vital-signs", Display: "Vital Signs"}},
+		Text:   "Vital Signs",
+	}
+	bloodPressureCode = CodeableConcept{
+		Coding: []Coding{{System: LOINCSystem, Code: LOINCBloodPressurePanel, Display: "Blood pressure panel with all children optional"}},
+		Text:   "Blood pressure",
+	}
+	systolicCode = CodeableConcept{
+		Coding: []Coding{{System: LOINCSystem, Code: LOINCSystolic, Display: "Systolic blood pressure"}},
+		Text:   "Systolic blood pressure",
+	}
+	diastolicCode = CodeableConcept{
+		Coding: []Coding{{System: LOINCSystem, Code: LOINCDiastolic, Display: "Diastolic blood pressure"}},
+		Text:   "Diastolic blood pressure",
+	}
+	heartRateCode = CodeableConcept{
+		Coding: []Coding{{System: LOINCSystem, Code: LOINCHeartRate, Display: "Heart rate"}},
+		Text:   "Heart rate",
+	}
+)
+
+// mmHg returns a pressure in UCUM millimeters of mercury
+func mmHg(v int) *Quantity {
+	return &Quantity{Value: float64(v), Unit: "mmHg", System: UCUMSystem, Code: "mm[Hg]"}
+}
+
+// perMinute returns a rate in UCUM beats per minute
+func perMinute(v int) *Quantity {
+	return &Quantity{Value: float64(v), Unit: "beats/minute", System: UCUMSystem, Code: "/min"}
+}
+
+// BloodPressureObservation converts a reading into an Observation that
+// conforms to the vital-signs blood pressure profile: a LOINC 85354-9 panel
+// with systolic (8480-6) and diastolic (8462-4) components. The pulse is an
+// extra heart rate (8867-4) component, which the profile's open slicing
+// allows. The reading's timestamp is written in loc.
+func BloodPressureObservation(r *models.Reading, subject Reference, loc *time.Location) Observation {
+	obs := Observation{
+		ResourceType:      "Observation",
+		ID:                strconv.FormatInt(r.ID, 10),
+		Meta:              &Meta{Profile: []string{BloodPressureProfile}},
+		Status:            "final",
+		Category:          []CodeableConcept{vitalSignsCategory},
+		Code:              bloodPressureCode,
+		Subject:           &subject,
+		EffectiveDateTime: r.Timestamp.In(loc).Format(time.RFC3339),
+		Component: []ObservationComponent{
+			{Code: systolicCode, ValueQuantity: mmHg(r.Systolic)},
+			{Code: diastolicCode, ValueQuantity: mmHg(r.Diastolic)},
+			{Code: heartRateCode, ValueQuantity: perMinute(r.Pulse)},
+		},
+	}
+	if r.Version > 0 {
+		obs.Meta.VersionID = strconv.Itoa(r.Version)
+	}
+	if r.Classification != "" {
+		obs.Interpretation = []CodeableConcept{{Text: r.Classification}}
+	}
+	return obs
+}
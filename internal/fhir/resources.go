@@ -0,0 +1,128 @@
+// File: internal/fhir/resources.go
+
+package fhir
+
+// The subset of FHIR R4 data types used by the tracker. Optional elements
+// are omitted from JSON when empty, as FHIR requires.
+
+// Coding is a code from a code system
+type Coding struct {
+	System  string `json:"system,omitempty"`
+	Code    string `json:"code,omitempty"`
+	Display string `json:"display,omitempty"`
+}
+
+// CodeableConcept is a concept given by codings and/or text
+type CodeableConcept struct {
+	Coding []Coding `json:"coding,omitempty"`
+	Text   string   `json:"text,omitempty"`
+}
+
+// Quantity is a measured amount with UCUM units
+type Quantity struct {
+	Value  float64 `json:"value"`
+	Unit   string  `json:"unit,omitempty"`
+	System string  `json:"system,omitempty"`
+	Code   string  `json:"code,omitempty"`
+}
+
+// Reference points at another resource, e.g. "Patient/123"
+type Reference struct {
+	Reference string `json:"reference,omitempty"`
+	Display   string `json:"display,omitempty"`
+}
+
+// Meta holds resource metadata
+type Meta struct {
+	VersionID string   `json:"versionId,omitempty"`
+	Source    string   `json:"source,omitempty"` // URI of the system the resource came from
+	Profile   []string `json:"profile,omitempty"`
+}
+
+// Period is a time range; either end may be open
+type Period struct {
+	Start string `json:"start,omitempty"`
+	End   string `json:"end,omitempty"`
+}
+
+// Observation is a FHIR R4 Observation resource
+type Observation struct {
+	ResourceType      string                 `json:"resourceType"` // Always "Observation"
+	ID                string                 `json:"id,omitempty"`
+	Meta              *Meta                  `json:"meta,omitempty"`
+	Status            string                 `json:"status"`
+	Category          []CodeableConcept      `json:"category,omitempty"`
+	Code              CodeableConcept        `json:"code"`
+	Subject           *Reference             `json:"subject,omitempty"`
+	EffectiveDateTime string                 `json:"effectiveDateTime,omitempty"`
+	EffectiveInstant  string                 `json:"effectiveInstant,omitempty"`
+	EffectivePeriod   *Period                `json:"effectivePeriod,omitempty"`
+	Performer         []Reference            `json:"performer,omitempty"`
+	ValueQuantity     *Quantity              `json:"valueQuantity,omitempty"`
+	Interpretation    []CodeableConcept      `json:"interpretation,omitempty"`
+	Component         []ObservationComponent `json:"component,omitempty"`
+}
+
+// ObservationComponent is one value within a panel Observation
+type ObservationComponent struct {
+	Code          CodeableConcept `json:"code"`
+	ValueQuantity *Quantity       `json:"valueQuantity,omitempty"`
+}
+
+// HasCoding reports whether the concept includes the given system and code
+func (c CodeableConcept) HasCoding(system, code string) bool {
+	for _, coding := range c.Coding {
+		if coding.System == system && coding.Code == code {
+			return true
+		}
+	}
+	return false
+}
+
+// Bundle is a FHIR R4 Bundle resource
+type Bundle struct {
+	ResourceType string        `json:"resourceType"` // Always "Bundle"
+	Type         string        `json:"type"`
+	Total        *int          `json:"total,omitempty"`
+	Link         []BundleLink  `json:"link,omitempty"`
+	Entry        []BundleEntry `json:"entry,omitempty"`
+}
+
+// BundleLink is a related URL such as the next page of a search
+type BundleLink struct {
+	Relation string `json:"relation"`
+	URL      string `json:"url"`
+}
+
+// BundleEntry is one resource in a Bundle
+type BundleEntry struct {
+	FullURL  string       `json:"fullUrl,omitempty"`
+	Resource interface{}  `json:"resource,omitempty"`
+	Search   *BundleMatch `json:"search,omitempty"`
+}
+
+// BundleMatch says why an entry is in a search Bundle
+type BundleMatch struct {
+	Mode string `json:"mode"` // "match" for search results
+}
+
+// OperationOutcome reports errors in FHIR's own format
+type OperationOutcome struct {
+	ResourceType string         `json:"resourceType"` // Always "OperationOutcome"
+	Issue        []OutcomeIssue `json:"issue"`
+}
+
+// OutcomeIssue is one problem in an OperationOutcome
+type OutcomeIssue struct {
+	Severity    string `json:"severity"` // fatal | error | warning | information
+	Code        string `json:"code"`     // e.g. "invalid", "not-found", "exception"
+	Diagnostics string `json:"diagnostics,omitempty"`
+}
+
+// NewOperationOutcome returns an outcome with a single error issue
+func NewOperationOutcome(code, diagnostics string) OperationOutcome {
+	return OperationOutcome{
+		ResourceType: "OperationOutcome",
+		Issue:        []OutcomeIssue{{Severity: "error", Code: code, Diagnostics: diagnostics}},
+	}
+}
@@ -0,0 +1,124 @@
+// File: internal/fhir/search.go
+
+package fhir
+
+import (
+	"fmt"
+	"strings"
+	"time"
+)
+
+// DateRange is the half-open interval [From, To) selected by date search
+// parameters. Zero bounds are open.
+type DateRange struct {
+	From time.Time
+	To   time.Time
+}
+
+// dateLayouts are the FHIR date/dateTime precisions accepted in searches,
+// each with the width of the interval the value stands for
+var dateLayouts = []struct {
+	layout string
+	zoned  bool
+	next   func(time.Time) time.Time
+}{
+	{"2006", false, func(t time.Time) time.Time { return t.AddDate(1, 0, 0) }},
+	{"2006-01", false, func(t time.Time) time.Time { return t.AddDate(0, 1, 0) }},
+	{"2006-01-02", false, func(t time.Time) time.Time { return t.AddDate(0, 0, 1) }},
+	{"2006-01-02T15:04", false, func(t time.Time) time.Time { return t.Add(time.Minute) }},
+	{"2006-01-02T15:04:05", false, func(t time.Time) time.Time { return t.Add(time.Second) }},
+	{"2006-01-02T15:04Z07:00", true, func(t time.Time) time.Time { return t.Add(time.Minute) }},
+	{time.RFC3339, true, func(t time.Time) time.Time { return t.Add(time.Second) }},
+}
+
+// ParseDateParams intersects the values of repeated date search parameters,
+// e.g. date=ge2025-01-01&date=lt2025-02-01. Each value is an optional prefix
+// (eq, gt, lt, ge, le; eq by default) and a date or dateTime. A value stands
+// for the whole interval of its precision, so "2025-01" is all of January;
+// values without a zone are in loc.
+func ParseDateParams(values []string, loc *time.Location) (DateRange, error) {
+	var dr DateRange
+	for _, value := range values {
+		prefix, date := "eq", value
+		if len(value) > 2 && value[0] >= 'a' && value[0] <= 'z' {
+			prefix, date = value[:2], value[2:]
+		}
+
+		start, end, err := parseDateValue(date, loc)
+		if err != nil {
+			return dr, err
+		}
+
+		switch prefix {
+		case "eq":
+			dr.lower(start)
+			dr.upper(end)
+		case "ge":
+			dr.lower(start)
+		case "gt":
+			dr.lower(end)
+		case "le":
+			dr.upper(end)
+		case "lt":
+			dr.upper(start)
+		default:
+			return dr, fmt.Errorf("unsupported date prefix %q (use eq, gt, lt, ge or le)", prefix)
+		}
+	}
+	return dr, nil
+}
+
+// lower raises From to t if that narrows the range
+func (dr *DateRange) lower(t time.Time) {
+	if dr.From.IsZero() || t.After(dr.From) {
+		dr.From = t
+	}
+}
+
+// upper lowers To to t if that narrows the range
+func (dr *DateRange) upper(t time.Time) {
+	if dr.To.IsZero() || t.Before(dr.To) {
+		dr.To = t
+	}
+}
+
+// parseDateValue returns the interval a date or dateTime value stands for
+func parseDateValue(value string, loc *time.Location) (time.Time, time.Time, error) {
+	for _, d := range dateLayouts {
+		var t time.Time
+		var err error
+		if d.zoned {
+			t, err = time.Parse(d.layout, value)
+		} else {
+			t, err = time.ParseInLocation(d.layout, value, loc)
+		}
+		if err == nil {
+			return t, d.next(t), nil
+		}
+	}
+	return time.Time{}, time.Time{}, fmt.Errorf("invalid date %q (expected YYYY, YYYY-MM, YYYY-MM-DD or a dateTime)", value)
+}
+
+// NewSearchBundle wraps search results in a searchset Bundle. base is the
+// server's FHIR base URL, used for entry fullUrls; next may be empty.
+func NewSearchBundle(base, self, next string, observations []Observation) Bundle {
+	b := Bundle{
+		ResourceType: "Bundle",
+		Type:         "searchset",
+		Link:         []BundleLink{{Relation: "self", URL: self}},
+		Entry:        make([]BundleEntry, 0, len(observations)),
+	}
+	if next != "" {
+		b.Link = append(b.Link, BundleLink{Relation: "next", URL: next})
+	}
+
+	base = strings.TrimSuffix(base, "/")
+	for _, obs := range observations {
+		b.Entry = append(b.Entry, BundleEntry{
+			FullURL:  base + "/Observation/" + obs.ID,
+			Resource: obs,
+			Search:   &BundleMatch{Mode: "match"},
+		})
+	}
+	return b
+}
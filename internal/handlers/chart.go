@@ -0,0 +1,121 @@
+// File: internal/handlers/chart.go
+
+package handlers
+
+import (
+	"fmt"
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"bp-tracker/internal/chart"
+	"bp-tracker/internal/database"
+)
+
+// Chart size limits, in SVG user units (CSS pixels at 100%)
+const (
+	defaultChartWidth  = 800
+	defaultChartHeight = 300
+	maxChartWidth      = 2000
+	maxChartHeight     = 1200
+	maxMovingAvgDays   = 90
+)
+
+// TrendChartHandler renders readings as an SVG line chart
+// (GET /chart/trend.svg). The result is a standalone image, so the same URL
+// works in an <img> tag on the home page, in the report and in emails.
+// Query parameters:
+//   - from, to, tz: date range, as for /report (default: the last 30 days)
+//   - series: comma-separated systolic, diastolic, pulse (default: all)
+//   - bands: category bands to shade, systolic (default), diastolic or none
+//   - guideline: classifier whose categories are shaded (default: CLASSIFIER)
+//   - ma: days in a trailing moving average drawn over each series (default: none)
+//   - width, height: image size (default 800x300)
+func (h *Handler) TrendChartHandler(w http.ResponseWriter, r *http.Request) {
+	log.Println("Received request for /chart/trend.svg")
+	params := r.URL.Query()
+
+	loc, err := h.locationParam(params.Get("tz"))
+	if err != nil {
+		respondWithError(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	from, to, err := recentRange(params, loc)
+	if err != nil {
+		respondWithError(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	opts := chart.ReadingOptions{Bands: chart.MeasureSystolic}
+	if opts.Guideline, err = h.classifierFor(params.Get("guideline")); err != nil {
+		respondWithError(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	if v := params.Get("series"); v != "" {
+		for _, name := range strings.Split(v, ",") {
+			opts.Series = append(opts.Series, strings.ToLower(strings.TrimSpace(name)))
+		}
+	}
+	switch v := strings.ToLower(params.Get("bands")); v {
+	case "":
+	case "none":
+		opts.Bands = ""
+	default:
+		opts.Bands = chart.Measure(v)
+	}
+	if v := params.Get("ma"); v != "" {
+		days, err := strconv.Atoi(v)
+		if err != nil || days < 0 || days > maxMovingAvgDays {
+			respondWithError(w, fmt.Sprintf("Invalid ma (expected 0 to %d days)", maxMovingAvgDays), http.StatusBadRequest)
+			return
+		}
+		opts.MovingAverage = time.Duration(days) * 24 * time.Hour
+	}
+
+	width, err := sizeParam(params.Get("width"), defaultChartWidth, maxChartWidth)
+	if err != nil {
+		respondWithError(w, "Invalid width: "+err.Error(), http.StatusBadRequest)
+		return
+	}
+	height, err := sizeParam(params.Get("height"), defaultChartHeight, maxChartHeight)
+	if err != nil {
+		respondWithError(w, "Invalid height: "+err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	readings, err := database.QueryAllReadings(h.db, database.ReadingQuery{From: from, To: to, Order: database.SortAsc})
+	if err != nil {
+		log.Printf("ERROR TrendChartHandler - fetching readings: %v", err)
+		respondWithError(w, "Error fetching readings", http.StatusInternalServerError)
+		return
+	}
+
+	trend, err := chart.NewReadingTrend(readings, opts)
+	if err != nil {
+		respondWithError(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	trend.Title = fmt.Sprintf("Blood pressure, %s – %s", from.In(loc).Format("Jan 2, 2006"), to.Add(-time.Nanosecond).In(loc).Format("Jan 2, 2006"))
+	trend.Width, trend.Height = float64(width), float64(height)
+	trend.From, trend.To, trend.Location = from, to, loc
+
+	w.Header().Set("Content-Type", "image/svg+xml")
+	w.Header().Set("Cache-Control", "no-cache") // Changes with every new reading
+	if _, err := w.Write(trend.SVG()); err != nil {
+		log.Printf("ERROR TrendChartHandler - writing response: %v", err)
+	}
+}
+
+// sizeParam parses an image dimension between 100 and max
+func sizeParam(value string, fallback, max int) (int, error) {
+	if value == "" {
+		return fallback, nil
+	}
+	n, err := strconv.Atoi(value)
+	if err != nil || n < 100 || n > max {
+		return 0, fmt.Errorf("expected 100 to %d", max)
+	}
+	return n, nil
+}
@@ -0,0 +1,265 @@
+// File: internal/handlers/export.go
+
+package handlers
+
+import (
+	"bufio"
+	"bytes"
+	"errors"
+	"io"
+	"log"
+	"net/http"
+	"net/url"
+	"time"
+
+	"bp-tracker/internal/database"
+	"bp-tracker/internal/export"
+	"bp-tracker/internal/fhir"
+	"bp-tracker/internal/models"
+)
+
+// exportBufferSize is how much of an export is held back before the first
+// bytes are sent, so errors in the first rows still get a JSON error
+const exportBufferSize = 32 << 10
+
+// exportTimeout is how long a download may take to send
+const exportTimeout = 15 * time.Minute
+
+// ExportHandler streams readings as a download (GET /export), newest first,
+// straight from a database cursor. The format is chosen by the format query
+// value or the Accept header (default CSV): csv, json, ndjson, xlsx or fhir.
+// Query parameters:
+//   - format: a format name, overriding Accept
+//   - from, to: date range, as for /api/readings (default: all readings)
+//   - tz: timezone for the range and the dates and times written (default:
+//     TIMEZONE, which /import/csv also assumes)
+//   - columns: CSV and XLSX columns, comma-separated (default:
+//     date,time,systolic,diastolic,pulse,classification)
+//   - delimiter: CSV separator, comma (default), semicolon, tab or pipe
+//
+// On a self-hosted server, nothing is sent until the first rows are ready,
+// so a database error there is still reported as a JSON error; an error
+// after that closes the connection, leaving the client with a failed
+// download rather than a truncated file. On Lambda, which cannot close the
+// connection and buffers the whole response anyway, the export is held
+// until it is complete, so any error is a JSON error.
+func (h *Handler) ExportHandler(w http.ResponseWriter, r *http.Request) {
+	log.Println("Received request for /export")
+	format, ok := negotiateExport(w, r, "csv")
+	if !ok {
+		return
+	}
+	h.export(w, r, format)
+}
+
+// ExportCSVHandler is ExportHandler fixed to CSV (GET /export/csv)
+func (h *Handler) ExportCSVHandler(w http.ResponseWriter, r *http.Request) {
+	log.Println("Received request for /export/csv")
+	format, _ := export.Lookup("csv")
+	h.export(w, r, format)
+}
+
+// export serves a download of the requested range in format
+func (h *Handler) export(w http.ResponseWriter, r *http.Request, format export.Format) {
+	params := r.URL.Query()
+
+	loc, err := h.locationParam(params.Get("tz"))
+	if err != nil {
+		respondWithError(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	from, err := parseTimeParam(params.Get("from"), loc, false)
+	if err != nil {
+		respondWithError(w, "Invalid from: "+err.Error(), http.StatusBadRequest)
+		return
+	}
+	to, err := parseTimeParam(params.Get("to"), loc, true)
+	if err != nil {
+		respondWithError(w, "Invalid to: "+err.Error(), http.StatusBadRequest)
+		return
+	}
+	if !from.IsZero() && !to.IsZero() && !from.Before(to) {
+		respondWithError(w, "from must be before to", http.StatusBadRequest)
+		return
+	}
+
+	opts := h.exportOptions(r, loc)
+	if opts.Columns, err = export.ParseColumns(params.Get("columns")); err != nil {
+		respondWithError(w, "Invalid columns: "+err.Error(), http.StatusBadRequest)
+		return
+	}
+	if opts.Delimiter, err = export.ParseDelimiter(params.Get("delimiter")); err != nil {
+		respondWithError(w, "Invalid delimiter: "+err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	w.Header().Set("Content-Disposition", "attachment; filename="+exportFilename(from, to, loc, format))
+	q := database.ReadingQuery{From: from, To: to, Order: database.SortDesc}
+	streamExport(w, format, opts, func(fn func(*models.Reading) error) error {
+		return database.StreamReadings(r.Context(), h.db, q, fn)
+	})
+}
+
+// queryReadingsAs serves /api/readings in a format other than JSON. It
+// returns the same readings as the JSON response: all of them without
+// page parameters (see isPageQuery), otherwise one page, with the next page
+// in a Link header.
+func (h *Handler) queryReadingsAs(w http.ResponseWriter, r *http.Request, format export.Format, params url.Values) {
+	if !isPageQuery(params) {
+		loc, err := h.locationParam(params.Get("tz"))
+		if err != nil {
+			respondWithError(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		opts := h.exportOptions(r, loc)
+		streamExport(w, format, opts, func(fn func(*models.Reading) error) error {
+			return database.StreamReadings(r.Context(), h.db, database.ReadingQuery{}, fn)
+		})
+		return
+	}
+
+	q, loc, ok := h.readingQueryParams(w, params)
+	if !ok {
+		return
+	}
+	page, ok := h.queryReadingPage(w, q)
+	if !ok {
+		return
+	}
+
+	if page.NextCursor != "" {
+		next := r.URL.Query()
+		next.Set("cursor", page.NextCursor)
+		w.Header().Set("Link", "<"+r.URL.Path+"?"+next.Encode()+`>; rel="next"`)
+	}
+	log.Printf("Successfully fetched page of %d readings for /api/readings as %s", len(page.Readings), format.Name())
+	streamExport(w, format, h.exportOptions(r, loc), func(fn func(*models.Reading) error) error {
+		for _, reading := range page.Readings {
+			if err := fn(reading); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+// negotiateExport picks the export format of a request, responding with an
+// error for an unknown format name
+func negotiateExport(w http.ResponseWriter, r *http.Request, fallback string) (export.Format, bool) {
+	w.Header().Add("Vary", "Accept")
+	format, err := export.Negotiate(r.URL.Query().Get("format"), r.Header.Get("Accept"), fallback)
+	if errors.Is(err, export.ErrUnknownFormat) {
+		respondWithError(w, "Invalid format: "+err.Error(), http.StatusBadRequest)
+		return nil, false
+	}
+	return format, true
+}
+
+// exportOptions returns the format settings for a request
+func (h *Handler) exportOptions(r *http.Request, loc *time.Location) export.Options {
+	return export.Options{
+		Location: loc,
+		FHIRBase: fhirBase(r),
+		Patient:  fhir.Reference{Reference: "Patient/" + h.fhirPatient},
+	}
+}
+
+// streamExport writes the readings passed to fn by stream in format. Output
+// is buffered so that an error in the first rows can still be sent as a
+// JSON error; after that, the connection is aborted. Where it cannot be
+// aborted (see canAbort), the whole export is buffered instead, so an
+// error is always a JSON error rather than a truncated file.
+func streamExport(w http.ResponseWriter, format export.Format, opts export.Options, stream func(fn func(*models.Reading) error) error) {
+	extendDeadline(w, exportTimeout)
+	w.Header().Set("Content-Type", format.ContentType())
+
+	out := &sentWriter{w: w}
+	var dst io.Writer = out
+	var whole *bytes.Buffer
+	if !canAbort(w) {
+		whole = new(bytes.Buffer)
+		dst = whole
+	}
+	buf := bufio.NewWriterSize(dst, exportBufferSize)
+	enc := format.NewWriter(buf, opts)
+
+	err := stream(enc.Write)
+	if err == nil {
+		err = enc.Close()
+	}
+	if err == nil {
+		err = buf.Flush()
+	}
+	if err == nil && whole != nil {
+		_, err = whole.WriteTo(out)
+	}
+	if err == nil {
+		return
+	}
+
+	if !out.sent {
+		log.Printf("ERROR export - fetching readings as %s: %v", format.Name(), err)
+		w.Header().Del("Content-Disposition")
+		w.Header().Del("Link")
+		respondWithError(w, "Error fetching readings", http.StatusInternalServerError)
+		return
+	}
+	log.Printf("ERROR export - streaming readings as %s, aborting response: %v", format.Name(), err)
+	abortResponse(w)
+}
+
+// exportFilename names a download after the range, if one was given
+func exportFilename(from, to time.Time, loc *time.Location, format export.Format) string {
+	name := "blood_pressure_readings"
+	if !from.IsZero() {
+		name += "_from_" + from.In(loc).Format("2006-01-02")
+	}
+	if !to.IsZero() {
+		name += "_to_" + to.Add(-time.Nanosecond).In(loc).Format("2006-01-02")
+	}
+	return name + "." + format.Extension()
+}
+
+// sentWriter records whether anything has been written to the response
+type sentWriter struct {
+	w    io.Writer
+	sent bool
+}
+
+func (s *sentWriter) Write(p []byte) (int, error) {
+	s.sent = true
+	return s.w.Write(p)
+}
+
+// canAbort reports whether the connection under w can be taken over to
+// abort a response. It looks past wrappers such as Gin's, which offer
+// Hijack whatever they wrap, to the writer of the server itself: net/http's
+// HTTP/1 connections can be; Lambda's proxy responses and HTTP/2 streams
+// cannot.
+func canAbort(w http.ResponseWriter) bool {
+	for {
+		u, ok := w.(interface{ Unwrap() http.ResponseWriter })
+		if !ok {
+			break
+		}
+		w = u.Unwrap()
+	}
+	_, ok := w.(http.Hijacker)
+	return ok
+}
+
+// abortResponse closes the connection under a partly written response so
+// the client sees an incomplete download instead of a short but valid file.
+// Where the connection cannot be taken over the response simply ends.
+func abortResponse(w http.ResponseWriter) {
+	if !canAbort(w) {
+		log.Printf("ERROR abortResponse - cannot abort response on this connection")
+		return
+	}
+	conn, _, err := http.NewResponseController(w).Hijack()
+	if err != nil {
+		log.Printf("ERROR abortResponse - cannot abort response: %v", err)
+		return
+	}
+	conn.Close()
+}
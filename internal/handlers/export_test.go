@@ -0,0 +1,84 @@
+// File: internal/handlers/export_test.go
+
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"bp-tracker/internal/export"
+	"bp-tracker/internal/models"
+)
+
+// TestStreamExportLateError checks that a writer that cannot be aborted,
+// as on Lambda, gets a JSON error rather than a truncated file, even after
+// more than exportBufferSize has been written
+func TestStreamExportLateError(t *testing.T) {
+	format, _ := export.Lookup("csv")
+	w := httptest.NewRecorder()
+	w.Header().Set("Content-Disposition", "attachment; filename=readings.csv")
+	streamExport(w, format, export.Options{Location: time.UTC}, func(fn func(*models.Reading) error) error {
+		for i := 0; i < 5000; i++ {
+			if err := fn(&models.Reading{Timestamp: time.Unix(int64(i)*3600, 0), Systolic: 120, Diastolic: 80, Pulse: 70}); err != nil {
+				return err
+			}
+		}
+		return errors.New("connection lost")
+	})
+
+	if w.Code != http.StatusInternalServerError {
+		t.Fatalf("status = %d, want %d", w.Code, http.StatusInternalServerError)
+	}
+	var body map[string]string
+	if err := json.Unmarshal(w.Body.Bytes(), &body); err != nil || body["error"] == "" {
+		t.Errorf("body = %.100q, want a JSON error", w.Body.String())
+	}
+	if got := w.Header().Get("Content-Disposition"); got != "" {
+		t.Errorf("Content-Disposition = %q on an error", got)
+	}
+}
+
+func TestStreamExportComplete(t *testing.T) {
+	format, _ := export.Lookup("csv")
+	w := httptest.NewRecorder()
+	streamExport(w, format, export.Options{Location: time.UTC}, func(fn func(*models.Reading) error) error {
+		for i := 0; i < 5000; i++ {
+			if err := fn(&models.Reading{Timestamp: time.Unix(int64(i)*3600, 0), Systolic: 120, Diastolic: 80, Pulse: 70}); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if w.Code != http.StatusOK || w.Body.Len() < exportBufferSize {
+		t.Fatalf("status = %d with %d bytes, want the whole export", w.Code, w.Body.Len())
+	}
+}
+
+func TestCanAbort(t *testing.T) {
+	if canAbort(httptest.NewRecorder()) {
+		t.Error("canAbort(ResponseRecorder) = true")
+	}
+
+	aborts := make(chan bool, 1)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		aborts <- canAbort(&wrappedWriter{w})
+	}))
+	defer srv.Close()
+	resp, err := http.Get(srv.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+	if !<-aborts {
+		t.Error("canAbort(wrapped HTTP/1 writer) = false")
+	}
+}
+
+// wrappedWriter wraps a writer as middleware such as Gin's does
+type wrappedWriter struct{ http.ResponseWriter }
+
+func (w *wrappedWriter) Unwrap() http.ResponseWriter { return w.ResponseWriter }
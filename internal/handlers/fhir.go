@@ -0,0 +1,146 @@
+// File: internal/handlers/fhir.go
+
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"net/url"
+	"strconv"
+
+	"bp-tracker/internal/database"
+	"bp-tracker/internal/fhir"
+	"bp-tracker/internal/models"
+)
+
+// FHIRObservationSearchHandler returns readings as a searchset Bundle of blood
+// pressure Observations (GET /fhir/Observation), newest first.
+// Search parameters:
+//   - date: repeatable, e.g. date=ge2025-01-01&date=lt2025-02-01; dates
+//     without a zone are in TIMEZONE
+//   - _count: page size, default 100, max 1000
+//   - _cursor: opaque token from the Bundle's "next" link
+//
+// Other parameters are ignored and left out of the "self" link, as FHIR
+// servers do for parameters they do not support.
+func (h *Handler) FHIRObservationSearchHandler(w http.ResponseWriter, r *http.Request) {
+	log.Println("Received request for /fhir/Observation")
+	params := r.URL.Query()
+
+	dates, err := fhir.ParseDateParams(params["date"], h.location)
+	if err != nil {
+		respondWithOutcome(w, http.StatusBadRequest, "invalid", err.Error())
+		return
+	}
+	q := database.ReadingQuery{From: dates.From, To: dates.To, Cursor: params.Get("_cursor")}
+
+	if s := params.Get("_count"); s != "" {
+		if q.Limit, err = strconv.Atoi(s); err != nil || q.Limit < 1 {
+			respondWithOutcome(w, http.StatusBadRequest, "invalid", fmt.Sprintf("invalid _count %q (expected a positive integer)", s))
+			return
+		}
+		if q.Limit > database.MaxPageSize {
+			q.Limit = database.MaxPageSize
+		}
+	}
+
+	page, err := h.db.QueryReadings(q)
+	if errors.Is(err, database.ErrInvalidCursor) {
+		respondWithOutcome(w, http.StatusBadRequest, "invalid", "invalid _cursor")
+		return
+	} else if err != nil {
+		log.Printf("ERROR FHIRObservationSearchHandler: %v", err)
+		respondWithOutcome(w, http.StatusInternalServerError, "exception", "Error querying readings")
+		return
+	}
+
+	observations := make([]fhir.Observation, len(page.Readings))
+	for i, reading := range page.Readings {
+		observations[i] = h.observation(reading)
+	}
+
+	// Links repeat only the parameters that were applied
+	base := fhirBase(r)
+	used := url.Values{}
+	for _, name := range []string{"date", "_count", "_cursor"} {
+		if values, ok := params[name]; ok {
+			used[name] = values
+		}
+	}
+	self := searchURL(base, used)
+	var next string
+	if page.NextCursor != "" {
+		used.Set("_cursor", page.NextCursor)
+		next = searchURL(base, used)
+	}
+
+	respondWithFHIR(w, http.StatusOK, fhir.NewSearchBundle(base, self, next, observations))
+}
+
+// FHIRObservationReadHandler returns one reading as an Observation
+// (GET /fhir/Observation/:id), the target of the search Bundle's fullUrls
+func (h *Handler) FHIRObservationReadHandler(w http.ResponseWriter, r *http.Request) {
+	id, err := readingIDFromPath(r)
+	if err != nil {
+		respondWithOutcome(w, http.StatusBadRequest, "invalid", err.Error())
+		return
+	}
+
+	reading, err := h.db.GetReading(id)
+	if errors.Is(err, database.ErrNotFound) {
+		respondWithOutcome(w, http.StatusNotFound, "not-found", fmt.Sprintf("Observation/%d is not known", id))
+		return
+	} else if err != nil {
+		log.Printf("ERROR FHIRObservationReadHandler: Failed to fetch ID %d: %v", id, err)
+		respondWithOutcome(w, http.StatusInternalServerError, "exception", "Error fetching reading")
+		return
+	}
+
+	w.Header().Set("ETag", "W/"+versionETag(reading.Version))
+	respondWithFHIR(w, http.StatusOK, h.observation(reading))
+}
+
+// observation converts a reading for the deployment's patient (FHIR_PATIENT_ID)
+func (h *Handler) observation(r *models.Reading) fhir.Observation {
+	return fhir.BloodPressureObservation(r, fhir.Reference{Reference: "Patient/" + h.fhirPatient}, h.location)
+}
+
+// fhirBase returns the absolute FHIR base URL of this server, honoring the
+// scheme set by a proxy or load balancer
+func fhirBase(r *http.Request) string {
+	scheme := "http"
+	if proto := r.Header.Get("X-Forwarded-Proto"); proto != "" {
+		scheme = proto
+	} else if r.TLS != nil {
+		scheme = "https"
+	}
+	return scheme + "://" + r.Host + "/fhir"
+}
+
+// searchURL returns the Observation search URL for params
+func searchURL(base string, params url.Values) string {
+	if len(params) == 0 {
+		return base + "/Observation"
+	}
+	return base + "/Observation?" + params.Encode()
+}
+
+// respondWithFHIR sends a FHIR resource as application/fhir+json
+func respondWithFHIR(w http.ResponseWriter, code int, resource interface{}) {
+	w.Header().Set("Content-Type", "application/fhir+json")
+	w.WriteHeader(code)
+	enc := json.NewEncoder(w)
+	enc.SetEscapeHTML(false) // Keep "&" readable in Bundle links
+	if err := enc.Encode(resource); err != nil {
+		log.Printf("ERROR respondWithFHIR - encoding response: %v", err)
+	}
+}
+
+// respondWithOutcome sends an error as a FHIR OperationOutcome
+func respondWithOutcome(w http.ResponseWriter, code int, issueCode, message string) {
+	log.Printf("Responding with FHIR error (Code %d): %s", code, message)
+	respondWithFHIR(w, code, fhir.NewOperationOutcome(issueCode, message))
+}
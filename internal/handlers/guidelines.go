@@ -0,0 +1,65 @@
+// File: internal/handlers/guidelines.go
+
+package handlers
+
+import (
+	"net/http"
+
+	"bp-tracker/internal/utils"
+)
+
+// GuidelinesHandler lists the classification guidelines a reading can be
+// classified by (GET /api/guidelines). The deployment's default, set by
+// CLASSIFIER, is marked; clients send another ID as "guideline" on submit.
+func (h *Handler) GuidelinesHandler(w http.ResponseWriter, r *http.Request) {
+	type categoryInfo struct {
+		Name        string `json:"name"`
+		Description string `json:"description"`
+		Risk        string `json:"risk"`
+		// Lowest values that reach the category, when the guideline is a
+		// threshold table; omitted where one value alone cannot
+		Systolic  int `json:"systolic,omitempty"`
+		Diastolic int `json:"diastolic,omitempty"`
+		// Highest values, exclusive, for hypotension
+		SystolicBelow  int      `json:"systolic_below,omitempty"`
+		DiastolicBelow int      `json:"diastolic_below,omitempty"`
+		Subtypes       []string `json:"subtypes,omitempty"`
+	}
+	type guidelineInfo struct {
+		ID         string         `json:"id"`
+		Label      string         `json:"label"`
+		Default    bool           `json:"default"`
+		Categories []categoryInfo `json:"categories"`
+	}
+
+	var list []guidelineInfo
+	for _, c := range utils.Classifiers() {
+		info := guidelineInfo{ID: c.ID(), Label: c.Label(), Default: c.ID() == h.classifier.ID()}
+		if tiered, ok := c.(*utils.TieredClassifier); ok {
+			low := tiered.Hypotension()
+			info.Categories = append(info.Categories, categoryInfo{
+				Name: low.Name, Description: low.Description, Risk: low.Risk,
+				SystolicBelow: utils.HypotensionSystolic, DiastolicBelow: utils.HypotensionDiastolic,
+			})
+			for _, t := range tiered.Tiers() {
+				category := categoryInfo{Name: t.Category.Name, Description: t.Category.Description, Risk: t.Category.Risk}
+				if t.Isolated {
+					category.Subtypes = utils.Subtypes
+				}
+				if t.Systolic != utils.NoThreshold {
+					category.Systolic = t.Systolic
+				}
+				if t.Diastolic != utils.NoThreshold {
+					category.Diastolic = t.Diastolic
+				}
+				info.Categories = append(info.Categories, category)
+			}
+		} else {
+			for _, category := range c.Categories() {
+				info.Categories = append(info.Categories, categoryInfo{Name: category.Name, Description: category.Description, Risk: category.Risk})
+			}
+		}
+		list = append(list, info)
+	}
+	respondWithJSON(w, list)
+}
@@ -4,19 +4,20 @@ package handlers
 
 import (
 	"context"
-	"encoding/csv"
 	"encoding/json"
 	"errors"
 	"fmt"
 	"html/template"
-	"io/ioutil"
 	"log"
 	"net/http"
+	"net/url"
+	"os"
 	"strconv"
 	"strings"
 	"time"
 
 	"bp-tracker/internal/database"
+	"bp-tracker/internal/diagnosis"
 	"bp-tracker/internal/models"
 	"bp-tracker/internal/utils"
 	"bp-tracker/internal/validation"
@@ -24,12 +25,30 @@ import (
 
 // Handler holds dependencies for HTTP handlers
 type Handler struct {
-	db        *database.DB
+	db        database.ReadingStore
 	templates *template.Template
+
+	// discardFirst is the default "discard first reading" policy for
+	// sessions that don't specify one (DISCARD_FIRST_READING=true)
+	discardFirst bool
+
+	// location is the deployment timezone (TIMEZONE) used for date-only
+	// query parameters and stats buckets unless a request passes tz
+	location *time.Location
+
+	// fhirPatient is the Patient id that FHIR Observations refer to as
+	// their subject (FHIR_PATIENT_ID, default "self")
+	fhirPatient string
+	// classifier is the deployment's guideline (CLASSIFIER); requests may
+	// choose another
+	classifier utils.Classifier
+	// protocol is the monitoring window the current status is averaged
+	// over (STATUS_DAYS, STATUS_SKIP_FIRST_DAY, STATUS_MIN_READINGS)
+	protocol diagnosis.Protocol
 }
 
-// New creates a new Handler instance
-func New(db *database.DB) (*Handler, error) {
+// New creates a new Handler instance backed by any ReadingStore implementation
+func New(db database.ReadingStore) (*Handler, error) {
 	log.Println("Parsing templates...")
 	tmpl, err := template.ParseGlob("web/templates/*.html")
 	if err != nil {
@@ -37,9 +56,38 @@ func New(db *database.DB) (*Handler, error) {
 	}
 	log.Println("Templates parsed successfully.")
 
+	tz := os.Getenv("TIMEZONE")
+	if tz == "" {
+		tz = "America/Denver"
+	}
+	loc, err := time.LoadLocation(tz)
+	if err != nil {
+		return nil, fmt.Errorf("invalid TIMEZONE %q: %w", tz, err)
+	}
+
+	fhirPatient := os.Getenv("FHIR_PATIENT_ID")
+	if fhirPatient == "" {
+		fhirPatient = "self"
+	}
+
+	classifier, err := utils.ClassifierFromEnv()
+	if err != nil {
+		return nil, err
+	}
+
+	protocol, err := diagnosis.ProtocolFromEnv()
+	if err != nil {
+		return nil, err
+	}
+
 	h := &Handler{
-		db:        db,
-		templates: tmpl,
+		db:           db,
+		templates:    tmpl,
+		discardFirst: os.Getenv("DISCARD_FIRST_READING") == "true",
+		location:     loc,
+		fhirPatient:  fhirPatient,
+		classifier:   classifier,
+		protocol:     protocol,
 	}
 
 	// Log handler methods to confirm presence
@@ -59,8 +107,24 @@ func (h *Handler) HomeHandler(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	status, err := h.currentStatus()
+	if err != nil {
+		log.Printf("ERROR HomeHandler - computing status: %v", err)
+		http.Error(w, "Error fetching statistics", http.StatusInternalServerError)
+		return
+	}
+
+	// Stats fields are promoted so the template can keep using .LastReading etc.
+	data := struct {
+		*models.Stats
+		CurrentStatus *diagnosis.Status
+		DiscardFirst  bool
+		Guidelines    []utils.Classifier
+		Guideline     string // The deployment's default
+	}{stats, status, h.discardFirst, utils.Classifiers(), h.classifier.ID()}
+
 	// Render template
-	if err := h.templates.ExecuteTemplate(w, "index.html", stats); err != nil {
+	if err := h.templates.ExecuteTemplate(w, "index.html", data); err != nil {
 		log.Printf("ERROR HomeHandler - rendering template: %v", err)
 		http.Error(w, "Error rendering template", http.StatusInternalServerError)
 	}
@@ -75,18 +139,31 @@ func (h *Handler) SubmitReadingHandler(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	// Apply the deployment's discard-first policy unless the client chose one
+	if input.DiscardFirst == nil {
+		input.DiscardFirst = &h.discardFirst
+	}
+
 	// Validate readings
 	if err := validation.ValidateReadings(&input); err != nil {
 		respondWithError(w, err.Error(), http.StatusBadRequest)
 		return
 	}
 
-	// Calculate average
+	// Calculate the rounded session average; the individual measurements
+	// are kept on avg.Measurements and stored alongside it
 	avg := input.Average()
 
-	// Classify blood pressure
-	category := utils.ClassifyBP(avg.Systolic, avg.Diastolic)
-	avg.Classification = category.Name
+	// Classify blood pressure by the chosen guideline, recording which one
+	classifier, err := h.classifierFor(input.Guideline)
+	if err != nil {
+		respondWithError(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	explanation := classifier.Explain(avg.Systolic, avg.Diastolic)
+	category := explanation.Category
+	avg.Classification = category.Classification()
+	avg.ClassificationVersion = classifier.Version()
 	avg.Timestamp = time.Now() // Ensure timestamp is set
 
 	// Save to database with context
@@ -104,124 +181,142 @@ func (h *Handler) SubmitReadingHandler(w http.ResponseWriter, r *http.Request) {
 		respondWithError(w, "Error fetching statistics after save", http.StatusInternalServerError)
 		return
 	}
+	status, err := h.currentStatus()
+	if err != nil {
+		log.Printf("ERROR SubmitReadingHandler - computing status after save: %v", err)
+		respondWithError(w, "Error fetching statistics after save", http.StatusInternalServerError)
+		return
+	}
 
 	// Return success response
 	response := map[string]interface{}{
 		"message":        "Reading saved successfully",
-		"stats":          stats,
+		"reading":        avg,
+		"stats":          statsResponse{stats, status},
 		"classification": category,
 		"recommendation": utils.GetRecommendation(category),
+		"explanation":    explanation,
 	}
 
 	respondWithJSON(w, response)
 }
 
-// ExportCSVHandler handles the export of readings to CSV
-func (h *Handler) ExportCSVHandler(w http.ResponseWriter, r *http.Request) {
-
-	// Set headers for CSV download
-	w.Header().Set("Content-Type", "text/csv")
-	w.Header().Set("Content-Disposition", "attachment; filename=blood_pressure_readings.csv")
-
-	// Create CSV writer
-	writer := csv.NewWriter(w)
-	defer writer.Flush()
+// --- NEW HANDLER ---
+// MigrateHandler applies, rolls back or reports on the embedded schema migrations.
+// Query parameters:
+//   - action: "up" (default), "down" or "status"
+//   - steps: number of migrations to run (default: all pending for up, 1 for down)
+//   - dry_run: "true" to report the plan without changing the database
+//
+// Status is served from this POST route so that it stays behind the same
+// IAM authorization as the migration itself.
+// WARNING: This endpoint should be secured, ideally via IAM authorization.
+func (h *Handler) MigrateHandler(w http.ResponseWriter, r *http.Request) {
+	log.Println("Received request for /migrate")
 
-	// Write header
-	headers := []string{"Date", "Time", "Systolic", "Diastolic", "Pulse", "Classification"}
-	if err := writer.Write(headers); err != nil {
-		log.Printf("ERROR ExportCSVHandler - writing header: %v", err)
-		http.Error(w, "Error writing CSV headers", http.StatusInternalServerError)
+	store, ok := database.AsMigratable(h.db)
+	if !ok {
+		respondWithError(w, "Migrations are not supported by the configured storage backend", http.StatusNotImplemented)
 		return
 	}
 
-	readings, err := h.db.GetAllReadings()
+	migrator, err := store.Migrator()
 	if err != nil {
-		log.Printf("ERROR ExportCSVHandler - fetching readings: %v", err)
-		http.Error(w, "Error fetching readings", http.StatusInternalServerError)
+		msg := fmt.Sprintf("MIGRATION_ERROR: Error loading migrations: %v", err)
+		log.Println(msg)
+		respondWithError(w, msg, http.StatusInternalServerError)
 		return
 	}
 
-	// Write readings to CSV
-	for _, reading := range readings {
-		record := []string{
-			reading.Timestamp.Format("2006-01-02"),
-			reading.Timestamp.Format("15:04:05"),
-			fmt.Sprintf("%d", reading.Systolic),
-			fmt.Sprintf("%d", reading.Diastolic),
-			fmt.Sprintf("%d", reading.Pulse),
-			reading.Classification,
-		}
+	query := r.URL.Query()
+	ctx, cancel := context.WithTimeout(r.Context(), 30*time.Second) // Use request context with timeout
+	defer cancel()
 
-		if err := writer.Write(record); err != nil {
-			log.Printf("ERROR ExportCSVHandler - writing record: %v", err)
-			http.Error(w, "Error writing CSV data", http.StatusInternalServerError)
-			return // Stop writing if one record fails
+	action := query.Get("action")
+	if action == "status" {
+		states, err := migrator.Status(ctx)
+		if err != nil {
+			msg := fmt.Sprintf("MIGRATION_ERROR: Error reading migration status: %v", err)
+			log.Println(msg)
+			respondWithError(w, msg, http.StatusInternalServerError)
+			return
 		}
+		respondWithJSON(w, map[string]interface{}{"migrations": states})
+		return
 	}
-}
-
-// --- NEW HANDLER ---
-// MigrateHandler applies the schema.sql file to the database.
-// WARNING: This endpoint should be secured, ideally via IAM authorization.
-func (h *Handler) MigrateHandler(w http.ResponseWriter, r *http.Request) {
-	log.Println("Received request for /migrate")
 
-	// Define path relative to where binary runs in Lambda (/var/task)
-	schemaPath := "schema.sql"
-	schemaBytes, err := ioutil.ReadFile(schemaPath)
-	if err != nil {
-		// Specific error for file reading
-		msg := fmt.Sprintf("MIGRATION_ERROR: Error reading schema file %s: %v", schemaPath, err)
-		log.Println(msg) // Use Println for consistency
-		respondWithError(w, msg, http.StatusInternalServerError)
+	opts := database.MigrateOptions{Direction: database.MigrateUp}
+	switch action {
+	case "", "up":
+	case "down":
+		opts.Direction = database.MigrateDown
+	default:
+		respondWithError(w, fmt.Sprintf("Invalid action %q (expected up, down or status)", action), http.StatusBadRequest)
 		return
 	}
-	schemaSQL := string(schemaBytes)
-
-	// Execute schema in a transaction
-	ctx, cancel := context.WithTimeout(r.Context(), 30*time.Second) // Use request context with timeout
-	defer cancel()
 
-	tx, err := h.db.BeginTx(ctx, nil)
-	if err != nil {
-		// Specific error for transaction start
-		msg := fmt.Sprintf("MIGRATION_ERROR: Error starting transaction: %v", err)
-		log.Println(msg)
-		respondWithError(w, msg, http.StatusInternalServerError)
-		return
+	if stepsStr := query.Get("steps"); stepsStr != "" {
+		steps, err := strconv.Atoi(stepsStr)
+		if err != nil || steps < 0 {
+			respondWithError(w, "steps must be a non-negative integer", http.StatusBadRequest)
+			return
+		}
+		opts.Steps = steps
 	}
 
-	_, err = tx.ExecContext(ctx, schemaSQL)
-	if err != nil {
-		// Specific error for schema execution
-		msg := fmt.Sprintf("MIGRATION_ERROR: Error executing schema: %v", err)
-		log.Println(msg)
-		// Attempt to rollback
-		if rollbackErr := tx.Rollback(); rollbackErr != nil {
-			log.Printf("ERROR MigrateHandler - Error rolling back transaction after schema execution failure: %v", rollbackErr)
+	if dryRunStr := query.Get("dry_run"); dryRunStr != "" {
+		dryRun, err := strconv.ParseBool(dryRunStr)
+		if err != nil {
+			respondWithError(w, "dry_run must be true or false", http.StatusBadRequest)
+			return
 		}
-		respondWithError(w, msg, http.StatusInternalServerError)
-		return
+		opts.DryRun = dryRun
 	}
 
-	if err := tx.Commit(); err != nil {
-		// Specific error for commit
-		msg := fmt.Sprintf("MIGRATION_ERROR: Error committing transaction: %v", err)
+	report, err := migrator.Migrate(ctx, opts)
+	if err != nil {
+		msg := fmt.Sprintf("MIGRATION_ERROR: %v", err)
 		log.Println(msg)
 		respondWithError(w, msg, http.StatusInternalServerError)
 		return
 	}
 
-	log.Println("Schema migration applied successfully via /migrate endpoint.")
-	respondWithJSON(w, map[string]string{"message": "Schema migration applied successfully!"})
+	message := "Schema migration applied successfully!"
+	if opts.DryRun {
+		message = "Dry run: no changes were made."
+	}
+	log.Printf("Migration via /migrate finished: direction=%s dry_run=%t version %d -> %d",
+		report.Direction, report.DryRun, report.StartVersion, report.EndVersion)
+	respondWithJSON(w, map[string]interface{}{"message": message, "report": report})
 }
 
 // --- NEW HANDLER ---
 // GetAllReadingsJSONHandler handles the request to fetch all readings as JSON.
+// Without query parameters it returns every reading as a bare array, as older
+// clients expect. With any of from, to, classification, sort, limit or cursor
+// it returns one page as {"readings": [...], "next_cursor": "..."}; other
+// parameters, such as tz alone or a cache-buster, do not change the shape.
+// The Accept header or a format parameter selects another export format
+// (csv, ndjson, xlsx, fhir) for the same readings; see ExportHandler.
 func (h *Handler) GetAllReadingsJSONHandler(w http.ResponseWriter, r *http.Request) {
 	log.Println("Received request for /api/readings")
 
+	format, ok := negotiateExport(w, r, "json")
+	if !ok {
+		return
+	}
+	params := r.URL.Query()
+	params.Del("format")
+	if format.Name() != "json" {
+		h.queryReadingsAs(w, r, format, params)
+		return
+	}
+
+	if isPageQuery(params) {
+		h.queryReadings(w, params)
+		return
+	}
+
 	readings, err := h.db.GetAllReadings()
 	if err != nil {
 		log.Printf("ERROR GetAllReadingsJSONHandler - fetching readings: %v", err)
@@ -238,11 +333,139 @@ func (h *Handler) GetAllReadingsJSONHandler(w http.ResponseWriter, r *http.Reque
 	respondWithJSON(w, readings)
 }
 
+// pageParams are the query parameters that ask /api/readings for a page
+// rather than every reading
+var pageParams = []string{"from", "to", "classification", "sort", "limit", "cursor"}
+
+// isPageQuery reports whether params include any of pageParams
+func isPageQuery(params url.Values) bool {
+	for _, name := range pageParams {
+		if params.Has(name) {
+			return true
+		}
+	}
+	return false
+}
+
+// queryReadings serves a filtered, paginated page of readings
+func (h *Handler) queryReadings(w http.ResponseWriter, params url.Values) {
+	q, _, ok := h.readingQueryParams(w, params)
+	if !ok {
+		return
+	}
+	page, ok := h.queryReadingPage(w, q)
+	if !ok {
+		return
+	}
+
+	log.Printf("Successfully fetched page of %d readings for /api/readings", len(page.Readings))
+	respondWithJSON(w, page)
+}
+
+// readingQueryParams parses the /api/readings filter and paging parameters,
+// returning the query and the timezone of its dates
+func (h *Handler) readingQueryParams(w http.ResponseWriter, params url.Values) (database.ReadingQuery, *time.Location, bool) {
+	var q database.ReadingQuery
+
+	loc, err := h.locationParam(params.Get("tz"))
+	if err != nil {
+		respondWithError(w, err.Error(), http.StatusBadRequest)
+		return q, nil, false
+	}
+	if q.From, err = parseTimeParam(params.Get("from"), loc, false); err != nil {
+		respondWithError(w, "Invalid from: "+err.Error(), http.StatusBadRequest)
+		return q, nil, false
+	}
+	if q.To, err = parseTimeParam(params.Get("to"), loc, true); err != nil {
+		respondWithError(w, "Invalid to: "+err.Error(), http.StatusBadRequest)
+		return q, nil, false
+	}
+
+	// classification may be repeated or comma-separated
+	for _, v := range params["classification"] {
+		for _, name := range strings.Split(v, ",") {
+			if name = strings.TrimSpace(name); name != "" {
+				q.Classifications = append(q.Classifications, utils.ClassificationVariants(name)...)
+			}
+		}
+	}
+
+	switch sortOrder := database.SortOrder(strings.ToLower(params.Get("sort"))); sortOrder {
+	case "", database.SortDesc, database.SortAsc:
+		q.Order = sortOrder
+	default:
+		respondWithError(w, "Invalid sort (expected asc or desc)", http.StatusBadRequest)
+		return q, nil, false
+	}
+
+	if v := params.Get("limit"); v != "" {
+		if q.Limit, err = strconv.Atoi(v); err != nil || q.Limit < 1 {
+			respondWithError(w, fmt.Sprintf("Invalid limit (expected 1 to %d)", database.MaxPageSize), http.StatusBadRequest)
+			return q, nil, false
+		}
+	}
+	q.Cursor = params.Get("cursor")
+	return q, loc, true
+}
+
+// queryReadingPage runs q, responding with an error if it fails
+func (h *Handler) queryReadingPage(w http.ResponseWriter, q database.ReadingQuery) (*database.ReadingPage, bool) {
+	page, err := h.db.QueryReadings(q)
+	if errors.Is(err, database.ErrInvalidCursor) {
+		respondWithError(w, "Invalid cursor", http.StatusBadRequest)
+		return nil, false
+	} else if err != nil {
+		log.Printf("ERROR GetAllReadingsJSONHandler - querying readings: %v", err)
+		respondWithError(w, "Error fetching readings", http.StatusInternalServerError)
+		return nil, false
+	}
+	return page, true
+}
+
+// locationParam returns the timezone named by a tz query value, or the
+// deployment timezone when it is empty
+func (h *Handler) locationParam(tz string) (*time.Location, error) {
+	if tz == "" {
+		return h.location, nil
+	}
+	loc, err := time.LoadLocation(tz)
+	if err != nil {
+		return nil, fmt.Errorf("Invalid tz %q (expected an IANA name such as America/Denver)", tz)
+	}
+	return loc, nil
+}
+
+// parseTimeParam parses a date (2006-01-02, midnight in loc) or RFC 3339
+// query value. A bare date used as an upper bound covers that whole day.
+func parseTimeParam(value string, loc *time.Location, endOfDay bool) (time.Time, error) {
+	if value == "" {
+		return time.Time{}, nil
+	}
+	if t, err := time.ParseInLocation("2006-01-02", value, loc); err == nil {
+		if endOfDay {
+			t = t.AddDate(0, 0, 1)
+		}
+		return t, nil
+	}
+	t, err := time.Parse(time.RFC3339, value)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("expected YYYY-MM-DD or RFC 3339 timestamp")
+	}
+	return t, nil
+}
+
 // --- NEW HANDLER ---
 // GetStatsHandler handles the request to fetch statistics as JSON.
+// With from, to, group_by or tz it returns statistics for that range instead
+// (see rangeStats).
 func (h *Handler) GetStatsHandler(w http.ResponseWriter, r *http.Request) {
 	log.Println("Received request for /api/stats")
 
+	if r.URL.RawQuery != "" {
+		h.rangeStats(w, r)
+		return
+	}
+
 	stats, err := h.db.GetStats()
 	if err != nil {
 		log.Printf("ERROR GetStatsHandler - fetching stats: %v", err)
@@ -260,7 +483,71 @@ func (h *Handler) GetStatsHandler(w http.ResponseWriter, r *http.Request) {
 		log.Println("Warning: GetStats returned nil, sending potentially empty stats object.")
 	}
 
+	status, err := h.currentStatus()
+	if err != nil {
+		log.Printf("ERROR GetStatsHandler - computing status: %v", err)
+		respondWithError(w, "Error fetching statistics", http.StatusInternalServerError)
+		return
+	}
+
 	log.Println("Successfully fetched stats for /api/stats")
+	respondWithJSON(w, statsResponse{stats, status})
+}
+
+// statsResponse is the stats JSON: GetStats plus the current status. Stats
+// may be shared by the cache, so the status is kept beside it.
+type statsResponse struct {
+	*models.Stats
+	CurrentStatus *diagnosis.Status `json:"current_status"`
+}
+
+// currentStatus classifies the average over the deployment's monitoring
+// protocol window, by its guideline and in its timezone
+func (h *Handler) currentStatus() (*diagnosis.Status, error) {
+	return diagnosis.Current(h.db, h.protocol, h.classifier, h.location, time.Now())
+}
+
+// rangeStats serves averages, min/max and counts for a date range, optionally
+// grouped into day, week or month buckets aligned to midnight in tz
+func (h *Handler) rangeStats(w http.ResponseWriter, r *http.Request) {
+	params := r.URL.Query()
+
+	loc, err := h.locationParam(params.Get("tz"))
+	if err != nil {
+		respondWithError(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	q := database.StatsQuery{Location: loc}
+	if q.From, err = parseTimeParam(params.Get("from"), loc, false); err != nil {
+		respondWithError(w, "Invalid from: "+err.Error(), http.StatusBadRequest)
+		return
+	}
+	if q.To, err = parseTimeParam(params.Get("to"), loc, true); err != nil {
+		respondWithError(w, "Invalid to: "+err.Error(), http.StatusBadRequest)
+		return
+	}
+	if !q.From.IsZero() && !q.To.IsZero() && !q.From.Before(q.To) {
+		respondWithError(w, "from must be before to", http.StatusBadRequest)
+		return
+	}
+
+	switch groupBy := database.GroupBy(strings.ToLower(params.Get("group_by"))); groupBy {
+	case database.GroupByNone, database.GroupByDay, database.GroupByWeek, database.GroupByMonth:
+		q.GroupBy = groupBy
+	default:
+		respondWithError(w, "Invalid group_by (expected day, week or month)", http.StatusBadRequest)
+		return
+	}
+
+	stats, err := h.db.GetRangeStats(q)
+	if err != nil {
+		log.Printf("ERROR GetStatsHandler - fetching range stats: %v", err)
+		respondWithError(w, "Error fetching statistics", http.StatusInternalServerError)
+		return
+	}
+
+	log.Printf("Successfully fetched range stats for /api/stats (%d buckets)", len(stats.Buckets))
 	respondWithJSON(w, stats)
 }
 
@@ -274,24 +561,30 @@ func (h *Handler) SeedHandler(w http.ResponseWriter, r *http.Request) {
 	now := time.Now()
 	seedData := []*models.Reading{
 		// > 90 days ago
-		{Timestamp: now.AddDate(0, 0, -100), Systolic: 125, Diastolic: 83, Pulse: 70, Classification: "Hypertension Stage 1"},
-		{Timestamp: now.AddDate(0, 0, -95), Systolic: 120, Diastolic: 79, Pulse: 68, Classification: "Normal"},
+		{Timestamp: now.AddDate(0, 0, -100), Systolic: 125, Diastolic: 83, Pulse: 70},
+		{Timestamp: now.AddDate(0, 0, -95), Systolic: 120, Diastolic: 79, Pulse: 68},
 
 		// ~ 30-90 days ago
-		{Timestamp: now.AddDate(0, 0, -45), Systolic: 133, Diastolic: 86, Pulse: 74, Classification: "Hypertension Stage 1"},
-		{Timestamp: now.AddDate(0, 0, -35), Systolic: 128, Diastolic: 82, Pulse: 71, Classification: "Elevated"},
+		{Timestamp: now.AddDate(0, 0, -45), Systolic: 133, Diastolic: 86, Pulse: 74},
+		{Timestamp: now.AddDate(0, 0, -35), Systolic: 128, Diastolic: 82, Pulse: 71},
 
 		// ~ 7-30 days ago
-		{Timestamp: now.AddDate(0, 0, -25), Systolic: 142, Diastolic: 91, Pulse: 78, Classification: "Hypertension Stage 2"},
-		{Timestamp: now.AddDate(0, 0, -15), Systolic: 138, Diastolic: 87, Pulse: 76, Classification: "Hypertension Stage 1"},
-		{Timestamp: now.AddDate(0, 0, -10), Systolic: 126, Diastolic: 83, Pulse: 72, Classification: "Hypertension Stage 1"},
-		{Timestamp: now.AddDate(0, 0, -8), Systolic: 121, Diastolic: 79, Pulse: 69, Classification: "Normal"},
+		{Timestamp: now.AddDate(0, 0, -25), Systolic: 142, Diastolic: 91, Pulse: 78},
+		{Timestamp: now.AddDate(0, 0, -15), Systolic: 138, Diastolic: 87, Pulse: 76},
+		{Timestamp: now.AddDate(0, 0, -10), Systolic: 126, Diastolic: 83, Pulse: 72},
+		{Timestamp: now.AddDate(0, 0, -8), Systolic: 121, Diastolic: 79, Pulse: 69},
 
 		// < 7 days ago
-		{Timestamp: now.AddDate(0, 0, -6), Systolic: 118, Diastolic: 78, Pulse: 65, Classification: "Normal"},
-		{Timestamp: now.AddDate(0, 0, -5), Systolic: 122, Diastolic: 81, Pulse: 70, Classification: "Elevated"},
-		{Timestamp: now.AddDate(0, 0, -3), Systolic: 135, Diastolic: 88, Pulse: 75, Classification: "Hypertension Stage 1"},
-		{Timestamp: now.AddDate(0, 0, -1), Systolic: 141, Diastolic: 90, Pulse: 76, Classification: "Hypertension Stage 2"},
+		{Timestamp: now.AddDate(0, 0, -6), Systolic: 118, Diastolic: 78, Pulse: 65},
+		{Timestamp: now.AddDate(0, 0, -5), Systolic: 122, Diastolic: 81, Pulse: 70},
+		{Timestamp: now.AddDate(0, 0, -3), Systolic: 135, Diastolic: 88, Pulse: 75},
+		{Timestamp: now.AddDate(0, 0, -1), Systolic: 141, Diastolic: 90, Pulse: 76},
+	}
+
+	// Classify as SubmitReadingHandler would, so seeding creates no drift
+	for _, reading := range seedData {
+		reading.Classification = h.classifier.Classify(reading.Systolic, reading.Diastolic).Classification()
+		reading.ClassificationVersion = h.classifier.Version()
 	}
 
 	err := h.db.SeedReadings(seedData)
@@ -320,40 +613,21 @@ func (h *Handler) ClearHandler(w http.ResponseWriter, r *http.Request) {
 
 // DeleteReadingHandler handles requests to delete a specific reading.
 func (h *Handler) DeleteReadingHandler(w http.ResponseWriter, r *http.Request) {
-	// Need to get the ID from the URL path parameter
-	// Using ginadapter means we access parameters via gin context,
-	// but since we wrapped http.HandlerFunc, we need a way to access it.
-	// This might require adjusting how routes are handled or using a different adapter
-	// if direct path param access is needed without full Gin context.
-
-	// --- WORKAROUND (Less Ideal): Extract ID from URL path string ---
-	// Assumes URL like /api/readings/123
-	// NOTE: This is fragile and depends on the exact path registered in main.go
-	// A better solution involves using a router that properly populates context/vars.
-	pathParts := strings.Split(strings.Trim(r.URL.Path, "/"), "/")
-	if len(pathParts) < 3 { // Expecting ["api", "readings", "id"]
-		 log.Printf("ERROR DeleteReadingHandler: Invalid path structure: %s", r.URL.Path)
-		 respondWithError(w, "Invalid request path for deleting reading", http.StatusBadRequest)
-		 return
-	}
-	idStr := pathParts[len(pathParts)-1] // Get the last part as ID
-	// --- End Workaround ---
-
-	log.Printf("Received request to delete reading with ID string: %s", idStr)
-
-	id, err := strconv.ParseInt(idStr, 10, 64)
+	id, err := readingIDFromPath(r)
 	if err != nil {
-		log.Printf("ERROR DeleteReadingHandler: Invalid ID format '%s': %v", idStr, err)
-		respondWithError(w, "Invalid reading ID format", http.StatusBadRequest)
+		log.Printf("ERROR DeleteReadingHandler: %v", err)
+		respondWithError(w, err.Error(), http.StatusBadRequest)
 		return
 	}
 
+	log.Printf("Received request to delete reading with ID: %d", id)
+
 	err = h.db.DeleteReading(id)
 	if err != nil {
 		// Check if it's a "not found" error specifically
-		if strings.Contains(err.Error(), "no reading found") {
+		if errors.Is(err, database.ErrNotFound) {
 			 log.Printf("INFO DeleteReadingHandler: Reading ID %d not found: %v", id, err)
-			 respondWithError(w, err.Error(), http.StatusNotFound) // 404 Not Found
+			 respondWithError(w, fmt.Sprintf("no reading found with id %d", id), http.StatusNotFound) // 404 Not Found
 		} else {
 			 log.Printf("ERROR DeleteReadingHandler: Failed to delete ID %d: %v", id, err)
 			respondWithError(w, fmt.Sprintf("Error deleting reading: %v", err), http.StatusInternalServerError)
@@ -364,6 +638,199 @@ func (h *Handler) DeleteReadingHandler(w http.ResponseWriter, r *http.Request) {
 	respondWithJSON(w, map[string]string{"message": fmt.Sprintf("Successfully deleted reading %d", id)})
 }
 
+// GetReadingHandler returns a single reading with its measurements. The
+// ETag header carries the reading's version for use in If-Match on updates.
+func (h *Handler) GetReadingHandler(w http.ResponseWriter, r *http.Request) {
+	id, err := readingIDFromPath(r)
+	if err != nil {
+		respondWithError(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	reading, err := h.db.GetReading(id)
+	if errors.Is(err, database.ErrNotFound) {
+		respondWithError(w, fmt.Sprintf("no reading found with id %d", id), http.StatusNotFound)
+		return
+	} else if err != nil {
+		log.Printf("ERROR GetReadingHandler: Failed to fetch ID %d: %v", id, err)
+		respondWithError(w, "Error fetching reading", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("ETag", versionETag(reading.Version))
+	respondWithJSON(w, reading)
+}
+
+// UpdateReadingHandler edits an existing reading (PUT or PATCH /api/readings/:id).
+//
+// PUT replaces the session's measurements and requires them; PATCH may send
+// only the fields that change (e.g. just the timestamp). Either way the
+// average and classification are recomputed and the original timestamp is
+// kept unless a new one is given. The client must say which version it
+// edited, via an If-Match header (412 on mismatch) or a "version" field in
+// the body (409 on mismatch), so concurrent edits are never silently lost.
+func (h *Handler) UpdateReadingHandler(w http.ResponseWriter, r *http.Request) {
+	id, err := readingIDFromPath(r)
+	if err != nil {
+		respondWithError(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	var input models.ReadingInput
+	if err := json.NewDecoder(r.Body).Decode(&input); err != nil {
+		respondWithError(w, "Invalid input format", http.StatusBadRequest)
+		return
+	}
+
+	// Work out which version the client's edit is based on
+	var expectedVersion int
+	conflictStatus := http.StatusConflict
+	if match := r.Header.Get("If-Match"); match != "" {
+		v, err := parseVersionETag(match)
+		if err != nil {
+			respondWithError(w, "Invalid If-Match header", http.StatusBadRequest)
+			return
+		}
+		expectedVersion = v
+		conflictStatus = http.StatusPreconditionFailed
+	} else if input.Version != nil {
+		expectedVersion = *input.Version
+	} else {
+		respondWithError(w, "Updates require an If-Match header or a version field", http.StatusPreconditionRequired)
+		return
+	}
+
+	existing, err := h.db.GetReading(id)
+	if errors.Is(err, database.ErrNotFound) {
+		respondWithError(w, fmt.Sprintf("no reading found with id %d", id), http.StatusNotFound)
+		return
+	} else if err != nil {
+		log.Printf("ERROR UpdateReadingHandler: Failed to fetch ID %d: %v", id, err)
+		respondWithError(w, "Error fetching reading", http.StatusInternalServerError)
+		return
+	}
+
+	hasMeasurements := len(input.SessionMeasurements()) > 0
+	if r.Method == http.MethodPut && !hasMeasurements {
+		respondWithError(w, "PUT requires the session's measurements; use PATCH to change other fields only", http.StatusBadRequest)
+		return
+	}
+
+	// A PATCH that only toggles discard_first re-applies it to the stored measurements
+	if !hasMeasurements && input.DiscardFirst != nil && len(existing.Measurements) > 0 {
+		input.Measurements = existing.Measurements
+		hasMeasurements = true
+	}
+
+	updated := *existing
+	updated.Measurements = nil // Keep the stored measurements unless new ones are sent
+	if hasMeasurements {
+		if input.DiscardFirst == nil {
+			input.DiscardFirst = &h.discardFirst
+		}
+		if err := validation.ValidateReadings(&input); err != nil {
+			respondWithError(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		avg := input.Average()
+		updated.Systolic, updated.Diastolic, updated.Pulse = avg.Systolic, avg.Diastolic, avg.Pulse
+		updated.Measurements = avg.Measurements
+	}
+
+	if input.Timestamp != "" {
+		ts, err := models.ParseTimestamp(input.Timestamp)
+		if err != nil {
+			respondWithError(w, "Invalid timestamp (expected \"2006-01-02 15:04:05\" or RFC 3339)", http.StatusBadRequest)
+			return
+		}
+		updated.Timestamp = ts
+	}
+
+	// Keep the reading's guideline unless the edit chooses one
+	guideline := input.Guideline
+	if c, _, ok := utils.ParseClassification(existing.Classification); ok && guideline == "" {
+		guideline = c.ID()
+	}
+	classifier, err := h.classifierFor(guideline)
+	if err != nil {
+		respondWithError(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	explanation := classifier.Explain(updated.Systolic, updated.Diastolic)
+	category := explanation.Category
+	updated.Classification = category.Classification()
+	updated.ClassificationVersion = classifier.Version()
+
+	if err := h.db.UpdateReading(&updated, expectedVersion); err != nil {
+		switch {
+		case errors.Is(err, database.ErrNotFound):
+			respondWithError(w, fmt.Sprintf("no reading found with id %d", id), http.StatusNotFound)
+		case errors.Is(err, database.ErrVersionConflict):
+			log.Printf("INFO UpdateReadingHandler: %v", err)
+			respondWithError(w, err.Error(), conflictStatus)
+		default:
+			log.Printf("ERROR UpdateReadingHandler: Failed to update ID %d: %v", id, err)
+			respondWithError(w, "Error updating reading", http.StatusInternalServerError)
+		}
+		return
+	}
+
+	// Reload so the response includes the measurements actually stored
+	reading, err := h.db.GetReading(id)
+	if err != nil {
+		log.Printf("ERROR UpdateReadingHandler: Failed to reload ID %d: %v", id, err)
+		respondWithError(w, "Error fetching reading after update", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("ETag", versionETag(reading.Version))
+	respondWithJSON(w, map[string]interface{}{
+		"message":        fmt.Sprintf("Successfully updated reading %d", id),
+		"reading":        reading,
+		"classification": category,
+		"recommendation": utils.GetRecommendation(category),
+		"explanation":    explanation,
+	})
+}
+
+// classifierFor returns the classifier with the given ID, or the
+// deployment's when id is empty
+func (h *Handler) classifierFor(id string) (utils.Classifier, error) {
+	if id == "" {
+		return h.classifier, nil
+	}
+	c, ok := utils.LookupClassifier(id)
+	if !ok {
+		return nil, fmt.Errorf("Invalid guideline %q (expected %s)", id, strings.Join(utils.ClassifierIDs(), ", "))
+	}
+	return c, nil
+}
+
+// readingIDFromPath extracts the reading ID from a /api/readings/:id path.
+// The handlers are wrapped http.HandlerFuncs, so Gin's c.Param is unavailable.
+func readingIDFromPath(r *http.Request) (int64, error) {
+	pathParts := strings.Split(strings.Trim(r.URL.Path, "/"), "/")
+	if len(pathParts) < 3 { // Expecting ["api", "readings", "id"]
+		return 0, fmt.Errorf("invalid request path: %s", r.URL.Path)
+	}
+
+	id, err := strconv.ParseInt(pathParts[len(pathParts)-1], 10, 64)
+	if err != nil {
+		return 0, fmt.Errorf("invalid reading ID format")
+	}
+	return id, nil
+}
+
+// versionETag formats a reading version as a strong ETag
+func versionETag(version int) string {
+	return fmt.Sprintf("%q", strconv.Itoa(version))
+}
+
+// parseVersionETag parses an If-Match value produced by versionETag
+func parseVersionETag(etag string) (int, error) {
+	return strconv.Atoi(strings.Trim(strings.TrimPrefix(strings.TrimSpace(etag), "W/"), `"`))
+}
+
 // respondWithError sends an error response as JSON
 func respondWithError(w http.ResponseWriter, message string, code int) {
 	log.Printf("Responding with error (Code %d): %s", code, message) // Add logging here
@@ -386,6 +853,20 @@ func respondWithJSON(w http.ResponseWriter, data interface{}) {
 	}
 }
 
+// extendDeadline gives a handler that reads a large upload or streams a
+// large download d from now, instead of the server's default timeouts.
+// Writers without deadlines (e.g. under Lambda) are left alone.
+func extendDeadline(w http.ResponseWriter, d time.Duration) {
+	rc := http.NewResponseController(w)
+	deadline := time.Now().Add(d)
+	if err := rc.SetReadDeadline(deadline); err != nil && !errors.Is(err, http.ErrNotSupported) {
+		log.Printf("WARNING extendDeadline - setting read deadline: %v", err)
+	}
+	if err := rc.SetWriteDeadline(deadline); err != nil && !errors.Is(err, http.ErrNotSupported) {
+		log.Printf("WARNING extendDeadline - setting write deadline: %v", err)
+	}
+}
+
 // Removed securityHeaders middleware function as it's handled in main.go/Gin now
 /*
 func (h *Handler) securityHeaders(next http.HandlerFunc) http.HandlerFunc {
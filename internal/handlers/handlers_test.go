@@ -0,0 +1,188 @@
+// File: internal/handlers/handlers_test.go
+
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"bp-tracker/internal/database"
+	"bp-tracker/internal/diagnosis"
+	"bp-tracker/internal/models"
+	"bp-tracker/internal/utils"
+)
+
+// newTestHandler returns a Handler over store with the defaults New would
+// use, without templates
+func newTestHandler(t *testing.T, store database.ReadingStore) *Handler {
+	t.Helper()
+	loc, err := time.LoadLocation("America/Denver")
+	if err != nil {
+		t.Fatal(err)
+	}
+	return &Handler{
+		db:          store,
+		location:    loc,
+		fhirPatient: "self",
+		classifier:  utils.AHA2017,
+		protocol:    diagnosis.DefaultProtocol,
+	}
+}
+
+// racingStore runs before ahead of each UpdateReading, as another client
+// would between the handler's fetch and its update
+type racingStore struct {
+	*database.MemoryStore
+	before func()
+}
+
+func (s *racingStore) UpdateReading(r *models.Reading, expectedVersion int) error {
+	s.before()
+	return s.MemoryStore.UpdateReading(r, expectedVersion)
+}
+
+func TestUpdateReadingHandler(t *testing.T) {
+	const measurements = `"measurements": [{"systolic": 118, "diastolic": 76, "pulse": 70}]`
+
+	tests := []struct {
+		name    string
+		method  string
+		id      string
+		ifMatch string
+		body    string
+		race    func(t *testing.T, store *database.MemoryStore) // Before the update
+		status  int
+		etag    string
+	}{
+		{name: "If-Match", method: http.MethodPut, id: "1", ifMatch: `"1"`, body: `{` + measurements + `}`, status: http.StatusOK, etag: `"2"`},
+		{name: "weak If-Match", method: http.MethodPatch, id: "1", ifMatch: `W/"1"`, body: `{"timestamp": "2025-01-02 08:00:00"}`, status: http.StatusOK, etag: `"2"`},
+		{name: "body version", method: http.MethodPatch, id: "1", body: `{"version": 1, "timestamp": "2025-01-02 08:00:00"}`, status: http.StatusOK, etag: `"2"`},
+		{name: "stale If-Match", method: http.MethodPut, id: "1", ifMatch: `"0"`, body: `{` + measurements + `}`, status: http.StatusPreconditionFailed},
+		{name: "stale body version", method: http.MethodPatch, id: "1", body: `{"version": 2, "timestamp": "2025-01-02 08:00:00"}`, status: http.StatusConflict},
+		{name: "If-Match wins over body version", method: http.MethodPatch, id: "1", ifMatch: `"2"`, body: `{"version": 1}`, status: http.StatusPreconditionFailed},
+		{name: "no version", method: http.MethodPut, id: "1", body: `{` + measurements + `}`, status: http.StatusPreconditionRequired},
+		{name: "invalid If-Match", method: http.MethodPut, id: "1", ifMatch: `"one"`, body: `{` + measurements + `}`, status: http.StatusBadRequest},
+		{name: "PUT without measurements", method: http.MethodPut, id: "1", ifMatch: `"1"`, body: `{"timestamp": "2025-01-02 08:00:00"}`, status: http.StatusBadRequest},
+		{name: "not found", method: http.MethodPut, id: "99", ifMatch: `"1"`, body: `{` + measurements + `}`, status: http.StatusNotFound},
+		{
+			name: "deleted after fetch", method: http.MethodPut, id: "1", ifMatch: `"1"`, body: `{` + measurements + `}`,
+			race: func(t *testing.T, store *database.MemoryStore) {
+				if err := store.DeleteReading(1); err != nil {
+					t.Fatal(err)
+				}
+			},
+			status: http.StatusNotFound,
+		},
+		{
+			name: "edited after fetch", method: http.MethodPut, id: "1", ifMatch: `"1"`, body: `{` + measurements + `}`,
+			race: func(t *testing.T, store *database.MemoryStore) {
+				r, err := store.GetReading(1)
+				if err != nil {
+					t.Fatal(err)
+				}
+				if err := store.UpdateReading(r, r.Version); err != nil {
+					t.Fatal(err)
+				}
+			},
+			status: http.StatusPreconditionFailed,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			memory := database.NewMemoryStore()
+			seed := &models.Reading{Timestamp: time.Date(2025, 1, 1, 15, 0, 0, 0, time.UTC), Systolic: 135, Diastolic: 85, Pulse: 72}
+			if err := memory.SaveReading(seed); err != nil {
+				t.Fatal(err)
+			}
+			store := &racingStore{MemoryStore: memory, before: func() {}}
+			if tt.race != nil {
+				store.before = func() { tt.race(t, memory) }
+			}
+			h := newTestHandler(t, store)
+
+			req := httptest.NewRequest(tt.method, "/api/readings/"+tt.id, strings.NewReader(tt.body))
+			if tt.ifMatch != "" {
+				req.Header.Set("If-Match", tt.ifMatch)
+			}
+			w := httptest.NewRecorder()
+			h.UpdateReadingHandler(w, req)
+
+			if w.Code != tt.status {
+				t.Fatalf("status = %d, want %d: %s", w.Code, tt.status, w.Body.String())
+			}
+			if got := w.Header().Get("ETag"); got != tt.etag {
+				t.Errorf("ETag = %q, want %q", got, tt.etag)
+			}
+
+			stored, err := memory.GetReading(1)
+			if tt.status != http.StatusOK {
+				if tt.race == nil && (err != nil || stored.Version != 1 || stored.Systolic != 135) {
+					t.Errorf("rejected update changed the reading: %+v, %v", stored, err)
+				}
+				return
+			}
+
+			var resp struct {
+				Reading models.Reading `json:"reading"`
+			}
+			if err := json.Unmarshal(w.Body.Bytes(), &resp); err != nil {
+				t.Fatal(err)
+			}
+			if err != nil || resp.Reading.Version != 2 || stored.Version != 2 {
+				t.Errorf("stored %+v (%v), response %+v; want version 2", stored, err, resp.Reading)
+			}
+			if stored.Classification == "" || stored.ClassificationVersion != utils.AHA2017.Version() {
+				t.Errorf("classification = %q (%s), want it recomputed", stored.Classification, stored.ClassificationVersion)
+			}
+		})
+	}
+}
+
+// TestGetAllReadingsShape checks that only page parameters switch
+// /api/readings from the bare array older clients expect to a page
+func TestGetAllReadingsShape(t *testing.T) {
+	store := database.NewMemoryStore()
+	if err := store.SaveReading(&models.Reading{Timestamp: time.Date(2025, 1, 1, 15, 0, 0, 0, time.UTC), Systolic: 118, Diastolic: 76, Pulse: 70}); err != nil {
+		t.Fatal(err)
+	}
+	h := newTestHandler(t, store)
+
+	tests := []struct {
+		query string
+		page  bool
+	}{
+		{"", false},
+		{"?_=123", false},
+		{"?tz=Europe/Paris", false},
+		{"?format=json", false},
+		{"?from=2025-01-01", true},
+		{"?to=2025-02-01", true},
+		{"?classification=Normal", true},
+		{"?sort=asc", true},
+		{"?limit=10", true},
+		{"?_=123&limit=10", true},
+	}
+	for _, tt := range tests {
+		w := httptest.NewRecorder()
+		h.GetAllReadingsJSONHandler(w, httptest.NewRequest(http.MethodGet, "/api/readings"+tt.query, nil))
+		if w.Code != http.StatusOK {
+			t.Fatalf("%s: status = %d: %s", tt.query, w.Code, w.Body.String())
+		}
+		isPage := strings.HasPrefix(strings.TrimSpace(w.Body.String()), "{")
+		if isPage != tt.page {
+			t.Errorf("%q returned %s, want a page %t", tt.query, w.Body.String(), tt.page)
+		}
+	}
+
+	// Other formats follow the same rule: all readings, without a Link header
+	w := httptest.NewRecorder()
+	h.GetAllReadingsJSONHandler(w, httptest.NewRequest(http.MethodGet, "/api/readings?format=csv&_=123", nil))
+	if w.Code != http.StatusOK || w.Header().Get("Link") != "" || strings.Count(w.Body.String(), "\n") != 2 {
+		t.Errorf("CSV with a cache-buster = %d, Link %q: %s", w.Code, w.Header().Get("Link"), w.Body.String())
+	}
+}
@@ -0,0 +1,102 @@
+// File: internal/handlers/hl7.go
+
+package handlers
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"time"
+
+	"bp-tracker/internal/database"
+	"bp-tracker/internal/hl7"
+)
+
+// hl7ContentType is the usual media type for pipe-encoded HL7 v2
+const hl7ContentType = "x-application/hl7-v2+er7"
+
+// ExportHL7Handler returns readings as a batch of HL7 v2 ORU^R01 messages
+// (GET /export/hl7), oldest first, for clinics that only accept HL7 feeds.
+// Query parameters:
+//   - from, to: date range, as for /api/readings (default: all readings)
+//   - tz: timezone for the range and the message timestamps (default TIMEZONE)
+func (h *Handler) ExportHL7Handler(w http.ResponseWriter, r *http.Request) {
+	log.Println("Received request for /export/hl7")
+	params := r.URL.Query()
+
+	loc, err := h.locationParam(params.Get("tz"))
+	if err != nil {
+		respondWithError(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	q := database.ReadingQuery{Order: database.SortAsc}
+	if q.From, err = parseTimeParam(params.Get("from"), loc, false); err != nil {
+		respondWithError(w, "Invalid from: "+err.Error(), http.StatusBadRequest)
+		return
+	}
+	if q.To, err = parseTimeParam(params.Get("to"), loc, true); err != nil {
+		respondWithError(w, "Invalid to: "+err.Error(), http.StatusBadRequest)
+		return
+	}
+	if !q.From.IsZero() && !q.To.IsZero() && !q.From.Before(q.To) {
+		respondWithError(w, "from must be before to", http.StatusBadRequest)
+		return
+	}
+
+	extendDeadline(w, exportTimeout)
+	readings, err := database.QueryAllReadings(h.db, q)
+	if err != nil {
+		log.Printf("ERROR ExportHL7Handler - fetching readings: %v", err)
+		respondWithError(w, "Error fetching readings", http.StatusInternalServerError)
+		return
+	}
+
+	log.Printf("Exporting %d readings as HL7", len(readings))
+	respondWithHL7(w, "blood_pressure_readings.hl7", hl7.Batch(readings, h.hl7Options(loc)))
+}
+
+// ExportHL7ReadingHandler returns one reading as a single ORU^R01 message
+// (GET /export/hl7/:id). tz sets the timezone of the timestamps.
+func (h *Handler) ExportHL7ReadingHandler(w http.ResponseWriter, r *http.Request) {
+	id, err := readingIDFromPath(r)
+	if err != nil {
+		respondWithError(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	loc, err := h.locationParam(r.URL.Query().Get("tz"))
+	if err != nil {
+		respondWithError(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	reading, err := h.db.GetReading(id)
+	if errors.Is(err, database.ErrNotFound) {
+		respondWithError(w, fmt.Sprintf("Reading %d not found", id), http.StatusNotFound)
+		return
+	} else if err != nil {
+		log.Printf("ERROR ExportHL7ReadingHandler: Failed to fetch ID %d: %v", id, err)
+		respondWithError(w, "Error fetching reading", http.StatusInternalServerError)
+		return
+	}
+
+	respondWithHL7(w, fmt.Sprintf("blood_pressure_reading_%d.hl7", id), hl7.ORU(reading, h.hl7Options(loc)))
+}
+
+// hl7Options returns the deployment's HL7 settings (HL7_* variables) for
+// the FHIR patient, with timestamps in loc
+func (h *Handler) hl7Options(loc *time.Location) hl7.Options {
+	opts := hl7.OptionsFromEnv()
+	opts.PatientID = h.fhirPatient
+	opts.Location = loc
+	return opts
+}
+
+// respondWithHL7 sends HL7 messages as a file download
+func respondWithHL7(w http.ResponseWriter, filename string, messages []byte) {
+	w.Header().Set("Content-Type", hl7ContentType)
+	w.Header().Set("Content-Disposition", "attachment; filename="+filename)
+	if _, err := w.Write(messages); err != nil {
+		log.Printf("ERROR respondWithHL7 - writing response: %v", err)
+	}
+}
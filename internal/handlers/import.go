@@ -0,0 +1,248 @@
+// File: internal/handlers/import.go
+
+package handlers
+
+import (
+	"fmt"
+	"io"
+	"log"
+	"mime"
+	"mime/multipart"
+	"net/http"
+	"os"
+	"time"
+
+	"bp-tracker/internal/importer"
+)
+
+// maxImportSize limits uploaded import files
+const maxImportSize = 10 << 20 // 10 MB
+
+// importTimeout is how long an upload may take to arrive and be imported
+const importTimeout = 15 * time.Minute
+
+// maxAppleHealthSize limits Apple Health exports, which are spooled to a
+// temporary file rather than held in memory
+const maxAppleHealthSize = 2 << 30 // 2 GB
+
+// ImportCSVHandler loads a file produced by ExportCSVHandler (POST /import/csv).
+// It accepts the same parameters as ImportHandler except format.
+func (h *Handler) ImportCSVHandler(w http.ResponseWriter, r *http.Request) {
+	log.Println("Received request for /import/csv")
+	h.importCSV(w, r, importer.ExportFormat)
+}
+
+// ImportHandler loads a CSV export from this app or a cuff vendor's app
+// (POST /import). The file is the request body, or the "file" field of a
+// multipart form.
+// Query parameters:
+//   - format: a name from GET /import/formats, or "auto" (default) to detect
+//     it from the header row
+//   - tz: timezone of the file's dates and times (default: TIMEZONE, which
+//     the export also uses)
+//   - dry_run: "true" to preview what would be imported without saving
+func (h *Handler) ImportHandler(w http.ResponseWriter, r *http.Request) {
+	log.Println("Received request for /import")
+	format := r.URL.Query().Get("format")
+	if format == "" {
+		format = importer.AutoDetect
+	}
+	h.importCSV(w, r, format)
+}
+
+// ImportFormatsHandler lists the formats ImportHandler accepts (GET /import/formats)
+func (h *Handler) ImportFormatsHandler(w http.ResponseWriter, r *http.Request) {
+	type formatInfo struct {
+		Name  string `json:"name"`
+		Label string `json:"label"`
+	}
+	var list []formatInfo
+	for _, f := range importer.Formats() {
+		list = append(list, formatInfo{Name: f.Name(), Label: f.Label()})
+	}
+	respondWithJSON(w, list)
+}
+
+// ImportAppleHealthHandler loads blood pressure from an Apple Health
+// export.zip, or the export.xml inside it (POST /import/apple-health).
+// The file is the request body, or the "file" field of a multipart form.
+// Query parameters:
+//   - dry_run: "true" to preview what would be imported without saving
+func (h *Handler) ImportAppleHealthHandler(w http.ResponseWriter, r *http.Request) {
+	log.Println("Received request for /import/apple-health")
+
+	file, size, err := spoolUpload(w, r, maxAppleHealthSize)
+	if err != nil {
+		respondWithError(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	defer file.Close()
+
+	records, err := importer.ParseAppleHealth(file, size)
+	if err != nil {
+		respondWithError(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	h.runImport(w, "apple-health", records, r.URL.Query().Get("dry_run") == "true")
+}
+
+// ImportFHIRHandler loads blood pressure Observations from a FHIR Bundle or
+// an NDJSON bulk export (POST /import/fhir). Readings are tagged with their
+// source and take their pulse from a separate heart-rate Observation when
+// the panel has none; other resources are reported as unsupported. The
+// file is the request body, or the "file" field of a multipart form.
+// Query parameters:
+//   - tz: timezone for effectiveDateTime values without one (default:
+//     TIMEZONE)
+//   - dry_run: "true" to preview what would be imported without saving
+func (h *Handler) ImportFHIRHandler(w http.ResponseWriter, r *http.Request) {
+	log.Println("Received request for /import/fhir")
+
+	loc, err := h.locationParam(r.URL.Query().Get("tz"))
+	if err != nil {
+		respondWithError(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	body, err := importBody(w, r)
+	if err != nil {
+		respondWithError(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	defer body.Close()
+
+	records, err := importer.ParseFHIR(body, loc)
+	if err != nil {
+		respondWithError(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	h.runImport(w, "fhir", records, r.URL.Query().Get("dry_run") == "true")
+}
+
+// importCSV parses the uploaded file in the named format and imports it
+func (h *Handler) importCSV(w http.ResponseWriter, r *http.Request, formatName string) {
+	loc, err := h.locationParam(r.URL.Query().Get("tz"))
+	if err != nil {
+		respondWithError(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	body, err := importBody(w, r)
+	if err != nil {
+		respondWithError(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	defer body.Close()
+
+	format, records, err := importer.ParseCSV(body, formatName, loc)
+	if err != nil {
+		respondWithError(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	h.runImport(w, format.Name(), records, r.URL.Query().Get("dry_run") == "true")
+}
+
+// runImport imports parsed records and responds with the per-row report
+func (h *Handler) runImport(w http.ResponseWriter, format string, records []importer.Record, dryRun bool) {
+	report, err := importer.Import(h.db, records, h.classifier, dryRun)
+	if err != nil {
+		log.Printf("ERROR import: %v", err)
+		respondWithError(w, "Error importing readings", http.StatusInternalServerError)
+		return
+	}
+
+	message := fmt.Sprintf("Imported %d readings (%d skipped, %d duplicates)", report.Accepted, report.Skipped, report.Duplicates)
+	if dryRun {
+		message = fmt.Sprintf("Dry run: %d readings would be imported (%d skipped, %d duplicates)", report.Accepted, report.Skipped, report.Duplicates)
+	}
+	if report.Unsupported > 0 {
+		message += fmt.Sprintf("; %d unsupported resources", report.Unsupported)
+	}
+	log.Println(message)
+	respondWithJSON(w, map[string]interface{}{"message": message, "format": format, "dry_run": dryRun, "report": report})
+}
+
+// importBody returns the uploaded file: the "file" part of a multipart form,
+// or otherwise the raw request body
+func importBody(w http.ResponseWriter, r *http.Request) (io.ReadCloser, error) {
+	extendDeadline(w, importTimeout)
+	r.Body = http.MaxBytesReader(w, r.Body, maxImportSize)
+
+	mediaType, _, _ := mime.ParseMediaType(r.Header.Get("Content-Type"))
+	if mediaType != "multipart/form-data" {
+		return r.Body, nil
+	}
+
+	if err := r.ParseMultipartForm(maxImportSize); err != nil {
+		return nil, fmt.Errorf("invalid multipart upload: %v", err)
+	}
+	file, _, err := r.FormFile("file")
+	if err != nil {
+		return nil, fmt.Errorf("missing \"file\" field in upload")
+	}
+	return file, nil
+}
+
+// upload is an uploaded file that can be read at any offset, as zip
+// archives require
+type upload interface {
+	io.ReaderAt
+	io.Closer
+}
+
+// spoolUpload returns the uploaded file and its size without reading it into
+// memory. Multipart uploads larger than maxImportSize are already written to
+// a temporary file by ParseMultipartForm; a raw body is copied to one.
+func spoolUpload(w http.ResponseWriter, r *http.Request, limit int64) (upload, int64, error) {
+	extendDeadline(w, importTimeout)
+	r.Body = http.MaxBytesReader(w, r.Body, limit)
+
+	mediaType, _, _ := mime.ParseMediaType(r.Header.Get("Content-Type"))
+	if mediaType == "multipart/form-data" {
+		if err := r.ParseMultipartForm(maxImportSize); err != nil {
+			return nil, 0, fmt.Errorf("invalid multipart upload: %v", err)
+		}
+		file, header, err := r.FormFile("file")
+		if err != nil {
+			r.MultipartForm.RemoveAll()
+			return nil, 0, fmt.Errorf("missing \"file\" field in upload")
+		}
+		return multipartUpload{file, r.MultipartForm}, header.Size, nil
+	}
+
+	tmp, err := os.CreateTemp("", "bp-import-*")
+	if err != nil {
+		return nil, 0, fmt.Errorf("error buffering upload: %v", err)
+	}
+	spooled := tempUpload{tmp}
+	size, err := io.Copy(tmp, r.Body)
+	if err != nil {
+		spooled.Close()
+		return nil, 0, fmt.Errorf("error reading upload: %v", err)
+	}
+	return spooled, size, nil
+}
+
+// multipartUpload removes the form's temporary files when closed
+type multipartUpload struct {
+	multipart.File
+	form *multipart.Form
+}
+
+func (u multipartUpload) Close() error {
+	u.File.Close()
+	return u.form.RemoveAll()
+}
+
+// tempUpload deletes its temporary file when closed
+type tempUpload struct {
+	*os.File
+}
+
+func (u tempUpload) Close() error {
+	u.File.Close()
+	return os.Remove(u.Name())
+}
@@ -0,0 +1,82 @@
+// File: internal/handlers/reclassify.go
+
+package handlers
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"net/http"
+	"strconv"
+	"time"
+
+	"bp-tracker/internal/database"
+	"bp-tracker/internal/reclassify"
+)
+
+// ReclassifyHandler recomputes stored classifications (POST /reclassify),
+// reporting readings whose stored category no longer matches their
+// guideline's thresholds and fixing them in batches, each recorded with the
+// guideline version used.
+// Query parameters:
+//   - guideline: reclassify every reading by this guideline (default: each
+//     reading's own; readings in an unknown format use CLASSIFIER)
+//   - batch_size: readings per transaction (default 100, at most 1000)
+//   - dry_run: "true" to report the drift without saving
+//   - drift_limit: drifted readings to list (default 100, at most 1000); all
+//     are counted and fixed
+//
+// WARNING: Like /migrate, this endpoint should be secured, ideally via IAM
+// authorization.
+func (h *Handler) ReclassifyHandler(w http.ResponseWriter, r *http.Request) {
+	log.Println("Received request for /reclassify")
+	query := r.URL.Query()
+
+	opts := reclassify.Options{Default: h.classifier}
+	if id := query.Get("guideline"); id != "" {
+		var err error
+		if opts.Guideline, err = h.classifierFor(id); err != nil {
+			respondWithError(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+	}
+	if v := query.Get("batch_size"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n < 1 || n > database.MaxPageSize {
+			respondWithError(w, fmt.Sprintf("batch_size must be between 1 and %d", database.MaxPageSize), http.StatusBadRequest)
+			return
+		}
+		opts.BatchSize = n
+	}
+	if v := query.Get("drift_limit"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n < 1 || n > database.MaxPageSize {
+			respondWithError(w, fmt.Sprintf("drift_limit must be between 1 and %d", database.MaxPageSize), http.StatusBadRequest)
+			return
+		}
+		opts.DriftLimit = n
+	}
+	if v := query.Get("dry_run"); v != "" {
+		dryRun, err := strconv.ParseBool(v)
+		if err != nil {
+			respondWithError(w, "dry_run must be true or false", http.StatusBadRequest)
+			return
+		}
+		opts.DryRun = dryRun
+	}
+
+	ctx, cancel := context.WithTimeout(r.Context(), 30*time.Second)
+	defer cancel()
+
+	result, err := reclassify.Run(ctx, h.db, opts)
+	if err != nil {
+		// Batches saved before the error are kept
+		log.Printf("ERROR ReclassifyHandler - after %d readings updated: %v", result.Updated, err)
+		respondWithError(w, fmt.Sprintf("Reclassification failed after updating %d readings", result.Updated), http.StatusInternalServerError)
+		return
+	}
+
+	log.Printf("Reclassification via /reclassify finished: dry_run=%t checked=%d drifted=%d stale=%d updated=%d conflicts=%d",
+		result.DryRun, result.Checked, result.Drifted, result.Stale, result.Updated, result.Conflicts)
+	respondWithJSON(w, map[string]interface{}{"report": result})
+}
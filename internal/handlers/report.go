@@ -0,0 +1,121 @@
+// File: internal/handlers/report.go
+
+package handlers
+
+import (
+	"bytes"
+	"fmt"
+	"html/template"
+	"log"
+	"net/http"
+	"net/url"
+	"time"
+
+	"bp-tracker/internal/report"
+)
+
+// Default report and chart range, and the report's chart size
+const (
+	defaultReportDays = 30
+	reportChartWidth  = 900
+	reportChartHeight = 300
+)
+
+// ReportHandler renders a printable clinician report for a date range
+// (GET /report): averages, classification breakdown, morning/evening
+// averages, a trend chart and every reading.
+// Query parameters:
+//   - from, to: date range, as for /api/readings (default: the last 30 days)
+//   - tz: timezone for the range and the times shown (default TIMEZONE)
+//   - format: "html" (default, print-ready) or "pdf"
+func (h *Handler) ReportHandler(w http.ResponseWriter, r *http.Request) {
+	log.Println("Received request for /report")
+	params := r.URL.Query()
+
+	loc, err := h.locationParam(params.Get("tz"))
+	if err != nil {
+		respondWithError(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	from, to, err := recentRange(params, loc)
+	if err != nil {
+		respondWithError(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	format := params.Get("format")
+	if format != "" && format != "html" && format != "pdf" {
+		respondWithError(w, "Invalid format (expected html or pdf)", http.StatusBadRequest)
+		return
+	}
+
+	rpt, err := report.Build(h.db, from, to, loc, h.classifier)
+	if err != nil {
+		log.Printf("ERROR ReportHandler: %v", err)
+		respondWithError(w, "Error building report", http.StatusInternalServerError)
+		return
+	}
+
+	// Render into a buffer so a failure can still be reported as an error
+	var buf bytes.Buffer
+	if format == "pdf" {
+		if err := rpt.WritePDF(&buf); err != nil {
+			log.Printf("ERROR ReportHandler - rendering PDF: %v", err)
+			respondWithError(w, "Error rendering report", http.StatusInternalServerError)
+			return
+		}
+		w.Header().Set("Content-Type", "application/pdf")
+		w.Header().Set("Content-Disposition", "attachment; filename=blood_pressure_report_"+from.In(loc).Format("2006-01-02")+"_"+rpt.LastDay().Format("2006-01-02")+".pdf")
+		w.Write(buf.Bytes())
+		return
+	}
+
+	pdfParams := url.Values{}
+	for _, name := range []string{"from", "to", "tz"} {
+		if v := params.Get(name); v != "" {
+			pdfParams.Set(name, v)
+		}
+	}
+	pdfParams.Set("format", "pdf")
+
+	data := struct {
+		*report.Report
+		ChartSVG template.HTML // Generated by the chart package, which escapes all text
+		PDFLink  string
+	}{
+		Report:   rpt,
+		ChartSVG: template.HTML(rpt.Chart(reportChartWidth, reportChartHeight).SVG()),
+		PDFLink:  "/report?" + pdfParams.Encode(),
+	}
+	if err := h.templates.ExecuteTemplate(&buf, "report.html", data); err != nil {
+		log.Printf("ERROR ReportHandler - rendering template: %v", err)
+		http.Error(w, "Error rendering template", http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	w.Write(buf.Bytes())
+}
+
+// recentRange reads the from and to parameters of the report and charts,
+// defaulting to the 30 days through today in loc
+func recentRange(params url.Values, loc *time.Location) (time.Time, time.Time, error) {
+	from, err := parseTimeParam(params.Get("from"), loc, false)
+	if err != nil {
+		return from, from, fmt.Errorf("Invalid from: %v", err)
+	}
+	to, err := parseTimeParam(params.Get("to"), loc, true)
+	if err != nil {
+		return from, to, fmt.Errorf("Invalid to: %v", err)
+	}
+	if to.IsZero() {
+		now := time.Now().In(loc)
+		to = time.Date(now.Year(), now.Month(), now.Day()+1, 0, 0, 0, 0, loc) // Through today
+	}
+	if from.IsZero() {
+		from = to.AddDate(0, 0, -defaultReportDays)
+	}
+	if !from.Before(to) {
+		return from, to, fmt.Errorf("from must be before to")
+	}
+	return from, to, nil
+}
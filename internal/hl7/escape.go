@@ -0,0 +1,75 @@
+// File: internal/hl7/escape.go
+
+package hl7
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Delimiters of the ER7 (pipe) encoding, as declared in MSH-1 and MSH-2
+const (
+	FieldSeparator        = '|'
+	ComponentSeparator    = '^'
+	RepetitionSeparator   = '~'
+	EscapeCharacter       = '\\'
+	SubcomponentSeparator = '&'
+
+	// EncodingCharacters is MSH-2: component, repetition, escape, subcomponent
+	EncodingCharacters = `^~\&`
+
+	// SegmentTerminator ends every segment, including the last
+	SegmentTerminator = "\r"
+)
+
+// Escape makes s safe to use as one component: delimiters become the
+// standard \F\ \S\ \R\ \E\ \T\ sequences and control characters such as
+// line breaks become hexadecimal \Xhh\ sequences
+func Escape(s string) string {
+	if !strings.ContainsFunc(s, needsEscape) {
+		return s
+	}
+
+	var b strings.Builder
+	for _, c := range s {
+		switch c {
+		case FieldSeparator:
+			b.WriteString(`\F\`)
+		case ComponentSeparator:
+			b.WriteString(`\S\`)
+		case RepetitionSeparator:
+			b.WriteString(`\R\`)
+		case EscapeCharacter:
+			b.WriteString(`\E\`)
+		case SubcomponentSeparator:
+			b.WriteString(`\T\`)
+		default:
+			if c < 0x20 || c == 0x7f {
+				fmt.Fprintf(&b, `\X%02X\`, c)
+			} else {
+				b.WriteRune(c)
+			}
+		}
+	}
+	return b.String()
+}
+
+// needsEscape reports whether c must be written as an escape sequence
+func needsEscape(c rune) bool {
+	switch c {
+	case FieldSeparator, ComponentSeparator, RepetitionSeparator, EscapeCharacter, SubcomponentSeparator:
+		return true
+	}
+	return c < 0x20 || c == 0x7f
+}
+
+// components escapes each value and joins them into one field, e.g.
+// components("8480-6", "Systolic blood pressure", "LN")
+func components(values ...string) string {
+	escaped := make([]string, len(values))
+	for i, v := range values {
+		escaped[i] = Escape(v)
+	}
+	// Trailing empty components are left out
+	return strings.TrimRight(strings.Join(escaped, string(ComponentSeparator)), string(ComponentSeparator))
+}
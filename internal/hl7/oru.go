@@ -0,0 +1,251 @@
+// File: internal/hl7/oru.go
+
+package hl7
+
+import (
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"bp-tracker/internal/models"
+)
+
+// Version is the HL7 version written in MSH-12
+const Version = "2.5.1"
+
+// DefaultSendingApplication is MSH-3 when Options leaves it empty
+const DefaultSendingApplication = "BP-TRACKER"
+
+// timestampLayout is the DTM format, to the second with the UTC offset
+const timestampLayout = "20060102150405-0700"
+
+// Observation identifiers (OBR-4 and OBX-3), coded in LOINC
+var (
+	panelID     = []string{"85354-9", "Blood pressure panel with all children optional", "LN"}
+	systolicID  = []string{"8480-6", "Systolic blood pressure", "LN"}
+	diastolicID = []string{"8462-4", "Diastolic blood pressure", "LN"}
+	heartRateID = []string{"8867-4", "Heart rate", "LN"}
+
+	mmHgUnits      = []string{"mm[Hg]", "mm[Hg]", "UCUM"}
+	perMinuteUnits = []string{"/min", "/min", "UCUM"}
+)
+
+// Options identifies the systems and patient that messages are exchanged
+// between. Empty fields are left empty in the message unless noted.
+type Options struct {
+	SendingApplication   string // MSH-3, default DefaultSendingApplication
+	SendingFacility      string // MSH-4
+	ReceivingApplication string // MSH-5
+	ReceivingFacility    string // MSH-6
+	ProcessingID         string // MSH-11: P (production, default), T (training) or D (debugging)
+
+	PatientID         string // PID-3, default "self"
+	PatientFamilyName string // PID-5
+	PatientGivenName  string // PID-5
+
+	Location *time.Location   // Zone timestamps are written in, default time.Local
+	Now      func() time.Time // Message time (MSH-7), default time.Now
+}
+
+// OptionsFromEnv reads Options from HL7_SENDING_FACILITY,
+// HL7_RECEIVING_APPLICATION, HL7_RECEIVING_FACILITY, HL7_PROCESSING_ID,
+// HL7_PATIENT_FAMILY_NAME and HL7_PATIENT_GIVEN_NAME. The patient ID is
+// FHIR_PATIENT_ID, so both feeds name the same patient.
+func OptionsFromEnv() Options {
+	return Options{
+		SendingFacility:      os.Getenv("HL7_SENDING_FACILITY"),
+		ReceivingApplication: os.Getenv("HL7_RECEIVING_APPLICATION"),
+		ReceivingFacility:    os.Getenv("HL7_RECEIVING_FACILITY"),
+		ProcessingID:         os.Getenv("HL7_PROCESSING_ID"),
+		PatientID:            os.Getenv("FHIR_PATIENT_ID"),
+		PatientFamilyName:    os.Getenv("HL7_PATIENT_FAMILY_NAME"),
+		PatientGivenName:     os.Getenv("HL7_PATIENT_GIVEN_NAME"),
+	}
+}
+
+// withDefaults fills in the defaulted fields
+func (o Options) withDefaults() Options {
+	if o.SendingApplication == "" {
+		o.SendingApplication = DefaultSendingApplication
+	}
+	if o.ProcessingID == "" {
+		o.ProcessingID = "P"
+	}
+	if o.PatientID == "" {
+		o.PatientID = "self"
+	}
+	if o.Location == nil {
+		o.Location = time.Local
+	}
+	if o.Now == nil {
+		o.Now = time.Now
+	}
+	return o
+}
+
+// ORU renders one reading as an ORU^R01 unsolicited observation message:
+//
+//	MSH  message header; the control ID is "<reading id>-<version>"
+//	PID  the patient
+//	OBR  the blood pressure panel (LOINC 85354-9) at the reading's time
+//	NTE  the classification, irregular heartbeat flag and source, if any
+//	OBX  systolic (8480-6) and diastolic (8462-4) in mm[Hg], pulse (8867-4) in /min
+//
+// Results are final (F), or corrected (C) once the reading has been edited.
+// Every segment ends with a carriage return.
+func ORU(r *models.Reading, opts Options) []byte {
+	opts = opts.withDefaults()
+	var b strings.Builder
+	writeORU(&b, r, opts, opts.Now())
+	return []byte(b.String())
+}
+
+// Batch renders readings as ORU^R01 messages wrapped in a BHS/BTS batch, the
+// usual form for sending many messages as one file
+func Batch(readings []*models.Reading, opts Options) []byte {
+	opts = opts.withDefaults()
+	now := opts.Now()
+
+	var b strings.Builder
+	bhs := newSegment("BHS")
+	bhs.set(3, Escape(opts.SendingApplication))
+	bhs.set(4, Escape(opts.SendingFacility))
+	bhs.set(5, Escape(opts.ReceivingApplication))
+	bhs.set(6, Escape(opts.ReceivingFacility))
+	bhs.set(7, timestamp(now, opts.Location))
+	bhs.set(11, now.UTC().Format("20060102150405")) // Batch control ID
+	bhs.writeTo(&b)
+
+	for _, r := range readings {
+		writeORU(&b, r, opts, now)
+	}
+
+	bts := newSegment("BTS")
+	bts.set(1, strconv.Itoa(len(readings)))
+	bts.writeTo(&b)
+	return []byte(b.String())
+}
+
+// writeORU appends the segments of one message
+func writeORU(b *strings.Builder, r *models.Reading, opts Options, now time.Time) {
+	status := "F"
+	if r.Version > 1 {
+		status = "C"
+	}
+	observed := timestamp(r.Timestamp, opts.Location)
+
+	msh := newSegment("MSH")
+	msh.set(3, Escape(opts.SendingApplication))
+	msh.set(4, Escape(opts.SendingFacility))
+	msh.set(5, Escape(opts.ReceivingApplication))
+	msh.set(6, Escape(opts.ReceivingFacility))
+	msh.set(7, timestamp(now, opts.Location))
+	msh.set(9, components("ORU", "R01", "ORU_R01"))
+	msh.set(10, Escape(strconv.FormatInt(r.ID, 10)+"-"+strconv.Itoa(max(r.Version, 1))))
+	msh.set(11, Escape(opts.ProcessingID))
+	msh.set(12, Version)
+	msh.writeTo(b)
+
+	pid := newSegment("PID")
+	pid.set(1, "1")
+	pid.set(3, components(opts.PatientID, "", "", opts.SendingApplication))
+	pid.set(5, components(opts.PatientFamilyName, opts.PatientGivenName))
+	pid.writeTo(b)
+
+	obr := newSegment("OBR")
+	obr.set(1, "1")
+	obr.set(3, components(strconv.FormatInt(r.ID, 10), opts.SendingApplication))
+	obr.set(4, components(panelID...))
+	obr.set(7, observed)
+	obr.set(25, status)
+	obr.writeTo(b)
+
+	var notes []string
+	if r.Classification != "" {
+		notes = append(notes, "Classification: "+r.Classification)
+	}
+	if r.IrregularHeartbeat {
+		notes = append(notes, "Irregular heartbeat detected")
+	}
+	if r.Source != "" {
+		notes = append(notes, "Source: "+r.Source)
+	}
+	for i, note := range notes {
+		nte := newSegment("NTE")
+		nte.set(1, strconv.Itoa(i+1))
+		nte.set(2, "L") // Comment from the filler (this application)
+		nte.set(3, Escape(note))
+		nte.writeTo(b)
+	}
+
+	for i, obs := range []struct {
+		id    []string
+		value int
+		units []string
+	}{
+		{systolicID, r.Systolic, mmHgUnits},
+		{diastolicID, r.Diastolic, mmHgUnits},
+		{heartRateID, r.Pulse, perMinuteUnits},
+	} {
+		obx := newSegment("OBX")
+		obx.set(1, strconv.Itoa(i+1))
+		obx.set(2, "NM")
+		obx.set(3, components(obs.id...))
+		obx.set(5, strconv.Itoa(obs.value))
+		obx.set(6, components(obs.units...))
+		obx.set(11, status)
+		obx.set(14, observed)
+		obx.writeTo(b)
+	}
+}
+
+// timestamp formats t as an HL7 DTM in loc
+func timestamp(t time.Time, loc *time.Location) string {
+	return t.In(loc).Format(timestampLayout)
+}
+
+// segment holds the fields of one segment, already escaped; fields[0] is
+// the segment ID
+type segment struct {
+	fields []string
+}
+
+func newSegment(id string) *segment {
+	return &segment{fields: []string{id}}
+}
+
+// set sets field n (1-based, as numbered in the standard)
+func (s *segment) set(n int, value string) {
+	// In header segments field 1 is the field separator itself, so field 2
+	// (the encoding characters) is the first one written after it
+	if s.isHeader() {
+		n--
+	}
+	for len(s.fields) <= n {
+		s.fields = append(s.fields, "")
+	}
+	s.fields[n] = value
+}
+
+// isHeader reports whether the segment declares the delimiters
+func (s *segment) isHeader() bool {
+	switch s.fields[0] {
+	case "MSH", "BHS", "FHS":
+		return true
+	}
+	return false
+}
+
+// writeTo appends the segment and its terminator
+func (s *segment) writeTo(b *strings.Builder) {
+	if s.isHeader() {
+		s.set(2, EncodingCharacters)
+	}
+	fields := s.fields
+	for len(fields) > 1 && fields[len(fields)-1] == "" {
+		fields = fields[:len(fields)-1] // Trailing empty fields are left out
+	}
+	b.WriteString(strings.Join(fields, string(FieldSeparator)))
+	b.WriteString(SegmentTerminator)
+}
@@ -0,0 +1,139 @@
+// File: internal/hl7/oru_test.go
+
+package hl7
+
+import (
+	"bytes"
+	"flag"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"bp-tracker/internal/models"
+	"bp-tracker/internal/utils"
+)
+
+// Run with -update to rewrite the golden files after an intended change,
+// then check the diff of testdata/ by hand:
+//
+//	go test ./internal/hl7 -update
+var update = flag.Bool("update", false, "rewrite testdata/*.hl7 golden files")
+
+var denver = mustLoadLocation("America/Denver")
+
+func mustLoadLocation(name string) *time.Location {
+	loc, err := time.LoadLocation(name)
+	if err != nil {
+		panic(err)
+	}
+	return loc
+}
+
+// testOptions fixes the message time so output is reproducible
+func testOptions() Options {
+	return Options{
+		SendingFacility:      "HOME",
+		ReceivingApplication: "EHR",
+		ReceivingFacility:    "FAMILY-PRACTICE",
+		PatientID:            "self",
+		PatientFamilyName:    "Doe",
+		PatientGivenName:     "Jane",
+		Location:             denver,
+		Now:                  func() time.Time { return time.Date(2025, 2, 1, 16, 0, 0, 0, time.UTC) },
+	}
+}
+
+func morningReading() *models.Reading {
+	return &models.Reading{
+		ID:             42,
+		Timestamp:      time.Date(2025, 1, 15, 14, 30, 0, 0, time.UTC),
+		Systolic:       128,
+		Diastolic:      82,
+		Pulse:          70,
+		Classification: utils.ClassifyBP(128, 82).Classification(),
+		Version:        1,
+	}
+}
+
+func TestORUGolden(t *testing.T) {
+	escaped := testOptions()
+	escaped.SendingFacility = "Smith & Sons|Home"
+	escaped.PatientFamilyName = `O'Brien^Smith`
+	escaped.PatientGivenName = `Mary~Ann \ Jo`
+
+	// Edited, imported reading: results are corrected and notes carry
+	// delimiters that must be escaped
+	corrected := morningReading()
+	corrected.ID = 7
+	corrected.Version = 3
+	corrected.Timestamp = time.Date(2025, 7, 4, 2, 5, 9, 0, time.UTC) // Summer time in Denver
+	corrected.Systolic, corrected.Diastolic, corrected.Pulse = 152, 96, 88
+	corrected.Classification = utils.ClassifyBP(152, 96).Classification()
+	corrected.IrregularHeartbeat = true
+	corrected.Source = "fhir:https://clinic.example/fhir?site=a&unit=b"
+
+	tests := []struct {
+		name string
+		got  []byte
+	}{
+		{"oru_reading", ORU(morningReading(), testOptions())},
+		{"oru_corrected_escaped", ORU(corrected, escaped)},
+		{"batch", Batch([]*models.Reading{morningReading(), corrected}, testOptions())},
+		{"batch_empty", Batch(nil, testOptions())},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := filepath.Join("testdata", tt.name+".hl7")
+			if *update {
+				if err := os.WriteFile(path, tt.got, 0o644); err != nil {
+					t.Fatal(err)
+				}
+			}
+
+			want, err := os.ReadFile(path)
+			if err != nil {
+				t.Fatalf("reading golden file (run with -update to create it): %v", err)
+			}
+			if !bytes.Equal(tt.got, want) {
+				t.Errorf("message does not match %s\n got:\n%s\nwant:\n%s", path, readable(tt.got), readable(want))
+			}
+		})
+	}
+}
+
+// readable puts each segment on its own line for failure output
+func readable(msg []byte) string {
+	return strings.ReplaceAll(string(msg), SegmentTerminator, "\n")
+}
+
+func TestEscape(t *testing.T) {
+	tests := []struct {
+		in, want string
+	}{
+		{"Elevated", "Elevated"},
+		{"a|b", `a\F\b`},
+		{"a^b", `a\S\b`},
+		{"a~b", `a\R\b`},
+		{`a\b`, `a\E\b`},
+		{"a&b", `a\T\b`},
+		{`\F\`, `\E\F\E\`}, // Escape sequences in the input are literal text
+		{"line 1\r\nline 2", `line 1\X0D\\X0A\line 2`},
+		{"café", "café"},
+	}
+	for _, tt := range tests {
+		if got := Escape(tt.in); got != tt.want {
+			t.Errorf("Escape(%q) = %q, want %q", tt.in, got, tt.want)
+		}
+	}
+}
+
+func TestORUDoesNotChangeCodes(t *testing.T) {
+	// components must not escape into the shared code tables
+	ORU(morningReading(), testOptions())
+	if got := ORU(morningReading(), testOptions()); !bytes.Contains(got, []byte("|8480-6^Systolic blood pressure^LN|")) {
+		t.Errorf("systolic OBX-3 changed after a second call:\n%s", readable(got))
+	}
+}
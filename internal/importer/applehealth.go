@@ -0,0 +1,228 @@
+// File: internal/importer/applehealth.go
+
+package importer
+
+import (
+	"archive/zip"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"path"
+	"sort"
+	"time"
+
+	"bp-tracker/internal/models"
+)
+
+// Apple Health identifiers used in export.xml
+const (
+	appleBloodPressure = "HKCorrelationTypeIdentifierBloodPressure"
+	appleSystolic      = "HKQuantityTypeIdentifierBloodPressureSystolic"
+	appleDiastolic     = "HKQuantityTypeIdentifierBloodPressureDiastolic"
+	appleHeartRate     = "HKQuantityTypeIdentifierHeartRate"
+
+	appleDateLayout = "2006-01-02 15:04:05 -0700"
+)
+
+// ApplePulseWindow is how far a heart-rate sample may be from a blood
+// pressure reading to be used as its pulse
+const ApplePulseWindow = 15 * time.Minute
+
+// ParseAppleHealth reads blood pressure from an Apple Health export: the
+// export.zip produced by the Health app, or the export.xml inside it. Each
+// blood pressure correlation becomes one record, numbered in file order,
+// with the nearest heart-rate sample within ApplePulseWindow as its pulse.
+//
+// The XML is streamed twice rather than loaded: the first pass keeps only
+// the blood pressure readings and the second matches heart-rate samples to
+// them, so memory grows with the number of readings, not the export size.
+func ParseAppleHealth(r io.ReaderAt, size int64) ([]Record, error) {
+	open, err := appleExportXML(r, size)
+	if err != nil {
+		return nil, err
+	}
+
+	records, err := scanAppleBloodPressure(open)
+	if err != nil {
+		return nil, err
+	}
+	if err := matchApplePulse(open, records); err != nil {
+		return nil, err
+	}
+
+	for i := range records {
+		if records[i].Err == nil && records[i].Reading.Pulse == 0 {
+			records[i].Err = fmt.Errorf("no heart-rate sample within %v of the reading", ApplePulseWindow)
+			records[i].Reading = nil
+		}
+	}
+	sort.Slice(records, func(i, j int) bool { return records[i].Row < records[j].Row })
+	return records, nil
+}
+
+// appleExportXML returns a function that opens export.xml from the start,
+// either from inside a zip archive or, when r is not a zip, r itself
+func appleExportXML(r io.ReaderAt, size int64) (func() (io.ReadCloser, error), error) {
+	archive, err := zip.NewReader(r, size)
+	if errors.Is(err, zip.ErrFormat) {
+		return func() (io.ReadCloser, error) {
+			return io.NopCloser(io.NewSectionReader(r, 0, size)), nil
+		}, nil
+	} else if err != nil {
+		return nil, fmt.Errorf("error reading Apple Health archive: %w", err)
+	}
+
+	for _, f := range archive.File {
+		if path.Base(f.Name) == "export.xml" {
+			return f.Open, nil
+		}
+	}
+	return nil, fmt.Errorf("archive does not contain export.xml; upload the export.zip from the Health app")
+}
+
+// scanAppleBloodPressure collects one record per blood pressure correlation,
+// sorted by time for matchApplePulse
+func scanAppleBloodPressure(open func() (io.ReadCloser, error)) ([]Record, error) {
+	f, err := open()
+	if err != nil {
+		return nil, fmt.Errorf("error opening export.xml: %w", err)
+	}
+	defer f.Close()
+
+	var records []Record
+	var current *Record // Correlation being read
+	err = walkAppleXML(f, func(start *xml.StartElement, end *xml.EndElement) {
+		switch {
+		case start != nil && start.Name.Local == "Correlation" && attr(start, "type") == appleBloodPressure:
+			current = &Record{Row: len(records) + 1, Reading: &models.Reading{}}
+			ts, err := time.Parse(appleDateLayout, attr(start, "startDate"))
+			if err != nil {
+				current.Err = fmt.Errorf("invalid startDate %q", attr(start, "startDate"))
+			}
+			current.Reading.Timestamp = ts
+
+		case start != nil && start.Name.Local == "Record" && current != nil && current.Err == nil:
+			var dest *int
+			switch attr(start, "type") {
+			case appleSystolic:
+				dest = &current.Reading.Systolic
+			case appleDiastolic:
+				dest = &current.Reading.Diastolic
+			default:
+				return
+			}
+			value, err := parseWhole(attr(start, "value"))
+			if err != nil {
+				current.Err = fmt.Errorf("invalid %s value %q", attr(start, "type"), attr(start, "value"))
+			}
+			*dest = value
+
+		case end != nil && end.Name.Local == "Correlation" && current != nil:
+			if current.Err == nil && (current.Reading.Systolic == 0 || current.Reading.Diastolic == 0) {
+				current.Err = fmt.Errorf("blood pressure correlation at %s is missing its systolic or diastolic record", current.Reading.Timestamp.Format(appleDateLayout))
+			}
+			if current.Err != nil {
+				current.Reading = nil
+			}
+			records = append(records, *current)
+			current = nil
+		}
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	sort.SliceStable(records, func(i, j int) bool {
+		return appleTime(records[i]).Before(appleTime(records[j]))
+	})
+	return records, nil
+}
+
+// matchApplePulse sets each reading's pulse to the nearest heart-rate sample
+// within ApplePulseWindow. records must be sorted by appleTime.
+func matchApplePulse(open func() (io.ReadCloser, error), records []Record) error {
+	if len(records) == 0 {
+		return nil
+	}
+
+	f, err := open()
+	if err != nil {
+		return fmt.Errorf("error opening export.xml: %w", err)
+	}
+	defer f.Close()
+
+	best := make([]time.Duration, len(records)) // Distance to the pulse chosen so far
+	return walkAppleXML(f, func(start *xml.StartElement, end *xml.EndElement) {
+		if start == nil || start.Name.Local != "Record" || attr(start, "type") != appleHeartRate {
+			return
+		}
+		ts, err := time.Parse(appleDateLayout, attr(start, "startDate"))
+		if err != nil {
+			return
+		}
+		pulse, err := parseWhole(attr(start, "value"))
+		if err != nil {
+			return
+		}
+
+		// Check every reading close enough to use this sample
+		from := ts.Add(-ApplePulseWindow)
+		i := sort.Search(len(records), func(i int) bool { return !appleTime(records[i]).Before(from) })
+		for ; i < len(records) && !appleTime(records[i]).After(ts.Add(ApplePulseWindow)); i++ {
+			d := ts.Sub(records[i].Reading.Timestamp)
+			if d < 0 {
+				d = -d
+			}
+			if records[i].Reading.Pulse == 0 || d < best[i] {
+				records[i].Reading.Pulse, best[i] = pulse, d
+			}
+		}
+	})
+}
+
+// walkAppleXML streams the elements of r to fn, with exactly one of start
+// and end set for each call. The root element must be HealthData.
+func walkAppleXML(r io.Reader, fn func(start *xml.StartElement, end *xml.EndElement)) error {
+	d := xml.NewDecoder(r)
+	root := true
+	for {
+		tok, err := d.Token()
+		if err == io.EOF {
+			if root {
+				return fmt.Errorf("not an Apple Health export (no HealthData element)")
+			}
+			return nil
+		} else if err != nil {
+			return fmt.Errorf("error reading export.xml: %w", err)
+		}
+		switch t := tok.(type) {
+		case xml.StartElement:
+			if root && t.Name.Local != "HealthData" {
+				return fmt.Errorf("not an Apple Health export (root element is %s, not HealthData)", t.Name.Local)
+			}
+			root = false
+			fn(&t, nil)
+		case xml.EndElement:
+			fn(nil, &t)
+		}
+	}
+}
+
+// attr returns the value of the named attribute, or "" if it is absent
+func attr(e *xml.StartElement, name string) string {
+	for _, a := range e.Attr {
+		if a.Name.Local == name {
+			return a.Value
+		}
+	}
+	return ""
+}
+
+// appleTime orders records for pulse matching; unparsed ones sort first
+func appleTime(r Record) time.Time {
+	if r.Reading == nil {
+		return time.Time{}
+	}
+	return r.Reading.Timestamp
+}
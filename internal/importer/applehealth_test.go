@@ -0,0 +1,106 @@
+// File: internal/importer/applehealth_test.go
+
+package importer_test
+
+import (
+	"archive/zip"
+	"bytes"
+	"os"
+	"strings"
+	"testing"
+
+	"bp-tracker/internal/importer"
+)
+
+// zipFiles returns a zip archive holding the given files, by name
+func zipFiles(t *testing.T, files map[string][]byte) []byte {
+	t.Helper()
+	var buf bytes.Buffer
+	w := zip.NewWriter(&buf)
+	for name, data := range files {
+		f, err := w.Create(name)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if _, err := f.Write(data); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := w.Close(); err != nil {
+		t.Fatal(err)
+	}
+	return buf.Bytes()
+}
+
+func TestParseAppleHealth(t *testing.T) {
+	export, err := os.ReadFile("testdata/apple_export.xml")
+	if err != nil {
+		t.Fatal(err)
+	}
+	inputs := map[string][]byte{
+		"xml": export,
+		"zip": zipFiles(t, map[string][]byte{
+			"apple_health_export/export_cda.xml": []byte("<ClinicalDocument/>"),
+			"apple_health_export/export.xml":     export,
+		}),
+	}
+
+	tests := []struct {
+		row       int
+		timestamp string // Local time in Denver
+		values    [3]int
+		err       string
+	}{
+		// 71 bpm three minutes after is nearer than 68 five minutes before
+		{row: 1, timestamp: "2025-01-15 07:30:00", values: [3]int{128, 82, 71}},
+		{row: 2, err: "no heart-rate sample within 15m0s of the reading"},
+		{row: 3, err: "blood pressure correlation at 2025-01-16 07:00:00 -0700 is missing its systolic or diastolic record"},
+		// In UTC; the heart rate ten minutes later is in -0700
+		{row: 4, timestamp: "2025-01-16 01:00:00", values: [3]int{130, 85, 66}},
+		{row: 5, err: `invalid startDate "yesterday"`},
+		// The sample exactly ApplePulseWindow away is used, the one a
+		// second further is not
+		{row: 6, timestamp: "2025-01-17 07:00:00", values: [3]int{129, 81, 77}},
+	}
+
+	for name, input := range inputs {
+		t.Run(name, func(t *testing.T) {
+			records, err := importer.ParseAppleHealth(bytes.NewReader(input), int64(len(input)))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(records) != len(tests) {
+				t.Fatalf("got %d records, want %d", len(records), len(tests))
+			}
+			for i, tt := range tests {
+				checkRecord(t, records[i], tt.row, tt.timestamp, tt.values, tt.err)
+			}
+		})
+	}
+}
+
+func TestParseAppleHealthErrors(t *testing.T) {
+	tests := []struct {
+		name  string
+		input []byte
+		err   string
+	}{
+		{"zip without export.xml", zipFiles(t, map[string][]byte{"export_cda.xml": []byte("<ClinicalDocument/>")}), "archive does not contain export.xml"},
+		{"other XML", []byte(`<?xml version="1.0"?><ClinicalDocument/>`), "root element is ClinicalDocument, not HealthData"},
+		{"empty", nil, "no HealthData element"},
+		{"CSV", []byte("Date,Time,Systolic,Diastolic,Pulse\n"), "no HealthData element"},
+	}
+	for _, tt := range tests {
+		_, err := importer.ParseAppleHealth(bytes.NewReader(tt.input), int64(len(tt.input)))
+		if err == nil || !strings.Contains(err.Error(), tt.err) {
+			t.Errorf("%s: error = %v, want %q", tt.name, err, tt.err)
+		}
+	}
+
+	// An export with no blood pressure is not an error
+	empty := []byte(`<HealthData locale="en_US"><ExportDate value="2025-01-20 09:00:00 -0700"/></HealthData>`)
+	records, err := importer.ParseAppleHealth(bytes.NewReader(empty), int64(len(empty)))
+	if err != nil || len(records) != 0 {
+		t.Errorf("export without blood pressure = %v, %v; want no records", records, err)
+	}
+}
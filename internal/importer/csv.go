@@ -0,0 +1,65 @@
+// File: internal/importer/csv.go
+
+package importer
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"bp-tracker/internal/models"
+)
+
+// ExportFormat is the name of the format written by the CSV export
+const ExportFormat = "bp-tracker"
+
+// ExportHeader is the header row written by the CSV export
+var ExportHeader = []string{"Date", "Time", "Systolic", "Diastolic", "Pulse", "Classification"}
+
+// exportFormat reads files produced by the CSV export. The Classification
+// column is ignored because it is recomputed on import.
+type exportFormat struct{}
+
+func (exportFormat) Name() string  { return ExportFormat }
+func (exportFormat) Label() string { return "BP Tracker CSV export" }
+
+func (exportFormat) Bind(header []string) (RowParser, bool) {
+	return parseExportRow, headerMatches(header, ExportHeader)
+}
+
+// parseExportRow converts one export row into a reading
+func parseExportRow(fields []string, loc *time.Location) (*models.Reading, error) {
+	if len(fields) < 5 {
+		return nil, fmt.Errorf("expected at least 5 columns, got %d", len(fields))
+	}
+
+	ts, err := time.ParseInLocation("2006-01-02 15:04:05", strings.TrimSpace(fields[0])+" "+strings.TrimSpace(fields[1]), loc)
+	if err != nil {
+		return nil, fmt.Errorf("invalid date/time %q %q", fields[0], fields[1])
+	}
+
+	var values [3]int
+	for i, name := range []string{"systolic", "diastolic", "pulse"} {
+		if values[i], err = strconv.Atoi(strings.TrimSpace(fields[2+i])); err != nil {
+			return nil, fmt.Errorf("invalid %s %q", name, fields[2+i])
+		}
+	}
+
+	return &models.Reading{Timestamp: ts, Systolic: values[0], Diastolic: values[1], Pulse: values[2]}, nil
+}
+
+// headerMatches compares a header row case-insensitively, ignoring a UTF-8
+// byte order mark added by spreadsheet programs
+func headerMatches(header, want []string) bool {
+	if len(header) < len(want) {
+		return false
+	}
+	for i, name := range want {
+		got := strings.TrimSpace(strings.TrimPrefix(header[i], "\ufeff"))
+		if !strings.EqualFold(got, name) {
+			return false
+		}
+	}
+	return true
+}
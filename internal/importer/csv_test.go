@@ -0,0 +1,164 @@
+// File: internal/importer/csv_test.go
+
+package importer_test
+
+import (
+	"bytes"
+	"os"
+	"strings"
+	"testing"
+	"time"
+
+	"bp-tracker/internal/export"
+	"bp-tracker/internal/importer"
+	"bp-tracker/internal/models"
+)
+
+var denver = mustLoadLocation("America/Denver")
+
+func mustLoadLocation(name string) *time.Location {
+	loc, err := time.LoadLocation(name)
+	if err != nil {
+		panic(err)
+	}
+	return loc
+}
+
+// TestExportRoundTrip writes readings with the CSV export's default columns
+// and reads them back, in a timezone other than UTC
+func TestExportRoundTrip(t *testing.T) {
+	readings := []*models.Reading{
+		{Timestamp: time.Date(2025, 1, 15, 14, 30, 0, 0, time.UTC), Systolic: 128, Diastolic: 82, Pulse: 70, Classification: "Elevated (AHA/ACC 2017)"},
+		{Timestamp: time.Date(2025, 3, 9, 9, 30, 15, 0, time.UTC), Systolic: 118, Diastolic: 76, Pulse: 64, Classification: "Normal (AHA/ACC 2017)"}, // 03:30 MDT, the day DST starts
+		{Timestamp: time.Date(2025, 11, 2, 7, 45, 0, 0, time.UTC), Systolic: 141, Diastolic: 79, Pulse: 80, Classification: "Hypertension Stage 2, isolated systolic (AHA/ACC 2017)"},
+	}
+
+	format, _ := export.Lookup("csv")
+	var buf bytes.Buffer
+	w := format.NewWriter(&buf, export.Options{Location: denver, Columns: export.DefaultColumns()})
+	for _, r := range readings {
+		if err := w.Write(r); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := w.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	detected, records, err := importer.ParseCSV(&buf, importer.AutoDetect, denver)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if detected.Name() != importer.ExportFormat {
+		t.Errorf("detected format %q, want %q", detected.Name(), importer.ExportFormat)
+	}
+	if len(records) != len(readings) {
+		t.Fatalf("got %d records, want %d", len(records), len(readings))
+	}
+	for i, rec := range records {
+		want := readings[i]
+		if rec.Err != nil {
+			t.Errorf("row %d: %v", rec.Row, rec.Err)
+			continue
+		}
+		got := rec.Reading
+		if rec.Row != i+2 || !got.Timestamp.Equal(want.Timestamp) || got.Systolic != want.Systolic || got.Diastolic != want.Diastolic || got.Pulse != want.Pulse {
+			t.Errorf("row %d = %v %d/%d %d, want row %d %v %d/%d %d", rec.Row, got.Timestamp, got.Systolic, got.Diastolic, got.Pulse,
+				i+2, want.Timestamp, want.Systolic, want.Diastolic, want.Pulse)
+		}
+	}
+}
+
+// TestExportDefaultColumns keeps the export's default header and the
+// importer's in step
+func TestExportDefaultColumns(t *testing.T) {
+	columns := export.DefaultColumns()
+	var header []string
+	for _, c := range columns {
+		header = append(header, c.Header)
+	}
+	if strings.Join(header, ",") != strings.Join(importer.ExportHeader, ",") {
+		t.Errorf("export default columns %q, importer expects %q", header, importer.ExportHeader)
+	}
+}
+
+func TestParseExportCSV(t *testing.T) {
+	f, err := os.Open("testdata/export.csv")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+
+	format, records, err := importer.ParseCSV(f, importer.ExportFormat, denver)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if format.Name() != importer.ExportFormat {
+		t.Errorf("format = %q", format.Name())
+	}
+
+	tests := []struct {
+		row       int
+		timestamp string // Local time in Denver
+		values    [3]int
+		err       string
+	}{
+		{row: 2, timestamp: "2025-01-15 07:30:00", values: [3]int{128, 82, 70}},
+		{row: 3, timestamp: "2025-01-15 19:45:10", values: [3]int{118, 76, 64}},
+		// Row 4 is blank and left out
+		{row: 5, err: `invalid date/time "2025-13-01" "07:30:00"`},
+		{row: 6, err: `invalid systolic "abc"`},
+		{row: 7, err: "expected at least 5 columns, got 4"},
+		{row: 8, timestamp: "2025-07-04 06:15:00", values: [3]int{121, 79, 66}},
+	}
+	if len(records) != len(tests) {
+		t.Fatalf("got %d records, want %d: %+v", len(records), len(tests), records)
+	}
+	for i, tt := range tests {
+		checkRecord(t, records[i], tt.row, tt.timestamp, tt.values, tt.err)
+	}
+}
+
+func TestParseCSVHeaderErrors(t *testing.T) {
+	tests := []struct {
+		name, input, format, err string
+	}{
+		{"empty file", "", importer.AutoDetect, "file is empty"},
+		{"unknown header", "When,High,Low\n", importer.AutoDetect, `unrecognized CSV header "When,High,Low"`},
+		{"unknown format", "Date,Time,Systolic,Diastolic,Pulse,Classification\n", "bogus", `unknown import format "bogus"`},
+		{"header of another format", "Date,Time,Systolic,Diastolic,Pulse,Classification\n", "withings", "does not match the Withings format"},
+	}
+	for _, tt := range tests {
+		_, _, err := importer.ParseCSV(strings.NewReader(tt.input), tt.format, denver)
+		if err == nil || !strings.Contains(err.Error(), tt.err) {
+			t.Errorf("%s: error = %v, want %q", tt.name, err, tt.err)
+		}
+	}
+}
+
+// checkRecord compares a parsed record with the expected row number and
+// either a local timestamp in Denver and values, or an error
+func checkRecord(t *testing.T, rec importer.Record, row int, timestamp string, values [3]int, wantErr string) {
+	t.Helper()
+	if rec.Row != row {
+		t.Errorf("record row = %d, want %d", rec.Row, row)
+	}
+	if wantErr != "" {
+		if rec.Err == nil || !strings.Contains(rec.Err.Error(), wantErr) || rec.Reading != nil {
+			t.Errorf("row %d: error = %v with reading %+v, want %q", row, rec.Err, rec.Reading, wantErr)
+		}
+		return
+	}
+	if rec.Err != nil {
+		t.Errorf("row %d: %v", row, rec.Err)
+		return
+	}
+	want, err := time.ParseInLocation("2006-01-02 15:04:05", timestamp, denver)
+	if err != nil {
+		t.Fatal(err)
+	}
+	got := rec.Reading
+	if !got.Timestamp.Equal(want) || [3]int{got.Systolic, got.Diastolic, got.Pulse} != values {
+		t.Errorf("row %d = %v %d/%d %d, want %v %v", row, got.Timestamp.In(denver), got.Systolic, got.Diastolic, got.Pulse, want, values)
+	}
+}
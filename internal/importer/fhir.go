@@ -0,0 +1,349 @@
+// File: internal/importer/fhir.go
+
+package importer
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"sort"
+	"strings"
+	"time"
+
+	"bp-tracker/internal/fhir"
+	"bp-tracker/internal/models"
+)
+
+// FHIRSourcePrefix starts the Source of readings imported from FHIR
+const FHIRSourcePrefix = "fhir"
+
+// fhirDateLayouts are the dateTime precisions accepted for effective[x];
+// a bare year or month is too vague for a reading
+var fhirDateLayouts = []struct {
+	layout string
+	zoned  bool
+}{
+	{time.RFC3339Nano, true},
+	{"2006-01-02T15:04:05", false},
+	{"2006-01-02", false},
+}
+
+// FHIRPulseWindow is how far a heart-rate Observation may be from a blood
+// pressure panel without one to be used as its pulse
+const FHIRPulseWindow = 15 * time.Minute
+
+// ParseFHIR reads blood pressure Observations from a FHIR Bundle, a single
+// resource, or an NDJSON bulk export file (one resource per line). Every
+// resource becomes one record, numbered in file order, except heart-rate
+// Observations: as in the vital-signs profile, a panel without a heart rate
+// component takes its pulse from the nearest one for the same subject
+// within FHIRPulseWindow. Heart rates no panel uses, and resources that are
+// neither, are returned with an UnsupportedError so they can be reported
+// back. Dates without a zone are read in loc.
+func ParseFHIR(r io.Reader, loc *time.Location) ([]Record, error) {
+	var records []Record
+	var panels []fhirPanel // Readings still needing a pulse
+	var pulses []fhirPulse
+	row := 0
+	add := func(raw json.RawMessage) {
+		row++
+		entry := parseFHIRResource(raw, loc)
+		switch {
+		case entry.pulse != nil:
+			entry.pulse.row = row
+			pulses = append(pulses, *entry.pulse)
+			return
+		case entry.needsPulse:
+			panels = append(panels, fhirPanel{record: len(records), name: entry.name, subject: entry.subject})
+		}
+		records = append(records, Record{Row: row, Reading: entry.reading, Err: entry.err})
+	}
+
+	// NDJSON is a stream of JSON values, so one decoder handles both forms
+	d := json.NewDecoder(r)
+	for {
+		var raw json.RawMessage
+		if err := d.Decode(&raw); err == io.EOF {
+			break
+		} else if err != nil {
+			return nil, fmt.Errorf("error reading FHIR resource %d: %w", row+1, err)
+		}
+
+		var head struct {
+			ResourceType string `json:"resourceType"`
+			Entry        []struct {
+				Resource json.RawMessage `json:"resource"`
+			} `json:"entry"`
+		}
+		if err := json.Unmarshal(raw, &head); err != nil {
+			return nil, fmt.Errorf("error reading FHIR resource %d: %w", row+1, err)
+		}
+		if head.ResourceType == "" {
+			return nil, fmt.Errorf("FHIR resource %d has no resourceType", row+1)
+		}
+
+		if head.ResourceType != "Bundle" {
+			add(raw)
+			continue
+		}
+		for _, entry := range head.Entry {
+			add(entry.Resource)
+		}
+	}
+
+	if row == 0 {
+		return nil, fmt.Errorf("file contains no FHIR resources")
+	}
+
+	matchFHIRPulse(records, panels, pulses)
+	for _, p := range pulses {
+		if !p.used {
+			reason := fmt.Sprintf("%s is a heart rate with no blood pressure panel for the same subject within %v", p.name, FHIRPulseWindow)
+			records = append(records, Record{Row: p.row, Err: &UnsupportedError{reason}})
+		}
+	}
+	sort.Slice(records, func(i, j int) bool { return records[i].Row < records[j].Row })
+	return records, nil
+}
+
+// fhirEntry is one parsed resource: a reading, a heart rate, or an error
+type fhirEntry struct {
+	name       string
+	reading    *models.Reading
+	subject    string // subject.reference, for pairing panels with heart rates
+	needsPulse bool   // reading is a panel without a heart rate component
+	pulse      *fhirPulse
+	err        error
+}
+
+// fhirPanel is a reading waiting for a heart-rate Observation
+type fhirPanel struct {
+	record  int // Index in records
+	name    string
+	subject string
+}
+
+// fhirPulse is a separate heart-rate Observation
+type fhirPulse struct {
+	row     int
+	name    string
+	subject string
+	time    time.Time
+	value   int
+	used    bool
+}
+
+// parseFHIRResource converts one resource, or explains why it cannot be
+func parseFHIRResource(raw json.RawMessage, loc *time.Location) fhirEntry {
+	var obs fhir.Observation
+	if err := json.Unmarshal(raw, &obs); err != nil {
+		return fhirEntry{err: fmt.Errorf("invalid resource: %v", err)}
+	}
+	e := fhirEntry{name: resourceName(obs.ResourceType, obs.ID)}
+	if obs.Subject != nil {
+		e.subject = obs.Subject.Reference
+	}
+
+	if obs.ResourceType != "Observation" {
+		e.err = &UnsupportedError{e.name + " is not an Observation"}
+		return e
+	}
+	panel := obs.Code.HasCoding(fhir.LOINCSystem, fhir.LOINCBloodPressurePanel) || obs.Code.HasCoding(fhir.LOINCSystem, fhir.LOINCBloodPressureOld)
+	heartRate := obs.Code.HasCoding(fhir.LOINCSystem, fhir.LOINCHeartRate)
+	if !panel && !heartRate {
+		e.err = &UnsupportedError{fmt.Sprintf("%s is not a blood pressure panel or heart rate (%s)", e.name, codesOf(obs.Code))}
+		return e
+	}
+	switch obs.Status {
+	case "entered-in-error", "cancelled":
+		e.err = fmt.Errorf("%s has status %q", e.name, obs.Status)
+		return e
+	}
+
+	ts, err := fhirEffectiveTime(obs, loc)
+	if err != nil {
+		e.err = fmt.Errorf("%s: %v", e.name, err)
+		return e
+	}
+
+	if !panel {
+		q := obs.ValueQuantity
+		switch {
+		case q == nil:
+			e.err = fmt.Errorf("%s: heart rate has no valueQuantity", e.name)
+		case !isPerMinute(q):
+			e.err = fmt.Errorf("%s: heart rate has unsupported unit %q", e.name, q.Code)
+		default:
+			e.pulse = &fhirPulse{name: e.name, subject: e.subject, time: ts, value: int(math.Round(q.Value))}
+		}
+		return e
+	}
+
+	r := &models.Reading{Timestamp: ts, Source: fhirSource(obs)}
+	for _, c := range []struct {
+		code     string
+		dest     *int
+		unit     func(q *fhir.Quantity) bool
+		optional bool
+	}{
+		{fhir.LOINCSystolic, &r.Systolic, isMmHg, false},
+		{fhir.LOINCDiastolic, &r.Diastolic, isMmHg, false},
+		{fhir.LOINCHeartRate, &r.Pulse, isPerMinute, true}, // Else a separate Observation
+	} {
+		q, err := componentValue(obs, c.code)
+		if errors.Is(err, errNoComponent) && c.optional {
+			e.needsPulse = true
+			continue
+		}
+		if err != nil {
+			e.err = fmt.Errorf("%s: %v", e.name, err)
+			return e
+		}
+		if !c.unit(q) {
+			e.err = fmt.Errorf("%s: component %s has unsupported unit %q", e.name, c.code, q.Code)
+			return e
+		}
+		*c.dest = int(math.Round(q.Value))
+	}
+
+	e.reading = r
+	return e
+}
+
+// matchFHIRPulse sets the pulse of each panel to the nearest heart rate for
+// the same subject within FHIRPulseWindow. Panels without one become errors.
+func matchFHIRPulse(records []Record, panels []fhirPanel, pulses []fhirPulse) {
+	sort.SliceStable(pulses, func(i, j int) bool { return pulses[i].time.Before(pulses[j].time) })
+
+	for _, p := range panels {
+		rec := &records[p.record]
+		ts := rec.Reading.Timestamp
+		best := -1
+		var bestDistance time.Duration
+		i := sort.Search(len(pulses), func(i int) bool { return !pulses[i].time.Before(ts.Add(-FHIRPulseWindow)) })
+		for ; i < len(pulses) && !pulses[i].time.After(ts.Add(FHIRPulseWindow)); i++ {
+			if pulses[i].subject != p.subject {
+				continue
+			}
+			d := pulses[i].time.Sub(ts)
+			if d < 0 {
+				d = -d
+			}
+			if best < 0 || d < bestDistance {
+				best, bestDistance = i, d
+			}
+		}
+
+		if best < 0 {
+			rec.Reading = nil
+			rec.Err = fmt.Errorf("%s has no heart rate component and no heart-rate Observation for the same subject within %v", p.name, FHIRPulseWindow)
+			continue
+		}
+		rec.Reading.Pulse = pulses[best].value
+		pulses[best].used = true
+	}
+}
+
+// componentValue returns the quantity of the component coded with LOINC code
+func componentValue(obs fhir.Observation, code string) (*fhir.Quantity, error) {
+	for _, c := range obs.Component {
+		if !c.Code.HasCoding(fhir.LOINCSystem, code) {
+			continue
+		}
+		if c.ValueQuantity == nil {
+			return nil, fmt.Errorf("component %s has no valueQuantity", code)
+		}
+		return c.ValueQuantity, nil
+	}
+	return nil, fmt.Errorf("%w %s", errNoComponent, code)
+}
+
+// errNoComponent is returned by componentValue for a missing component
+var errNoComponent = errors.New("missing component")
+
+// isMmHg accepts UCUM mm[Hg] and quantities without a code
+func isMmHg(q *fhir.Quantity) bool {
+	return q.Code == "" || q.Code == "mm[Hg]" || strings.EqualFold(q.Code, "mmHg")
+}
+
+// isPerMinute accepts UCUM /min and its common variants
+func isPerMinute(q *fhir.Quantity) bool {
+	switch q.Code {
+	case "", "/min", "{beats}/min", "{Beats}/min", "beats/min":
+		return true
+	}
+	return false
+}
+
+// fhirEffectiveTime returns effectiveDateTime, effectiveInstant or the start
+// of effectivePeriod
+func fhirEffectiveTime(obs fhir.Observation, loc *time.Location) (time.Time, error) {
+	value := obs.EffectiveDateTime
+	if value == "" {
+		value = obs.EffectiveInstant
+	}
+	if value == "" && obs.EffectivePeriod != nil {
+		value = obs.EffectivePeriod.Start
+	}
+	if value == "" {
+		return time.Time{}, errors.New("no effectiveDateTime")
+	}
+
+	for _, d := range fhirDateLayouts {
+		var ts time.Time
+		var err error
+		if d.zoned {
+			ts, err = time.Parse(d.layout, value)
+		} else {
+			ts, err = time.ParseInLocation(d.layout, value, loc)
+		}
+		if err == nil {
+			return ts, nil
+		}
+	}
+	return time.Time{}, fmt.Errorf("invalid or imprecise effectiveDateTime %q", value)
+}
+
+// fhirSource tags a reading with where the Observation came from:
+// meta.source, else the first performer, e.g. "fhir:Organization/123"
+func fhirSource(obs fhir.Observation) string {
+	origin := ""
+	if obs.Meta != nil {
+		origin = obs.Meta.Source
+	}
+	if origin == "" && len(obs.Performer) > 0 {
+		origin = obs.Performer[0].Reference
+		if origin == "" {
+			origin = obs.Performer[0].Display
+		}
+	}
+	if origin == "" {
+		return FHIRSourcePrefix
+	}
+	return FHIRSourcePrefix + ":" + origin
+}
+
+// resourceName identifies a resource in report reasons, e.g. "Patient/123"
+func resourceName(resourceType, id string) string {
+	if resourceType == "" {
+		resourceType = "resource"
+	}
+	if id == "" {
+		return resourceType
+	}
+	return resourceType + "/" + id
+}
+
+// codesOf lists a concept's codes for report reasons
+func codesOf(c fhir.CodeableConcept) string {
+	var codes []string
+	for _, coding := range c.Coding {
+		codes = append(codes, coding.Code)
+	}
+	if len(codes) == 0 {
+		return "no code"
+	}
+	return "code " + strings.Join(codes, ", ")
+}
@@ -0,0 +1,124 @@
+// File: internal/importer/fhir_test.go
+
+package importer_test
+
+import (
+	"errors"
+	"os"
+	"strings"
+	"testing"
+	"time"
+
+	"bp-tracker/internal/importer"
+)
+
+// TestParseFHIR reads the same resources as a Bundle and as NDJSON. Panel
+// bp2 has no heart rate component and pairs with hr2, the nearest heart
+// rate for its subject; hr1 is another subject's and hr3 is further away,
+// so both are reported unused. Nothing is near bp3.
+func TestParseFHIR(t *testing.T) {
+	tests := []struct {
+		row         int
+		timestamp   string // Local time in Denver
+		values      [3]int
+		source      string
+		err         string
+		unsupported bool
+	}{
+		{row: 1, err: "Patient/p1 is not an Observation", unsupported: true},
+		{row: 2, timestamp: "2025-01-15 07:30:00", values: [3]int{128, 82, 70}, source: "fhir:https://clinic.example"},
+		{row: 3, timestamp: "2025-01-15 19:45:00", values: [3]int{118, 76, 64}, source: "fhir"}, // Zone-less, in Denver
+		{row: 4, err: "Observation/hr1 is a heart rate with no blood pressure panel for the same subject within 15m0s", unsupported: true},
+		// Row 5 is hr2, used as bp2's pulse
+		{row: 6, err: "Observation/hr3 is a heart rate with no blood pressure panel for the same subject within 15m0s", unsupported: true},
+		{row: 7, err: "Observation/bp3 has no heart rate component and no heart-rate Observation for the same subject within 15m0s"},
+		{row: 8, err: "Observation/wt1 is not a blood pressure panel or heart rate (code 29463-7)", unsupported: true},
+	}
+
+	for _, name := range []string{"bundle.json", "observations.ndjson"} {
+		t.Run(name, func(t *testing.T) {
+			f, err := os.Open("testdata/" + name)
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer f.Close()
+			records, err := importer.ParseFHIR(f, denver)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(records) != len(tests) {
+				t.Fatalf("got %d records, want %d", len(records), len(tests))
+			}
+			for i, tt := range tests {
+				rec := records[i]
+				checkRecord(t, rec, tt.row, tt.timestamp, tt.values, tt.err)
+				var unsupported *importer.UnsupportedError
+				if errors.As(rec.Err, &unsupported) != tt.unsupported {
+					t.Errorf("row %d: error %v unsupported = %t, want %t", tt.row, rec.Err, !tt.unsupported, tt.unsupported)
+				}
+				if rec.Reading != nil && rec.Reading.Source != tt.source {
+					t.Errorf("row %d: source = %q, want %q", tt.row, rec.Reading.Source, tt.source)
+				}
+			}
+		})
+	}
+}
+
+// TestParseFHIRDates checks that dates without a zone are read in the
+// location given, and dates with one are not
+func TestParseFHIRDates(t *testing.T) {
+	tokyo := mustLoadLocation("Asia/Tokyo")
+	tests := []struct {
+		date string
+		loc  *time.Location
+		want string // RFC 3339, or the error
+	}{
+		{"2025-01-15T19:45:00", denver, "2025-01-16T02:45:00Z"},
+		{"2025-01-15T19:45:00", tokyo, "2025-01-15T10:45:00Z"},
+		{"2025-07-15T19:45:00", denver, "2025-07-16T01:45:00Z"}, // Daylight time
+		{"2025-01-16", denver, "2025-01-16T07:00:00Z"},
+		{"2025-01-15T19:45:00+01:00", denver, "2025-01-15T18:45:00Z"},
+		{"2025-01-15T19:45:00.250Z", tokyo, "2025-01-15T19:45:00.25Z"},
+		{"2025-01", denver, `invalid or imprecise effectiveDateTime "2025-01"`},
+	}
+	for _, tt := range tests {
+		resource := `{"resourceType": "Observation", "id": "bp", "status": "final",
+			"code": {"coding": [{"system": "http://loinc.org", "code": "85354-9"}]},
+			"effectiveDateTime": "` + tt.date + `",
+			"component": [
+				{"code": {"coding": [{"system": "http://loinc.org", "code": "8480-6"}]}, "valueQuantity": {"value": 120, "code": "mm[Hg]"}},
+				{"code": {"coding": [{"system": "http://loinc.org", "code": "8462-4"}]}, "valueQuantity": {"value": 80, "code": "mm[Hg]"}},
+				{"code": {"coding": [{"system": "http://loinc.org", "code": "8867-4"}]}, "valueQuantity": {"value": 70, "code": "/min"}}
+			]}`
+		records, err := importer.ParseFHIR(strings.NewReader(resource), tt.loc)
+		if err != nil || len(records) != 1 {
+			t.Fatalf("%s: %v, %d records", tt.date, err, len(records))
+		}
+		got := ""
+		if rec := records[0]; rec.Err != nil {
+			got = strings.TrimPrefix(rec.Err.Error(), "Observation/bp: ")
+		} else {
+			got = rec.Reading.Timestamp.UTC().Format(time.RFC3339Nano)
+		}
+		if got != tt.want {
+			t.Errorf("%s in %s = %s, want %s", tt.date, tt.loc, got, tt.want)
+		}
+	}
+}
+
+func TestParseFHIRErrors(t *testing.T) {
+	tests := []struct {
+		name, input, err string
+	}{
+		{"empty", "", "file contains no FHIR resources"},
+		{"empty Bundle", `{"resourceType": "Bundle", "type": "searchset"}`, "file contains no FHIR resources"},
+		{"no resourceType", `{"id": "bp"}`, "FHIR resource 1 has no resourceType"},
+		{"not JSON", "Date,Time,Systolic\n", "error reading FHIR resource 1"},
+	}
+	for _, tt := range tests {
+		_, err := importer.ParseFHIR(strings.NewReader(tt.input), denver)
+		if err == nil || !strings.Contains(err.Error(), tt.err) {
+			t.Errorf("%s: error = %v, want %q", tt.name, err, tt.err)
+		}
+	}
+}
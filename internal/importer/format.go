@@ -0,0 +1,150 @@
+// File: internal/importer/format.go
+
+package importer
+
+import (
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+	"time"
+
+	"bp-tracker/internal/models"
+)
+
+// AutoDetect asks ParseCSV to pick the format from the header row
+const AutoDetect = "auto"
+
+// Format is a CSV layout that can be imported
+type Format interface {
+	Name() string  // Identifier used in the format query parameter
+	Label() string // Human-readable name, e.g. "Omron Connect"
+
+	// Bind checks a header row and returns a parser for the rows below it,
+	// or false if the header is not in this format
+	Bind(header []string) (RowParser, bool)
+}
+
+// RowParser converts one data row into a reading. Timestamps without a zone
+// are read in loc.
+type RowParser func(fields []string, loc *time.Location) (*models.Reading, error)
+
+// formats holds registered formats in detection order
+var formats []Format
+
+// The built-in formats in detection order. Qardio is tried before Withings
+// because its files can also carry a "Heart Rate" column; the irregular
+// heartbeat column, which Qardio always writes, tells them apart.
+func init() {
+	Register(exportFormat{})
+	Register(omronFormat)
+	Register(qardioFormat)
+	Register(withingsFormat)
+}
+
+// Register adds a format. Formats are tried in registration order when
+// detecting, so more specific layouts must be registered first.
+func Register(f Format) {
+	if _, ok := Lookup(f.Name()); ok {
+		panic("importer: format " + f.Name() + " registered twice")
+	}
+	formats = append(formats, f)
+}
+
+// Lookup returns the registered format with the given name
+func Lookup(name string) (Format, bool) {
+	for _, f := range formats {
+		if strings.EqualFold(f.Name(), name) {
+			return f, true
+		}
+	}
+	return nil, false
+}
+
+// Formats returns the registered formats sorted by name
+func Formats() []Format {
+	list := append([]Format(nil), formats...)
+	sort.Slice(list, func(i, j int) bool { return list[i].Name() < list[j].Name() })
+	return list
+}
+
+// ParseCSV parses a CSV file in the named format, or detects the format from
+// the header when name is empty or AutoDetect. Rows that cannot be parsed
+// are returned with Err set; only an unreadable file, an unknown format or
+// a header that does not match is an error.
+func ParseCSV(r io.Reader, name string, loc *time.Location) (Format, []Record, error) {
+	reader := csv.NewReader(r)
+	reader.FieldsPerRecord = -1 // Report short rows per row instead of failing the file
+	reader.TrimLeadingSpace = true
+	reader.LazyQuotes = true // Vendor exports are not always strict about quoting
+
+	header, err := reader.Read()
+	if err == io.EOF {
+		return nil, nil, fmt.Errorf("file is empty")
+	} else if err != nil {
+		return nil, nil, fmt.Errorf("error reading CSV header: %w", err)
+	}
+
+	format, parse, err := bindFormat(header, name)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	var records []Record
+	for row := 2; ; row++ {
+		fields, err := reader.Read()
+		if err == io.EOF {
+			break
+		}
+		var parseErr *csv.ParseError
+		if errors.As(err, &parseErr) {
+			records = append(records, Record{Row: row, Err: err})
+			continue
+		} else if err != nil {
+			return nil, nil, fmt.Errorf("error reading CSV row %d: %w", row, err)
+		}
+		if isBlank(fields) {
+			continue
+		}
+
+		reading, err := parse(fields, loc)
+		records = append(records, Record{Row: row, Reading: reading, Err: err})
+	}
+
+	return format, records, nil
+}
+
+// bindFormat finds the format for header, either the one named or the
+// first registered format that accepts it
+func bindFormat(header []string, name string) (Format, RowParser, error) {
+	if name != "" && name != AutoDetect {
+		format, ok := Lookup(name)
+		if !ok {
+			return nil, nil, fmt.Errorf("unknown import format %q", name)
+		}
+		parse, ok := format.Bind(header)
+		if !ok {
+			return nil, nil, fmt.Errorf("CSV header %q does not match the %s format", strings.Join(header, ","), format.Label())
+		}
+		return format, parse, nil
+	}
+
+	for _, format := range formats {
+		if parse, ok := format.Bind(header); ok {
+			return format, parse, nil
+		}
+	}
+	return nil, nil, fmt.Errorf("unrecognized CSV header %q", strings.Join(header, ","))
+}
+
+// isBlank reports whether every field in a row is empty
+func isBlank(fields []string) bool {
+	for _, f := range fields {
+		if strings.TrimSpace(f) != "" {
+			return false
+		}
+	}
+	return true
+}
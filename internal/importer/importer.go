@@ -0,0 +1,186 @@
+// File: internal/importer/importer.go
+
+package importer
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"bp-tracker/internal/database"
+	"bp-tracker/internal/models"
+	"bp-tracker/internal/utils"
+	"bp-tracker/internal/validation"
+)
+
+// Status is the outcome for one imported row
+type Status string
+
+const (
+	StatusAccepted    Status = "accepted"    // Valid and new; saved unless a dry run
+	StatusSkipped     Status = "skipped"     // Could not be parsed or failed validation
+	StatusDuplicate   Status = "duplicate"   // Already stored, or repeated in the file
+	StatusUnsupported Status = "unsupported" // Not a kind of data the tracker stores, e.g. a FHIR Patient
+)
+
+// UnsupportedError marks a Record that the source holds but the tracker
+// cannot store; Prepare reports it as StatusUnsupported rather than skipped
+type UnsupportedError struct {
+	Reason string
+}
+
+func (e *UnsupportedError) Error() string { return e.Reason }
+
+// Record is one parsed row from an import source
+type Record struct {
+	Row     int             // 1-based row in the source, including any header (the correlation number for Apple Health)
+	Reading *models.Reading // Timestamp, systolic, diastolic and pulse; nil if Err is set
+	Err     error           // Why the row could not be parsed
+}
+
+// RowResult reports what happened to one row
+type RowResult struct {
+	Row     int             `json:"row"`
+	Status  Status          `json:"status"`
+	Reason  string          `json:"reason,omitempty"`
+	Reading *models.Reading `json:"reading,omitempty"`
+}
+
+// Report summarizes an import
+type Report struct {
+	Accepted    int         `json:"accepted"`
+	Skipped     int         `json:"skipped"`
+	Duplicates  int         `json:"duplicates"`
+	Unsupported int         `json:"unsupported"`
+	Rows        []RowResult `json:"rows"`
+}
+
+// duplicateKey identifies a reading for duplicate detection. Timestamps are
+// compared to the second, the precision of the CSV export.
+type duplicateKey struct {
+	unix                       int64
+	systolic, diastolic, pulse int
+}
+
+func keyOf(r *models.Reading) duplicateKey {
+	return duplicateKey{r.Timestamp.Unix(), r.Systolic, r.Diastolic, r.Pulse}
+}
+
+// Prepare validates each record, recomputes its classification with
+// classifier and marks duplicates of existing readings or of earlier rows.
+// It returns the report and the accepted readings in source order.
+func Prepare(records []Record, existing []*models.Reading, classifier utils.Classifier) (*Report, []*models.Reading) {
+	seen := make(map[duplicateKey]bool, len(existing))
+	for _, r := range existing {
+		seen[keyOf(r)] = true
+	}
+
+	report := &Report{Rows: make([]RowResult, 0, len(records))}
+	var accepted []*models.Reading
+	for _, rec := range records {
+		result := RowResult{Row: rec.Row, Reading: rec.Reading}
+
+		var unsupported *UnsupportedError
+		if errors.As(rec.Err, &unsupported) {
+			result.Status, result.Reason = StatusUnsupported, rec.Err.Error()
+		} else if rec.Err != nil {
+			result.Status, result.Reason = StatusSkipped, rec.Err.Error()
+		} else if err := validation.ValidateReading(rec.Reading.Systolic, rec.Reading.Diastolic, rec.Reading.Pulse); err != nil {
+			result.Status, result.Reason = StatusSkipped, reasonOf(err)
+		} else if seen[keyOf(rec.Reading)] {
+			result.Status, result.Reason = StatusDuplicate, "a reading with the same time and values already exists"
+		} else {
+			rec.Reading.Classification = classifier.Classify(rec.Reading.Systolic, rec.Reading.Diastolic).Classification()
+			rec.Reading.ClassificationVersion = classifier.Version()
+			seen[keyOf(rec.Reading)] = true
+			accepted = append(accepted, rec.Reading)
+			result.Status = StatusAccepted
+		}
+
+		switch result.Status {
+		case StatusAccepted:
+			report.Accepted++
+		case StatusSkipped:
+			report.Skipped++
+		case StatusDuplicate:
+			report.Duplicates++
+		case StatusUnsupported:
+			report.Unsupported++
+		}
+		report.Rows = append(report.Rows, result)
+	}
+
+	return report, accepted
+}
+
+// Import prepares records against the readings already in store and saves
+// the accepted ones in a single SeedReadings batch, so either every accepted
+// row is stored or none is. With dryRun nothing is saved.
+func Import(store database.ReadingStore, records []Record, classifier utils.Classifier, dryRun bool) (*Report, error) {
+	existing, err := existingReadings(store, records)
+	if err != nil {
+		return nil, err
+	}
+
+	report, accepted := Prepare(records, existing, classifier)
+	if dryRun || len(accepted) == 0 {
+		return report, nil
+	}
+
+	if err := store.SeedReadings(accepted); err != nil {
+		return nil, fmt.Errorf("error saving imported readings: %w", err)
+	}
+	return report, nil
+}
+
+// existingReadings loads the stored readings in the time span of records
+func existingReadings(store database.ReadingStore, records []Record) ([]*models.Reading, error) {
+	var from, to time.Time
+	for _, rec := range records {
+		if rec.Reading == nil {
+			continue
+		}
+		if from.IsZero() || rec.Reading.Timestamp.Before(from) {
+			from = rec.Reading.Timestamp
+		}
+		if rec.Reading.Timestamp.After(to) {
+			to = rec.Reading.Timestamp
+		}
+	}
+	if from.IsZero() {
+		return nil, nil
+	}
+
+	q := database.ReadingQuery{
+		From:  from.Truncate(time.Second),
+		To:    to.Truncate(time.Second).Add(time.Second),
+		Order: database.SortAsc,
+		Limit: database.MaxPageSize,
+	}
+	var existing []*models.Reading
+	for {
+		page, err := store.QueryReadings(q)
+		if err != nil {
+			return nil, fmt.Errorf("error loading existing readings: %w", err)
+		}
+		existing = append(existing, page.Readings...)
+		if page.NextCursor == "" {
+			return existing, nil
+		}
+		q.Cursor = page.NextCursor
+	}
+}
+
+// reasonOf flattens validation errors into one line for the report
+func reasonOf(err error) string {
+	var errs validation.ValidationErrors
+	if !errors.As(err, &errs) {
+		return err.Error()
+	}
+	msgs := make([]string, len(errs))
+	for i, e := range errs {
+		msgs[i] = e.Error()
+	}
+	return strings.Join(msgs, "; ")
+}
@@ -0,0 +1,100 @@
+// File: internal/importer/importer_test.go
+
+package importer
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"bp-tracker/internal/database"
+	"bp-tracker/internal/models"
+	"bp-tracker/internal/utils"
+)
+
+func TestImport(t *testing.T) {
+	base := time.Date(2025, 1, 15, 14, 30, 0, 0, time.UTC)
+	reading := func(offset time.Duration, systolic, diastolic, pulse int) *models.Reading {
+		return &models.Reading{Timestamp: base.Add(offset), Systolic: systolic, Diastolic: diastolic, Pulse: pulse}
+	}
+
+	store := database.NewMemoryStore()
+	if err := store.SaveReading(reading(0, 128, 82, 70)); err != nil {
+		t.Fatal(err)
+	}
+
+	records := func() []Record {
+		return []Record{
+			{Row: 2, Reading: reading(0, 128, 82, 70)},                          // Already stored
+			{Row: 3, Reading: reading(500*time.Millisecond, 128, 82, 70)},       // Stored, to the second
+			{Row: 4, Reading: reading(0, 128, 82, 71)},                          // Same time, other values
+			{Row: 5, Reading: reading(time.Hour, 142, 88, 75)},                  // New
+			{Row: 6, Reading: reading(time.Hour, 142, 88, 75)},                  // Repeats row 5
+			{Row: 7, Reading: reading(2*time.Hour, 400, 88, 75)},                // Out of range
+			{Row: 8, Err: errors.New(`invalid systolic "abc"`)},                 // Unparsed
+			{Row: 9, Err: &UnsupportedError{"Patient/1 is not an Observation"}}, // Not a reading
+			{Row: 10, Reading: reading(3*time.Hour, 118, 76, 64)},               // New
+		}
+	}
+	want := []Status{
+		StatusDuplicate, StatusDuplicate, StatusAccepted, StatusAccepted, StatusDuplicate,
+		StatusSkipped, StatusSkipped, StatusUnsupported, StatusAccepted,
+	}
+
+	check := func(t *testing.T, report *Report) {
+		t.Helper()
+		if report.Accepted != 3 || report.Duplicates != 3 || report.Skipped != 2 || report.Unsupported != 1 {
+			t.Errorf("report = %d accepted, %d duplicates, %d skipped, %d unsupported; want 3, 3, 2, 1",
+				report.Accepted, report.Duplicates, report.Skipped, report.Unsupported)
+		}
+		for i, row := range report.Rows {
+			if row.Status != want[i] || row.Row != i+2 {
+				t.Errorf("row %d: %s (%s), want row %d %s", row.Row, row.Status, row.Reason, i+2, want[i])
+			}
+			if row.Status != StatusAccepted && row.Reason == "" {
+				t.Errorf("row %d: %s without a reason", row.Row, row.Status)
+			}
+		}
+	}
+
+	// A dry run reports the same but saves nothing
+	report, err := Import(store, records(), utils.AHA2017, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	check(t, report)
+	if all, _ := store.GetAllReadings(); len(all) != 1 {
+		t.Fatalf("dry run stored %d readings, want 1", len(all))
+	}
+
+	report, err = Import(store, records(), utils.AHA2017, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	check(t, report)
+	all, err := store.GetAllReadings()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(all) != 4 {
+		t.Fatalf("stored %d readings, want 4", len(all))
+	}
+	for _, r := range all {
+		if r.ID == 1 { // Stored before the import
+			continue
+		}
+		category := utils.AHA2017.Classify(r.Systolic, r.Diastolic)
+		if r.Classification != category.Classification() || r.ClassificationVersion != utils.AHA2017.Version() {
+			t.Errorf("imported %d/%d classified %q (%s), want %q", r.Systolic, r.Diastolic, r.Classification, r.ClassificationVersion, category.Classification())
+		}
+	}
+
+	// Importing the same file again finds every reading already stored
+	report, err = Import(store, records(), utils.AHA2017, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if report.Accepted != 0 || report.Duplicates != 6 {
+		t.Errorf("second import: %d accepted, %d duplicates; want 0, 6", report.Accepted, report.Duplicates)
+	}
+}
@@ -0,0 +1,209 @@
+// File: internal/importer/vendor.go
+
+package importer
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+	"time"
+
+	"bp-tracker/internal/models"
+)
+
+// omronFormat reads the CSV export of the OMRON connect app
+var omronFormat = &columnFormat{
+	name:      "omron",
+	label:     "OMRON connect",
+	date:      []string{"Date", "Measurement Date"},
+	time:      []string{"Time"},
+	systolic:  []string{"Systolic (mmHg)", "SYS (mmHg)", "SYS"},
+	diastolic: []string{"Diastolic (mmHg)", "DIA (mmHg)", "DIA"},
+	pulse:     []string{"Pulse (bpm)", "Pulse (Pulse/min)", "Pulse"},
+	irregular: []string{"Irregular Heartbeat", "Irregular heartbeat detected", "IHB"},
+	layouts: []string{
+		"2006-01-02 15:04",
+		"2006-01-02 15:04:05",
+		"2006/01/02 15:04",
+		"1/2/2006 15:04",
+		"1/2/2006 3:04 PM",
+		"Jan 2 2006 15:04",
+		"Jan 2 2006 3:04 PM",
+		"Jan 2, 2006 3:04 PM",
+	},
+}
+
+// qardioFormat reads the CSV export of the Qardio app. Slashed dates are
+// day first.
+var qardioFormat = &columnFormat{
+	name:      "qardio",
+	label:     "Qardio",
+	date:      []string{"Date", "Measurement Date"},
+	time:      []string{"Time"},
+	systolic:  []string{"Systolic"},
+	diastolic: []string{"Diastolic"},
+	pulse:     []string{"Pulse", "Heart Rate"},
+	irregular: []string{"Irregular Heartbeat", "Irregular heartbeat detected", "Arrhythmia"},
+
+	requireIrregular: true,
+	layouts: []string{
+		"2006-01-02 15:04:05",
+		"2006-01-02 15:04",
+		"2/1/2006 15:04:05",
+		"2/1/2006 15:04",
+	},
+}
+
+// withingsFormat reads "bp.csv" from a Withings data export. Withings does
+// not export an irregular heartbeat flag with blood pressure.
+var withingsFormat = &columnFormat{
+	name:      "withings",
+	label:     "Withings",
+	date:      []string{"Date"},
+	systolic:  []string{"Systolic"},
+	diastolic: []string{"Diastolic"},
+	pulse:     []string{"Heart rate"},
+	layouts: []string{
+		"2006-01-02 15:04:05",
+		"2006-01-02 15:04",
+	},
+}
+
+// columnFormat is a vendor CSV layout identified by its column names, which
+// are matched ignoring case and spaces. The date column may hold the time
+// too; when a separate time column exists the two are joined with a space
+// before trying each layout.
+type columnFormat struct {
+	name, label string
+
+	// Accepted header names for each field
+	date, time, systolic, diastolic, pulse, irregular []string
+
+	// requireIrregular rejects files without an irregular heartbeat column
+	requireIrregular bool
+
+	// Timestamp layouts, tried in order
+	layouts []string
+}
+
+func (f *columnFormat) Name() string  { return f.name }
+func (f *columnFormat) Label() string { return f.label }
+
+// columnIndexes are the positions of each field in a row, -1 when absent
+type columnIndexes struct {
+	date, time, systolic, diastolic, pulse, irregular int
+}
+
+func (f *columnFormat) Bind(header []string) (RowParser, bool) {
+	idx := columnIndexes{
+		date:      findColumn(header, f.date),
+		time:      findColumn(header, f.time),
+		systolic:  findColumn(header, f.systolic),
+		diastolic: findColumn(header, f.diastolic),
+		pulse:     findColumn(header, f.pulse),
+		irregular: findColumn(header, f.irregular),
+	}
+	if idx.date < 0 || idx.systolic < 0 || idx.diastolic < 0 || idx.pulse < 0 {
+		return nil, false
+	}
+	if f.requireIrregular && idx.irregular < 0 {
+		return nil, false
+	}
+
+	return func(fields []string, loc *time.Location) (*models.Reading, error) {
+		return f.parseRow(fields, idx, loc)
+	}, true
+}
+
+// parseRow converts one row using the bound column positions
+func (f *columnFormat) parseRow(fields []string, idx columnIndexes, loc *time.Location) (*models.Reading, error) {
+	field := func(i int) string {
+		if i < 0 || i >= len(fields) {
+			return ""
+		}
+		return strings.TrimSpace(fields[i])
+	}
+
+	value := field(idx.date)
+	if t := field(idx.time); t != "" {
+		value += " " + t
+	}
+	ts, err := parseLayouts(value, f.layouts, loc)
+	if err != nil {
+		return nil, err
+	}
+
+	r := &models.Reading{Timestamp: ts}
+	for _, c := range []struct {
+		name string
+		i    int
+		dest *int
+	}{
+		{"systolic", idx.systolic, &r.Systolic},
+		{"diastolic", idx.diastolic, &r.Diastolic},
+		{"pulse", idx.pulse, &r.Pulse},
+	} {
+		if *c.dest, err = parseWhole(field(c.i)); err != nil {
+			return nil, fmt.Errorf("invalid %s %q", c.name, field(c.i))
+		}
+	}
+
+	if r.IrregularHeartbeat, err = parseFlag(field(idx.irregular)); err != nil {
+		return nil, err
+	}
+
+	return r, nil
+}
+
+// findColumn returns the position of the first header matching any of names
+func findColumn(header []string, names []string) int {
+	for i, h := range header {
+		for _, name := range names {
+			if normalizeColumn(h) == normalizeColumn(name) {
+				return i
+			}
+		}
+	}
+	return -1
+}
+
+// normalizeColumn lowercases a header name and drops spaces and any UTF-8
+// byte order mark, so "Systolic (mmHg)" matches "systolic(mmhg)"
+func normalizeColumn(name string) string {
+	name = strings.TrimPrefix(name, "\ufeff")
+	return strings.ToLower(strings.Join(strings.Fields(name), ""))
+}
+
+// parseLayouts parses value with the first layout that fits
+func parseLayouts(value string, layouts []string, loc *time.Location) (time.Time, error) {
+	for _, layout := range layouts {
+		if ts, err := time.ParseInLocation(layout, value, loc); err == nil {
+			return ts, nil
+		}
+	}
+	return time.Time{}, fmt.Errorf("invalid date/time %q", value)
+}
+
+// parseWhole parses a whole number, rounding values exported with decimals
+func parseWhole(s string) (int, error) {
+	if n, err := strconv.Atoi(s); err == nil {
+		return n, nil
+	}
+	f, err := strconv.ParseFloat(s, 64)
+	if err != nil || math.IsNaN(f) || math.IsInf(f, 0) {
+		return 0, fmt.Errorf("not a number: %q", s)
+	}
+	return int(math.Round(f)), nil
+}
+
+// parseFlag reads a vendor's yes/no column; an empty value means no
+func parseFlag(s string) (bool, error) {
+	switch strings.ToLower(s) {
+	case "", "0", "no", "n", "false", "-", "off":
+		return false, nil
+	case "1", "yes", "y", "true", "x", "on", "detected", "✓":
+		return true, nil
+	}
+	return false, fmt.Errorf("invalid irregular heartbeat flag %q", s)
+}
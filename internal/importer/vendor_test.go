@@ -0,0 +1,102 @@
+// File: internal/importer/vendor_test.go
+
+package importer_test
+
+import (
+	"os"
+	"strings"
+	"testing"
+
+	"bp-tracker/internal/importer"
+)
+
+func TestParseVendorCSV(t *testing.T) {
+	type row struct {
+		row       int
+		timestamp string // Local time in Denver
+		values    [3]int
+		irregular bool
+		err       string
+	}
+	tests := []struct {
+		format string
+		rows   []row
+	}{
+		{"omron", []row{
+			{row: 2, timestamp: "2025-01-15 07:30:00", values: [3]int{128, 82, 70}},
+			{row: 3, timestamp: "2025-01-20 19:05:00", values: [3]int{135, 88, 74}, irregular: true},
+			{row: 4, timestamp: "2025-01-22 06:45:00", values: [3]int{122, 79, 66}}, // 121.6 rounded
+			{row: 5, err: `invalid irregular heartbeat flag "maybe"`},
+		}},
+		{"qardio", []row{
+			{row: 2, timestamp: "2025-01-15 07:30:00", values: [3]int{128, 82, 70}},
+			{row: 3, timestamp: "2025-01-02 19:45:00", values: [3]int{118, 76, 64}, irregular: true}, // Day first
+			{row: 4, timestamp: "2025-01-20 07:00:00", values: [3]int{131, 84, 69}, irregular: true}, // Time in the date column
+			{row: 5, err: `invalid irregular heartbeat flag "?"`},
+		}},
+		{"withings", []row{
+			{row: 2, timestamp: "2025-01-15 07:30:00", values: [3]int{128, 82, 70}},
+			{row: 3, timestamp: "2025-01-16 07:31:00", values: [3]int{130, 84, 71}},
+			{row: 4, err: `invalid date/time "2025-01-17"`},
+		}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.format, func(t *testing.T) {
+			// Detected from the header, and named
+			for _, name := range []string{importer.AutoDetect, tt.format} {
+				f, err := os.Open("testdata/" + tt.format + ".csv")
+				if err != nil {
+					t.Fatal(err)
+				}
+				format, records, err := importer.ParseCSV(f, name, denver)
+				f.Close()
+				if err != nil {
+					t.Fatal(err)
+				}
+				if format.Name() != tt.format {
+					t.Errorf("%s: format = %q, want %q", name, format.Name(), tt.format)
+				}
+				if len(records) != len(tt.rows) {
+					t.Fatalf("%s: got %d records, want %d", name, len(records), len(tt.rows))
+				}
+				for i, want := range tt.rows {
+					rec := records[i]
+					checkRecord(t, rec, want.row, want.timestamp, want.values, want.err)
+					if rec.Reading != nil && rec.Reading.IrregularHeartbeat != want.irregular {
+						t.Errorf("%s row %d: irregular heartbeat = %t, want %t", name, want.row, rec.Reading.IrregularHeartbeat, want.irregular)
+					}
+				}
+			}
+		})
+	}
+}
+
+func TestDetectVendorFormat(t *testing.T) {
+	tests := []struct {
+		header, format string
+	}{
+		{"Date,Time,Systolic,Diastolic,Pulse,Classification", importer.ExportFormat},
+		{"\ufeffDate,Time,Systolic (mmHg),Diastolic (mmHg),Pulse (bpm),Irregular Heartbeat", "omron"},
+		{"Measurement Date,SYS,DIA,Pulse", "omron"},
+		{"Date,Time,Systolic,Diastolic,Heart Rate,Arrhythmia", "qardio"},
+		{"Date,Heart rate,Systolic,Diastolic,Comments", "withings"},
+		// Without the irregular heartbeat column Qardio writes, the
+		// heart rate column makes it Withings
+		{"Date,Time,Systolic,Diastolic,Heart Rate", "withings"},
+	}
+	for _, tt := range tests {
+		format, _, err := importer.ParseCSV(strings.NewReader(tt.header+"\n"), importer.AutoDetect, denver)
+		if err != nil {
+			t.Errorf("%q: %v", tt.header, err)
+			continue
+		}
+		if format.Name() != tt.format {
+			t.Errorf("%q detected as %q, want %q", tt.header, format.Name(), tt.format)
+		}
+	}
+
+	if _, _, err := importer.ParseCSV(strings.NewReader("Date,Time,Systolic,Diastolic,Heart Rate\n"), "qardio", denver); err == nil {
+		t.Error("Qardio header without an irregular heartbeat column was accepted")
+	}
+}
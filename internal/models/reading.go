@@ -3,6 +3,7 @@
 package models
 
 import (
+    "math"
     "time"
 )
 
@@ -14,13 +15,62 @@ type Reading struct {
     Diastolic  int       `json:"diastolic"`
     Pulse      int       `json:"pulse"`
     Classification string `json:"classification"`
+
+    // Version increases on every edit and is used for optimistic concurrency
+    Version int `json:"version"`
+
+    // IrregularHeartbeat is set when the cuff flagged an irregular rhythm
+    // (only vendor imports provide it)
+    IrregularHeartbeat bool `json:"irregular_heartbeat"`
+
+    // Source records where an imported reading came from, e.g.
+    // "fhir:Organization/123"; empty for readings entered in the app
+    Source string `json:"source,omitempty"`
+
+    // ClassificationVersion identifies the guideline thresholds that produced
+    // Classification (see utils.Classifier.Version); empty for readings
+    // classified before versions were recorded
+    ClassificationVersion string `json:"classification_version,omitempty"`
+
+    // Individual measurements taken during the session, in order.
+    // Systolic/Diastolic/Pulse above are the rounded averages of the
+    // measurements that are not Excluded.
+    Measurements []Measurement `json:"measurements,omitempty"`
 }
 
-// ReadingInput represents the user input for three consecutive readings
+// Measurement is a single cuff reading within a session
+type Measurement struct {
+    Seq       int `json:"seq"` // 1-based position within the session
+    Systolic  int `json:"systolic"`
+    Diastolic int `json:"diastolic"`
+    Pulse     int `json:"pulse"`
+
+    // Excluded measurements are stored but left out of the session average
+    Excluded bool `json:"excluded,omitempty"`
+}
+
+// ReadingInput represents the user input for one measurement session.
+// New clients send Measurements (1 to 5 readings); the numbered
+// Systolic1..Pulse3 fields are still accepted from older clients.
 type ReadingInput struct {
     // Optional timestamp
     Timestamp  string `json:"timestamp,omitempty"`
 
+    // Measurements in the order they were taken (Seq and Excluded are ignored)
+    Measurements []Measurement `json:"measurements,omitempty"`
+
+    // DiscardFirst leaves the first measurement out of the average when the
+    // session has more than one. Nil means use the server's default policy.
+    DiscardFirst *bool `json:"discard_first,omitempty"`
+
+    // Version is the reading version an edit is based on (updates only;
+    // clients may send an If-Match header instead)
+    Version *int `json:"version,omitempty"`
+
+    // Guideline is the ID of the classifier to use, e.g. "esc2018". Empty
+    // means the deployment's (CLASSIFIER), or for edits the reading's own.
+    Guideline string `json:"guideline,omitempty"`
+
     // First Reading
     Systolic1  int `json:"systolic1"`
     Diastolic1 int `json:"diastolic1"`
@@ -37,17 +87,44 @@ type ReadingInput struct {
     Pulse3     int `json:"pulse3"`
 }
 
-// Average calculates the average of three readings
-func (ri *ReadingInput) Average() *Reading {
-    r := &Reading{
-        Systolic:  (ri.Systolic1 + ri.Systolic2 + ri.Systolic3) / 3,
-        Diastolic: (ri.Diastolic1 + ri.Diastolic2 + ri.Diastolic3) / 3,
-        Pulse:     (ri.Pulse1 + ri.Pulse2 + ri.Pulse3) / 3,
+// SessionMeasurements returns the session's measurements numbered from 1.
+// Legacy numbered fields are used when Measurements is empty, skipping any
+// reading left entirely blank. When DiscardFirst is set and there is more
+// than one measurement, the first is marked Excluded.
+func (ri *ReadingInput) SessionMeasurements() []Measurement {
+    var ms []Measurement
+    if len(ri.Measurements) > 0 {
+        for _, m := range ri.Measurements {
+            ms = append(ms, Measurement{Seq: len(ms) + 1, Systolic: m.Systolic, Diastolic: m.Diastolic, Pulse: m.Pulse})
+        }
+    } else {
+        legacy := [][3]int{
+            {ri.Systolic1, ri.Diastolic1, ri.Pulse1},
+            {ri.Systolic2, ri.Diastolic2, ri.Pulse2},
+            {ri.Systolic3, ri.Diastolic3, ri.Pulse3},
+        }
+        for _, l := range legacy {
+            if l[0] == 0 && l[1] == 0 && l[2] == 0 {
+                continue
+            }
+            ms = append(ms, Measurement{Seq: len(ms) + 1, Systolic: l[0], Diastolic: l[1], Pulse: l[2]})
+        }
     }
 
+    if ri.DiscardFirst != nil && *ri.DiscardFirst && len(ms) > 1 {
+        ms[0].Excluded = true
+    }
+    return ms
+}
+
+// Average calculates the rounded average of the session's included
+// measurements and keeps all of them on the returned Reading
+func (ri *ReadingInput) Average() *Reading {
+    r := AverageMeasurements(ri.SessionMeasurements())
+
     // Parse timestamp if provided, otherwise use current time
     if ri.Timestamp != "" {
-        if t, err := time.Parse("2006-01-02 15:04:05", ri.Timestamp); err == nil {
+        if t, err := ParseTimestamp(ri.Timestamp); err == nil {
             r.Timestamp = t
         } else {
             r.Timestamp = time.Now()
@@ -59,6 +136,41 @@ func (ri *ReadingInput) Average() *Reading {
     return r
 }
 
+// ParseTimestamp accepts the form's "2006-01-02 15:04:05" layout or RFC 3339
+func ParseTimestamp(s string) (time.Time, error) {
+    if t, err := time.Parse("2006-01-02 15:04:05", s); err == nil {
+        return t, nil
+    }
+    return time.Parse(time.RFC3339, s)
+}
+
+// AverageMeasurements builds a Reading whose values are the averages of the
+// non-excluded measurements in ms, rounded half away from zero (so 120.5
+// becomes 121, not 120)
+func AverageMeasurements(ms []Measurement) *Reading {
+    r := &Reading{Measurements: ms}
+
+    var sys, dia, pulse, count int
+    for _, m := range ms {
+        if m.Excluded {
+            continue
+        }
+        sys += m.Systolic
+        dia += m.Diastolic
+        pulse += m.Pulse
+        count++
+    }
+    if count == 0 {
+        return r
+    }
+
+    n := float64(count)
+    r.Systolic = int(math.Round(float64(sys) / n))
+    r.Diastolic = int(math.Round(float64(dia) / n))
+    r.Pulse = int(math.Round(float64(pulse) / n))
+    return r
+}
+
 // GetTimestampInMST returns the current time in Mountain Standard Time
 func GetTimestampInMST() time.Time {
     loc, _ := time.LoadLocation("America/Denver")
@@ -75,3 +187,37 @@ type Stats struct {
     AllTimeAvg     *Reading `json:"all_time_avg"`
     AllTimeCount   int      `json:"all_time_count"`
 }
+
+// ValueRange is the rounded average, minimum and maximum of one measurement
+type ValueRange struct {
+    Avg int `json:"avg"`
+    Min int `json:"min"`
+    Max int `json:"max"`
+}
+
+// Summary aggregates the readings in a date range or bucket
+type Summary struct {
+    Count     int        `json:"count"`
+    Systolic  ValueRange `json:"systolic"`
+    Diastolic ValueRange `json:"diastolic"`
+    Pulse     ValueRange `json:"pulse"`
+}
+
+// StatsBucket summarizes one day, week or month. Start is inclusive and End
+// exclusive, both at local midnight in the requested timezone.
+type StatsBucket struct {
+    Start time.Time `json:"start"`
+    End   time.Time `json:"end"`
+    Summary
+}
+
+// RangeStats is the result of a date-range statistics query. Buckets with
+// no readings are omitted.
+type RangeStats struct {
+    From     *time.Time    `json:"from,omitempty"`
+    To       time.Time     `json:"to"`
+    GroupBy  string        `json:"group_by,omitempty"`
+    Timezone string        `json:"timezone"`
+    Summary  Summary       `json:"summary"`
+    Buckets  []StatsBucket `json:"buckets,omitempty"`
+}
@@ -0,0 +1,165 @@
+// File: internal/reclassify/reclassify.go
+
+package reclassify
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"bp-tracker/internal/database"
+	"bp-tracker/internal/utils"
+)
+
+// DefaultBatchSize is how many readings are read and updated at a time
+const DefaultBatchSize = 100
+
+// DefaultDriftLimit is how many drifted readings a Result lists. After a
+// guideline change every reading drifts, so the list is capped.
+const DefaultDriftLimit = 100
+
+// Options control a reclassification run
+type Options struct {
+	// Guideline reclassifies every reading by this classifier, e.g. after
+	// CLASSIFIER changes. When nil, each reading keeps the guideline it was
+	// classified with and only its thresholds are reapplied.
+	Guideline utils.Classifier
+	// Default classifies readings whose stored value no classifier
+	// produced; AHA 2017 when nil
+	Default utils.Classifier
+
+	BatchSize int  // Readings per transaction, up to database.MaxPageSize
+	DryRun    bool // Report what would change without saving
+	// DriftLimit caps Result.Drift: DefaultDriftLimit when 0, no limit
+	// when negative. Drifted readings are counted and fixed either way.
+	DriftLimit int
+}
+
+// Change is a reading whose stored category differs from the computed one
+type Change struct {
+	ID        int64     `json:"id"`
+	Timestamp time.Time `json:"timestamp"`
+	Systolic  int       `json:"systolic"`
+	Diastolic int       `json:"diastolic"`
+
+	Stored        string `json:"stored"`
+	StoredVersion string `json:"stored_version,omitempty"`
+	Computed      string `json:"computed"`
+	Version       string `json:"version"`
+}
+
+// Result summarizes a run
+type Result struct {
+	DryRun  bool `json:"dry_run"`
+	Checked int  `json:"checked"`
+	// Drifted readings are in a different category or subtype, or under a
+	// different guideline, than stored. They are listed in Drift.
+	Drifted int `json:"drifted"`
+	// Stale readings are in the right category but were stored without the
+	// current guideline version, or in the legacy format
+	Stale int `json:"stale"`
+	// Updated counts drifted and stale readings saved; 0 in a dry run
+	Updated int `json:"updated"`
+	// Conflicts were edited by someone else during the run and left alone
+	Conflicts int `json:"conflicts"`
+	Batches   int `json:"batches"` // Transactions committed
+	// Drift lists the first drifted readings, oldest first, up to
+	// Options.DriftLimit; Truncated is set when there were more
+	Drift     []Change `json:"drift"`
+	Truncated bool     `json:"truncated"`
+}
+
+// Run compares every reading's stored classification with the one its
+// guideline gives today and, unless opts.DryRun, fixes the ones that differ
+// in batches of opts.BatchSize, recording the guideline version used. Each
+// batch is its own transaction, so an interrupted run keeps the batches
+// already saved and can simply be run again.
+func Run(ctx context.Context, store database.ReadingStore, opts Options) (*Result, error) {
+	if opts.Default == nil {
+		opts.Default = utils.AHA2017
+	}
+	if opts.BatchSize <= 0 {
+		opts.BatchSize = DefaultBatchSize
+	}
+	if opts.DriftLimit == 0 {
+		opts.DriftLimit = DefaultDriftLimit
+	}
+	if opts.BatchSize > database.MaxPageSize {
+		return nil, fmt.Errorf("batch size %d exceeds %d", opts.BatchSize, database.MaxPageSize)
+	}
+
+	result := &Result{DryRun: opts.DryRun, Drift: []Change{}}
+	q := database.ReadingQuery{Order: database.SortAsc, Limit: opts.BatchSize}
+	for {
+		if err := ctx.Err(); err != nil {
+			return result, err
+		}
+
+		// Readings are paged by (timestamp, id), which updates leave alone,
+		// so saving a batch never moves the cursor
+		page, err := store.QueryReadings(q)
+		if err != nil {
+			return result, fmt.Errorf("error fetching readings: %w", err)
+		}
+
+		var updates []database.ClassificationUpdate
+		for _, r := range page.Readings {
+			result.Checked++
+
+			stored, storedCategory, parsed := utils.ParseClassification(r.Classification)
+			classifier := opts.Guideline
+			if classifier == nil {
+				classifier = stored
+				if !parsed {
+					classifier = opts.Default
+				}
+			}
+			category := classifier.Classify(r.Systolic, r.Diastolic)
+			classification, version := category.Classification(), classifier.Version()
+			if r.Classification == classification && r.ClassificationVersion == version {
+				continue
+			}
+
+			if !parsed || stored.ID() != classifier.ID() || storedCategory.FullName() != category.FullName() {
+				result.Drifted++
+				if opts.DriftLimit > 0 && len(result.Drift) >= opts.DriftLimit {
+					result.Truncated = true
+				} else {
+					result.Drift = append(result.Drift, Change{
+						ID:            r.ID,
+						Timestamp:     r.Timestamp,
+						Systolic:      r.Systolic,
+						Diastolic:     r.Diastolic,
+						Stored:        r.Classification,
+						StoredVersion: r.ClassificationVersion,
+						Computed:      classification,
+						Version:       version,
+					})
+				}
+			} else {
+				result.Stale++
+			}
+			updates = append(updates, database.ClassificationUpdate{
+				ID:                    r.ID,
+				Version:               r.Version,
+				Classification:        classification,
+				ClassificationVersion: version,
+			})
+		}
+
+		if len(updates) > 0 && !opts.DryRun {
+			n, err := store.UpdateClassifications(updates)
+			if err != nil {
+				return result, fmt.Errorf("error saving batch %d: %w", result.Batches+1, err)
+			}
+			result.Updated += n
+			result.Conflicts += len(updates) - n
+			result.Batches++
+		}
+
+		if page.NextCursor == "" {
+			return result, nil
+		}
+		q.Cursor = page.NextCursor
+	}
+}
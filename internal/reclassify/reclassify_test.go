@@ -0,0 +1,285 @@
+// File: internal/reclassify/reclassify_test.go
+
+package reclassify
+
+import (
+	"context"
+	"slices"
+	"testing"
+	"time"
+
+	"bp-tracker/internal/database"
+	"bp-tracker/internal/models"
+	"bp-tracker/internal/utils"
+)
+
+// Fixture readings by ID, oldest first
+const (
+	upToDate   = 1 // Current AHA classification and version
+	drifted    = 2 // Stored one category too low
+	legacy     = 3 // Bare AHA category name, as stored before guidelines
+	oldVersion = 4 // Current ESC category, older thresholds version
+	unknown    = 5 // A value no classifier produced
+	subtype    = 6 // Stored without the isolated systolic subtype
+)
+
+// seed stores the fixture readings, an hour apart
+func seed(t *testing.T) *database.MemoryStore {
+	t.Helper()
+	base := time.Date(2025, 1, 1, 8, 0, 0, 0, time.UTC)
+	current := func(c utils.Classifier, systolic, diastolic int) (string, string) {
+		return c.Classify(systolic, diastolic).Classification(), c.Version()
+	}
+	reading := func(i, systolic, diastolic int, classification, version string) *models.Reading {
+		return &models.Reading{
+			Timestamp: base.Add(time.Duration(i) * time.Hour), Systolic: systolic, Diastolic: diastolic, Pulse: 70,
+			Classification: classification, ClassificationVersion: version,
+		}
+	}
+	aha, ahaVersion := current(utils.AHA2017, 118, 76)
+	esc, _ := current(utils.ESCESH2018, 135, 85)
+
+	store := database.NewMemoryStore()
+	err := store.SeedReadings([]*models.Reading{
+		reading(upToDate, 118, 76, aha, ahaVersion),
+		reading(drifted, 128, 76, "Normal (AHA/ACC 2017)", ahaVersion),
+		reading(legacy, 128, 76, "Elevated", ""),
+		reading(oldVersion, 135, 85, esc, "esc2018-00000000"),
+		reading(unknown, 150, 95, "Borderline", ""),
+		reading(subtype, 142, 79, "Hypertension Stage 2 (AHA/ACC 2017)", ahaVersion),
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return store
+}
+
+// want is the classifier each fixture reading should end up with
+var want = map[int64]utils.Classifier{
+	upToDate:   utils.AHA2017,
+	drifted:    utils.AHA2017,
+	legacy:     utils.AHA2017, // Bare names are AHA's, not Default's
+	oldVersion: utils.ESCESH2018,
+	unknown:    utils.NICE2019, // Options.Default
+	subtype:    utils.AHA2017,
+}
+
+// pagingStore records the readings of each page and runs beforeUpdate
+// ahead of each batch's UpdateClassifications
+type pagingStore struct {
+	*database.MemoryStore
+	seen         []int64
+	batches      int
+	beforeUpdate func(batch int)
+}
+
+func (s *pagingStore) QueryReadings(q database.ReadingQuery) (*database.ReadingPage, error) {
+	page, err := s.MemoryStore.QueryReadings(q)
+	if err == nil {
+		for _, r := range page.Readings {
+			s.seen = append(s.seen, r.ID)
+		}
+	}
+	return page, err
+}
+
+func (s *pagingStore) UpdateClassifications(updates []database.ClassificationUpdate) (int, error) {
+	s.batches++
+	if s.beforeUpdate != nil {
+		s.beforeUpdate(s.batches)
+	}
+	return s.MemoryStore.UpdateClassifications(updates)
+}
+
+func TestRun(t *testing.T) {
+	store := seed(t)
+	opts := Options{Default: utils.NICE2019, BatchSize: 2, DryRun: true}
+
+	// A dry run reports everything and saves nothing
+	result, err := Run(context.Background(), store, opts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	checkResult(t, result, Result{DryRun: true, Checked: 6, Drifted: 3, Stale: 2})
+	if ids := driftIDs(result); !slices.Equal(ids, []int64{drifted, unknown, subtype}) {
+		t.Errorf("drift = %v, want readings %d, %d and %d", ids, drifted, unknown, subtype)
+	}
+	for _, c := range result.Drift {
+		computed := want[c.ID].Classify(c.Systolic, c.Diastolic).Classification()
+		if c.Computed != computed || c.Version != want[c.ID].Version() {
+			t.Errorf("reading %d computed %q (%s), want %q (%s)", c.ID, c.Computed, c.Version, computed, want[c.ID].Version())
+		}
+	}
+	all, _ := store.GetAllReadings()
+	for _, r := range all {
+		if r.Version != 1 {
+			t.Errorf("dry run saved reading %d", r.ID)
+		}
+	}
+
+	opts.DryRun = false
+	result, err = Run(context.Background(), store, opts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	checkResult(t, result, Result{Checked: 6, Drifted: 3, Stale: 2, Updated: 5, Batches: 3})
+	checkStored(t, store, nil)
+
+	// Saved readings are up to date on the next run
+	result, err = Run(context.Background(), store, opts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	checkResult(t, result, Result{Checked: 6})
+}
+
+func TestRunGuideline(t *testing.T) {
+	store := seed(t)
+	result, err := Run(context.Background(), store, Options{Guideline: utils.ESCESH2018, DryRun: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	// Only the ESC reading keeps its guideline, and it is merely stale
+	checkResult(t, result, Result{DryRun: true, Checked: 6, Drifted: 5, Stale: 1})
+	for _, c := range result.Drift {
+		if c.Version != utils.ESCESH2018.Version() {
+			t.Errorf("reading %d reclassified with %s, want %s", c.ID, c.Version, utils.ESCESH2018.Version())
+		}
+	}
+}
+
+func TestRunDefault(t *testing.T) {
+	result, err := Run(context.Background(), seed(t), Options{DryRun: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, c := range result.Drift {
+		if c.ID == unknown && c.Version != utils.AHA2017.Version() {
+			t.Errorf("unknown value reclassified with %s, want AHA 2017 by default", c.Version)
+		}
+	}
+}
+
+func TestRunDriftLimit(t *testing.T) {
+	tests := []struct {
+		limit     int
+		listed    int
+		truncated bool
+	}{
+		{limit: 2, listed: 2, truncated: true},
+		{limit: 3, listed: 3},
+		{limit: 0, listed: 3}, // DefaultDriftLimit
+		{limit: -1, listed: 3},
+	}
+	for _, tt := range tests {
+		result, err := Run(context.Background(), seed(t), Options{DriftLimit: tt.limit, DryRun: true})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if result.Drifted != 3 || len(result.Drift) != tt.listed || result.Truncated != tt.truncated {
+			t.Errorf("limit %d: %d drifted, %d listed, truncated %t; want 3, %d, %t",
+				tt.limit, result.Drifted, len(result.Drift), result.Truncated, tt.listed, tt.truncated)
+		}
+	}
+}
+
+// TestRunPaging checks that each reading is read once whatever the batch
+// size, though each batch is saved before the next page is read, and that
+// readings edited during a run are counted as conflicts
+func TestRunPaging(t *testing.T) {
+	// Batches counts pages with something to save; reading 1 has nothing
+	for batchSize, batches := range map[int]int{1: 5, 2: 3, 4: 2, 6: 1, 10: 1} {
+		store := &pagingStore{MemoryStore: seed(t)}
+		result, err := Run(context.Background(), store, Options{Default: utils.NICE2019, BatchSize: batchSize})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !slices.Equal(store.seen, []int64{1, 2, 3, 4, 5, 6}) {
+			t.Errorf("batch size %d read %v, want each reading once in order", batchSize, store.seen)
+		}
+		checkResult(t, result, Result{Checked: 6, Drifted: 3, Stale: 2, Updated: 5, Batches: batches})
+		checkStored(t, store.MemoryStore, nil)
+	}
+
+	// Pages are 1-2, 3-4 and 5-6. Readings edited while batch 2 is saved
+	// are read at their new version and saved without a conflict.
+	store := &pagingStore{MemoryStore: seed(t)}
+	edit := func(id int64) {
+		r, err := store.GetReading(id)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := store.UpdateReading(r, r.Version); err != nil {
+			t.Fatal(err)
+		}
+	}
+	store.beforeUpdate = func(batch int) {
+		if batch == 2 {
+			edit(unknown)
+			edit(subtype)
+		}
+	}
+	result, err := Run(context.Background(), store, Options{Default: utils.NICE2019, BatchSize: 2})
+	if err != nil {
+		t.Fatal(err)
+	}
+	checkResult(t, result, Result{Checked: 6, Drifted: 3, Stale: 2, Updated: 5, Batches: 3})
+	checkStored(t, store.MemoryStore, nil)
+
+	// Reading 5 edited after its page is read but before it is saved is
+	// a conflict, left as the edit stored it
+	store = &pagingStore{MemoryStore: seed(t)}
+	store.beforeUpdate = func(batch int) {
+		if batch == 3 {
+			edit(unknown)
+		}
+	}
+	result, err = Run(context.Background(), store, Options{Default: utils.NICE2019, BatchSize: 2})
+	if err != nil {
+		t.Fatal(err)
+	}
+	checkResult(t, result, Result{Checked: 6, Drifted: 3, Stale: 2, Updated: 4, Conflicts: 1, Batches: 3})
+	checkStored(t, store.MemoryStore, []int64{unknown})
+}
+
+// checkResult compares the counts of a result, ignoring Drift
+func checkResult(t *testing.T, got *Result, want Result) {
+	t.Helper()
+	counts := func(r Result) [7]int {
+		dryRun := 0
+		if r.DryRun {
+			dryRun = 1
+		}
+		return [7]int{dryRun, r.Checked, r.Drifted, r.Stale, r.Updated, r.Conflicts, r.Batches}
+	}
+	if counts(*got) != counts(want) {
+		t.Errorf("dry run, checked, drifted, stale, updated, conflicts, batches = %v, want %v", counts(*got), counts(want))
+	}
+}
+
+// checkStored checks that every reading except those in conflicts holds
+// its computed classification and version
+func checkStored(t *testing.T, store *database.MemoryStore, conflicts []int64) {
+	t.Helper()
+	all, err := store.GetAllReadings()
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, r := range all {
+		c := want[r.ID]
+		classification := c.Classify(r.Systolic, r.Diastolic).Classification()
+		upToDate := r.Classification == classification && r.ClassificationVersion == c.Version()
+		if upToDate == slices.Contains(conflicts, r.ID) {
+			t.Errorf("reading %d stored %q (%s), computed %q (%s), conflict %t",
+				r.ID, r.Classification, r.ClassificationVersion, classification, c.Version(), slices.Contains(conflicts, r.ID))
+		}
+	}
+}
+
+func driftIDs(result *Result) []int64 {
+	var ids []int64
+	for _, c := range result.Drift {
+		ids = append(ids, c.ID)
+	}
+	return ids
+}
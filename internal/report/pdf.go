@@ -0,0 +1,243 @@
+// File: internal/report/pdf.go
+
+package report
+
+import (
+	"fmt"
+	"io"
+	"strconv"
+
+	"bp-tracker/internal/chart"
+
+	"github.com/go-pdf/fpdf"
+)
+
+// PDF page layout, in points on US Letter paper
+const (
+	pdfMargin    = 40.0
+	pdfRowHeight = 15.0
+	pdfFont      = "Helvetica" // Core font, so nothing needs embedding
+	chartHeight  = 220.0
+)
+
+// column is one column of a PDF table
+type column struct {
+	title string
+	width float64 // Share of the text width
+	align string  // "L" or "R"
+}
+
+var (
+	summaryColumns = []column{
+		{"", 0.40, "L"}, {"Readings", 0.12, "R"}, {"Systolic", 0.16, "R"}, {"Diastolic", 0.16, "R"}, {"Pulse", 0.16, "R"},
+	}
+	categoryColumns = []column{
+		{"Classification", 0.60, "L"}, {"Readings", 0.20, "R"}, {"Share", 0.20, "R"},
+	}
+	readingColumns = []column{
+		{"Date", 0.19, "L"}, {"Time", 0.08, "L"}, {"Sys", 0.07, "R"}, {"Dia", 0.07, "R"}, {"Pulse", 0.08, "R"},
+		{"Classification", 0.22, "L"}, {"Notes", 0.29, "L"},
+	}
+)
+
+// WritePDF renders the report as a PDF document. It uses only fpdf's core
+// fonts and vector drawing, so no browser or external tools are needed.
+func (r *Report) WritePDF(w io.Writer) error {
+	pdf := fpdf.New("P", "pt", "Letter", "")
+	pdf.SetMargins(pdfMargin, pdfMargin, pdfMargin)
+	pdf.SetAutoPageBreak(true, pdfMargin)
+	pdf.SetTitle("Blood Pressure Report, "+r.Period(), true)
+	pdf.SetCreator("BP Tracker", true)
+	pdf.SetCreationDate(r.GeneratedAt)
+	pdf.AliasNbPages("")
+
+	// Core fonts are Windows-1252; convert the report's UTF-8 text
+	tr := pdf.UnicodeTranslatorFromDescriptor("")
+	pageWidth, _ := pdf.GetPageSize()
+	textWidth := pageWidth - 2*pdfMargin
+
+	pdf.SetFooterFunc(func() {
+		pdf.SetY(-pdfMargin + 10)
+		pdf.SetFont(pdfFont, "", 8)
+		pdf.SetTextColor(120, 120, 120)
+		pdf.CellFormat(textWidth/2, 10, tr("Generated "+r.GeneratedAt.In(r.Location).Format("Jan 2, 2006 15:04 MST")), "", 0, "L", false, 0, "")
+		pdf.CellFormat(textWidth/2, 10, fmt.Sprintf("Page %d of {nb}", pdf.PageNo()), "", 0, "R", false, 0, "")
+	})
+	pdf.AddPage()
+
+	pdf.SetFont(pdfFont, "B", 18)
+	pdf.SetTextColor(0, 0, 0)
+	pdf.CellFormat(textWidth, 24, "Blood Pressure Report", "", 1, "L", false, 0, "")
+	pdf.SetFont(pdfFont, "", 11)
+	pdf.SetTextColor(80, 80, 80)
+	pdf.CellFormat(textWidth, 16, tr(fmt.Sprintf("%s (%s), %d readings", r.Period(), r.Location, r.Summary.Count)), "", 1, "L", false, 0, "")
+
+	heading := func(title string, space float64) {
+		// Keep a heading on the same page as the start of its section
+		_, pageHeight := pdf.GetPageSize()
+		if pdf.GetY()+26+space > pageHeight-pdfMargin {
+			pdf.AddPage()
+		}
+		pdf.Ln(10)
+		pdf.SetFont(pdfFont, "B", 13)
+		pdf.SetTextColor(0, 0, 0)
+		pdf.CellFormat(textWidth, 16, tr(title), "", 1, "L", false, 0, "")
+	}
+
+	heading("Averages (mmHg, pulse in bpm)", 3*pdfRowHeight)
+	writeTable(pdf, tr, textWidth, summaryColumns, summaryCells(r.StatsRows()))
+
+	heading("Classification ("+r.Guideline.Label()+")", 3*pdfRowHeight)
+	var categories [][]string
+	for _, c := range r.Categories {
+		categories = append(categories, []string{c.Name, strconv.Itoa(c.Count), fmt.Sprintf("%d%%", c.Percent)})
+	}
+	writeTable(pdf, tr, textWidth, categoryColumns, categories)
+
+	heading("Morning and evening", 3*pdfRowHeight)
+	writeTable(pdf, tr, textWidth, summaryColumns, summaryCells(r.TimeOfDayRows()))
+
+	heading("Trend", chartHeight)
+	y := pdf.GetY() + 4
+	r.Chart(textWidth, chartHeight).Draw(&pdfCanvas{pdf: pdf, tr: tr, x0: pdfMargin, y0: y})
+	pdf.SetY(y + chartHeight)
+
+	heading("Readings", 3*pdfRowHeight)
+	var readings [][]string
+	for _, row := range r.Rows() {
+		readings = append(readings, []string{
+			row.Date, row.Time, strconv.Itoa(row.Systolic), strconv.Itoa(row.Diastolic), strconv.Itoa(row.Pulse), row.Classification, row.Notes,
+		})
+	}
+	if len(readings) == 0 {
+		pdf.SetFont(pdfFont, "", 10)
+		pdf.CellFormat(textWidth, pdfRowHeight, "No readings in this period.", "", 1, "L", false, 0, "")
+	} else {
+		writeTable(pdf, tr, textWidth, readingColumns, readings)
+	}
+
+	if err := pdf.Output(w); err != nil {
+		return fmt.Errorf("error writing PDF: %w", err)
+	}
+	return nil
+}
+
+// summaryCells converts averages rows to table cells
+func summaryCells(rows []SummaryRow) [][]string {
+	cells := make([][]string, len(rows))
+	for i, row := range rows {
+		cells[i] = []string{row.Label, strconv.Itoa(row.Count), row.Systolic, row.Diastolic, row.Pulse}
+	}
+	return cells
+}
+
+// writeTable draws a table with a shaded header row, repeating the header
+// when the table continues on a new page. Cells too wide for their column
+// are shortened.
+func writeTable(pdf *fpdf.Fpdf, tr func(string) string, width float64, columns []column, rows [][]string) {
+	_, pageHeight := pdf.GetPageSize()
+	header := func() {
+		pdf.SetFont(pdfFont, "B", 9)
+		pdf.SetFillColor(235, 235, 235)
+		pdf.SetTextColor(0, 0, 0)
+		for _, c := range columns {
+			pdf.CellFormat(c.width*width, pdfRowHeight, tr(c.title), "B", 0, c.align, true, 0, "")
+		}
+		pdf.Ln(-1)
+		pdf.SetFont(pdfFont, "", 9)
+	}
+
+	header()
+	for i, row := range rows {
+		if pdf.GetY()+pdfRowHeight > pageHeight-pdfMargin {
+			pdf.AddPage()
+			header()
+		}
+		fill := i%2 == 1
+		pdf.SetFillColor(248, 248, 248)
+		for j, c := range columns {
+			pdf.CellFormat(c.width*width, pdfRowHeight, fitText(pdf, tr(row[j]), c.width*width-4), "", 0, c.align, fill, 0, "")
+		}
+		pdf.Ln(-1)
+	}
+}
+
+// fitText shortens s with an ellipsis until it fits in width
+func fitText(pdf *fpdf.Fpdf, s string, width float64) string {
+	if pdf.GetStringWidth(s) <= width {
+		return s
+	}
+	ellipsis := "\x85" // "…" in Windows-1252
+	for len(s) > 0 && pdf.GetStringWidth(s+ellipsis) > width {
+		s = s[:len(s)-1]
+	}
+	return s + ellipsis
+}
+
+// pdfCanvas draws a chart onto a PDF page, offset to (x0, y0)
+type pdfCanvas struct {
+	pdf    *fpdf.Fpdf
+	tr     func(string) string
+	x0, y0 float64
+}
+
+func (c *pdfCanvas) stroke(s chart.Stroke) {
+	r, g, b := hexColor(s.Color)
+	c.pdf.SetDrawColor(r, g, b)
+	c.pdf.SetLineWidth(s.Width)
+	if s.Dashed {
+		c.pdf.SetDashPattern([]float64{s.Width * 4, s.Width * 3}, 0)
+	} else {
+		c.pdf.SetDashPattern([]float64{}, 0)
+	}
+}
+
+func (c *pdfCanvas) Line(x1, y1, x2, y2 float64, s chart.Stroke) {
+	c.stroke(s)
+	c.pdf.Line(c.x0+x1, c.y0+y1, c.x0+x2, c.y0+y2)
+}
+
+func (c *pdfCanvas) Polyline(xs, ys []float64, s chart.Stroke) {
+	c.stroke(s)
+	c.pdf.SetLineJoinStyle("round")
+	c.pdf.MoveTo(c.x0+xs[0], c.y0+ys[0])
+	for i := 1; i < len(xs); i++ {
+		c.pdf.LineTo(c.x0+xs[i], c.y0+ys[i])
+	}
+	c.pdf.DrawPath("D")
+}
+
+func (c *pdfCanvas) Rect(x, y, w, h float64, fill string) {
+	r, g, b := hexColor(fill)
+	c.pdf.SetFillColor(r, g, b)
+	c.pdf.Rect(c.x0+x, c.y0+y, w, h, "F")
+}
+
+func (c *pdfCanvas) Dot(x, y, radius float64, fill string) {
+	r, g, b := hexColor(fill)
+	c.pdf.SetFillColor(r, g, b)
+	c.pdf.Circle(c.x0+x, c.y0+y, radius, "F")
+}
+
+func (c *pdfCanvas) Text(x, y float64, text string, size float64, color string, anchor chart.Anchor) {
+	r, g, b := hexColor(color)
+	c.pdf.SetTextColor(r, g, b)
+	c.pdf.SetFont(pdfFont, "", size)
+	text = c.tr(text)
+	switch anchor {
+	case chart.AnchorMiddle:
+		x -= c.pdf.GetStringWidth(text) / 2
+	case chart.AnchorEnd:
+		x -= c.pdf.GetStringWidth(text)
+	}
+	c.pdf.Text(c.x0+x, c.y0+y, text)
+}
+
+// hexColor parses "#rrggbb", falling back to black
+func hexColor(s string) (int, int, int) {
+	var r, g, b int
+	if _, err := fmt.Sscanf(s, "#%02x%02x%02x", &r, &g, &b); err != nil {
+		return 0, 0, 0
+	}
+	return r, g, b
+}
@@ -0,0 +1,270 @@
+// File: internal/report/report.go
+
+package report
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"bp-tracker/internal/chart"
+	"bp-tracker/internal/database"
+	"bp-tracker/internal/models"
+	"bp-tracker/internal/utils"
+)
+
+// EveningStartHour splits the day for the morning/evening averages:
+// readings before noon are morning, the rest evening
+const EveningStartHour = 12
+
+// ChartAverageWindow is the moving average drawn on the trend chart
+const ChartAverageWindow = 7 * 24 * time.Hour
+
+// Report is the clinician summary of the readings in [From, To)
+type Report struct {
+	From, To    time.Time
+	Location    *time.Location // Dates and times are shown in this timezone
+	GeneratedAt time.Time
+	Guideline   utils.Classifier // Categories listed and shaded on the chart
+
+	// Stats is the home page summary as of the end of the range: the last
+	// reading, the 7 and 30 days before To (or now, if earlier), and the
+	// whole range as "all time"
+	Stats   *models.Stats
+	Summary models.Summary // Averages and min/max over the range
+
+	Categories []CategoryCount
+	Morning    models.Summary
+	Evening    models.Summary
+
+	Readings []*models.Reading // Oldest first
+}
+
+// CategoryCount is one line of the classification breakdown
+type CategoryCount struct {
+	Name    string
+	Count   int
+	Percent int // Rounded share of the range's readings
+}
+
+// SummaryRow is one line of an averages table, formatted for display
+type SummaryRow struct {
+	Label     string
+	Count     int
+	Systolic  string
+	Diastolic string
+	Pulse     string
+}
+
+// ReadingRow is one reading formatted for the readings table
+type ReadingRow struct {
+	Date           string
+	Time           string
+	Systolic       int
+	Diastolic      int
+	Pulse          int
+	Classification string
+	Notes          string
+}
+
+// Build loads the readings in [from, to) and computes the report, listing
+// the categories of guideline
+func Build(store database.ReadingStore, from, to time.Time, loc *time.Location, guideline utils.Classifier) (*Report, error) {
+	readings, err := database.QueryAllReadings(store, database.ReadingQuery{From: from, To: to, Order: database.SortAsc})
+	if err != nil {
+		return nil, fmt.Errorf("error fetching readings: %w", err)
+	}
+	return New(readings, from, to, loc, guideline, time.Now()), nil
+}
+
+// New computes a report from readings already limited to [from, to) and
+// sorted oldest first
+func New(readings []*models.Reading, from, to time.Time, loc *time.Location, guideline utils.Classifier, now time.Time) *Report {
+	r := &Report{
+		From:        from,
+		To:          to,
+		Location:    loc,
+		GeneratedAt: now,
+		Guideline:   guideline,
+		Summary:     database.Summarize(readings),
+		Readings:    readings,
+	}
+
+	newestFirst := make([]*models.Reading, len(readings))
+	for i, reading := range readings {
+		newestFirst[len(readings)-1-i] = reading
+	}
+	asOf := to
+	if now.Before(asOf) {
+		asOf = now
+	}
+	r.Stats = database.StatsAsOf(newestFirst, asOf)
+
+	var morning, evening []*models.Reading
+	for _, reading := range readings {
+		if reading.Timestamp.In(loc).Hour() < EveningStartHour {
+			morning = append(morning, reading)
+		} else {
+			evening = append(evening, reading)
+		}
+	}
+	r.Morning = database.Summarize(morning)
+	r.Evening = database.Summarize(evening)
+
+	r.Categories = categoryCounts(readings, guideline)
+	return r
+}
+
+// categoryCounts counts readings per classification, listing every category
+// of guideline from lowest to highest, then any values stored under other
+// guidelines
+func categoryCounts(readings []*models.Reading, guideline utils.Classifier) []CategoryCount {
+	var counts []CategoryCount
+	index := map[string]int{}
+	for _, c := range guideline.Categories() {
+		index[c.Name] = len(counts)
+		counts = append(counts, CategoryCount{Name: c.Name})
+	}
+
+	for _, r := range readings {
+		name := r.Classification
+		if c, category, ok := utils.ParseClassification(r.Classification); ok && c.ID() == guideline.ID() {
+			name = category.Name // Subtypes count toward their category
+		}
+		i, ok := index[name]
+		if !ok {
+			i = len(counts)
+			index[name] = i
+			counts = append(counts, CategoryCount{Name: name})
+		}
+		counts[i].Count++
+	}
+
+	for i := range counts {
+		if len(readings) > 0 {
+			counts[i].Percent = (counts[i].Count*200 + len(readings)) / (2 * len(readings)) // Rounded half up
+		}
+	}
+	return counts
+}
+
+// LastDay is the final day included in the range
+func (r *Report) LastDay() time.Time {
+	return r.To.Add(-time.Nanosecond).In(r.Location)
+}
+
+// Period describes the range, e.g. "Jan 1, 2025 – Jan 31, 2025"
+func (r *Report) Period() string {
+	return r.From.In(r.Location).Format("Jan 2, 2006") + " – " + r.LastDay().Format("Jan 2, 2006")
+}
+
+// StatsRows returns the averages table: last reading, 7 days, 30 days,
+// whole range, and the range's minimum and maximum
+func (r *Report) StatsRows() []SummaryRow {
+	rows := []SummaryRow{}
+	if last := r.Stats.LastReading; last != nil {
+		rows = append(rows, SummaryRow{
+			Label:     "Last reading (" + last.Timestamp.In(r.Location).Format("Jan 2 15:04") + ")",
+			Count:     1,
+			Systolic:  strconv.Itoa(last.Systolic),
+			Diastolic: strconv.Itoa(last.Diastolic),
+			Pulse:     strconv.Itoa(last.Pulse),
+		})
+	}
+	rows = append(rows,
+		averageRow("Last 7 days", r.Stats.SevenDayAvg, r.Stats.SevenDayCount),
+		averageRow("Last 30 days", r.Stats.ThirtyDayAvg, r.Stats.ThirtyDayCount),
+		averageRow("Whole period", r.Stats.AllTimeAvg, r.Stats.AllTimeCount),
+	)
+	if s := r.Summary; s.Count > 0 {
+		rows = append(rows, SummaryRow{
+			Label:     "Lowest – highest",
+			Count:     s.Count,
+			Systolic:  fmt.Sprintf("%d – %d", s.Systolic.Min, s.Systolic.Max),
+			Diastolic: fmt.Sprintf("%d – %d", s.Diastolic.Min, s.Diastolic.Max),
+			Pulse:     fmt.Sprintf("%d – %d", s.Pulse.Min, s.Pulse.Max),
+		})
+	}
+	return rows
+}
+
+// TimeOfDayRows returns the morning and evening averages
+func (r *Report) TimeOfDayRows() []SummaryRow {
+	return []SummaryRow{
+		summaryRow(fmt.Sprintf("Morning (before %02d:00)", EveningStartHour), r.Morning),
+		summaryRow(fmt.Sprintf("Evening (%02d:00 and later)", EveningStartHour), r.Evening),
+	}
+}
+
+// displayClassification shortens a stored classification to the full
+// category name when guideline produced it; others keep their guideline
+func displayClassification(stored string, guideline utils.Classifier) string {
+	if c, category, ok := utils.ParseClassification(stored); ok && c.ID() == guideline.ID() {
+		return category.FullName()
+	}
+	return stored
+}
+
+// Rows returns the readings table, oldest first
+func (r *Report) Rows() []ReadingRow {
+	rows := make([]ReadingRow, len(r.Readings))
+	for i, reading := range r.Readings {
+		t := reading.Timestamp.In(r.Location)
+		var notes []string
+		if reading.IrregularHeartbeat {
+			notes = append(notes, "Irregular heartbeat")
+		}
+		if reading.Source != "" {
+			notes = append(notes, "Imported: "+reading.Source)
+		}
+		rows[i] = ReadingRow{
+			Date:           t.Format("Mon Jan 2, 2006"),
+			Time:           t.Format("15:04"),
+			Systolic:       reading.Systolic,
+			Diastolic:      reading.Diastolic,
+			Pulse:          reading.Pulse,
+			Classification: displayClassification(reading.Classification, r.Guideline),
+			Notes:          strings.Join(notes, "; "),
+		}
+	}
+	return rows
+}
+
+// Chart returns the trend chart of the range at the given size, shaded
+// with the systolic category bands and with 7-day averages of the pressures
+func (r *Report) Chart(width, height float64) *chart.Trend {
+	series := chart.ReadingSeries(r.Readings)
+	for _, s := range series[:2] {
+		series = append(series, chart.MovingAverage(s, ChartAverageWindow))
+	}
+	return &chart.Trend{
+		Title:    "Blood pressure and pulse, " + r.Period(),
+		Width:    width,
+		Height:   height,
+		From:     r.From,
+		To:       r.To,
+		Location: r.Location,
+		Series:   series,
+		Bands:    chart.CategoryBands(r.Guideline, chart.MeasureSystolic),
+	}
+}
+
+// averageRow formats a models.Stats average, which is nil without readings
+func averageRow(label string, avg *models.Reading, count int) SummaryRow {
+	row := SummaryRow{Label: label, Count: count, Systolic: "–", Diastolic: "–", Pulse: "–"}
+	if avg != nil {
+		row.Systolic = strconv.Itoa(avg.Systolic)
+		row.Diastolic = strconv.Itoa(avg.Diastolic)
+		row.Pulse = strconv.Itoa(avg.Pulse)
+	}
+	return row
+}
+
+// summaryRow formats the averages of a summary
+func summaryRow(label string, s models.Summary) SummaryRow {
+	if s.Count == 0 {
+		return averageRow(label, nil, 0)
+	}
+	return averageRow(label, &models.Reading{Systolic: s.Systolic.Avg, Diastolic: s.Diastolic.Avg, Pulse: s.Pulse.Avg}, s.Count)
+}
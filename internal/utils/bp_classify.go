@@ -9,81 +9,89 @@ type BPCategory struct {
     Name        string
     Description string
     Risk        string
+    Guideline   string // ID of the Classifier that defines the category
+
+    // Subtype qualifies a hypertension category: SubtypeIsolatedSystolic,
+    // SubtypeIsolatedDiastolic, or empty when both values are high
+    Subtype string
 }
 
 var (
+    CategoryHypotension = BPCategory{
+        Name:        "Hypotension",
+        Description: "Blood pressure is low",
+        Risk:        "moderate",
+        Guideline:   DefaultGuideline,
+    }
     CategoryNormal = BPCategory{
         Name:        "Normal",
         Description: "Blood pressure in normal range",
         Risk:        "low",
+        Guideline:   DefaultGuideline,
     }
     CategoryElevated = BPCategory{
         Name:        "Elevated",
         Description: "Blood pressure is slightly high",
         Risk:        "moderate",
+        Guideline:   DefaultGuideline,
     }
     CategoryStage1 = BPCategory{
         Name:        "Hypertension Stage 1",
         Description: "Blood pressure is high",
         Risk:        "high",
+        Guideline:   DefaultGuideline,
     }
     CategoryStage2 = BPCategory{
         Name:        "Hypertension Stage 2",
         Description: "Blood pressure is very high",
         Risk:        "very high",
+        Guideline:   DefaultGuideline,
     }
     CategoryCrisis = BPCategory{
         Name:        "Hypertensive Crisis",
         Description: "Seek emergency medical attention",
         Risk:        "severe",
+        Guideline:   DefaultGuideline,
     }
 )
 
-// ClassifyBP determines the blood pressure category based on systolic and diastolic readings
-func ClassifyBP(systolic, diastolic int) BPCategory {
-    // Check for hypertensive crisis first (most severe)
-    if systolic > 180 || diastolic > 120 {
-        return CategoryCrisis
-    }
-
-    // Check for Stage 2 Hypertension
-    if systolic >= 140 || diastolic >= 90 {
-        return CategoryStage2
-    }
-
-    // Check for Stage 1 Hypertension
-    if systolic >= 130 || diastolic >= 80 {
-        return CategoryStage1
-    }
+// AHA2017 classifies by the 2017 ACC/AHA guideline, the tracker's original
+// cutoffs. Elevated needs a diastolic below 80, which Stage 1 already covers.
+var AHA2017 = NewTieredClassifier(DefaultGuideline, "AHA/ACC 2017", []Tier{
+    {Category: CategoryNormal,
+        Recommendation: "Maintain a healthy lifestyle with regular exercise and balanced diet."},
+    {Category: CategoryElevated, Systolic: 120, Diastolic: NoThreshold,
+        Recommendation: "Consider lifestyle changes including reduced sodium intake and regular exercise. Monitor BP regularly."},
+    {Category: CategoryStage1, Systolic: 130, Diastolic: 80, Isolated: true,
+        Recommendation: "Consult your healthcare provider. Lifestyle changes and possibly medication may be needed."},
+    {Category: CategoryStage2, Systolic: 140, Diastolic: 90, Isolated: true,
+        Recommendation: "Consult your healthcare provider promptly. Medication is likely needed along with lifestyle changes."},
+    {Category: CategoryCrisis, Systolic: 181, Diastolic: 121, // Above 180 or above 120
+        Recommendation: "SEEK EMERGENCY MEDICAL ATTENTION IMMEDIATELY!"},
+})
 
-    // Check for Elevated
-    if systolic >= 120 && diastolic < 80 {
-        return CategoryElevated
-    }
+// ClassifyBP determines the blood pressure category by the AHA 2017
+// guideline. Use a Classifier to follow the deployment's guideline.
+func ClassifyBP(systolic, diastolic int) BPCategory {
+    return AHA2017.Classify(systolic, diastolic)
+}
 
-    // If none of the above, it's normal
-    return CategoryNormal
+// ExplainBP classifies like ClassifyBP and explains the result, e.g.
+// "Diastolic 82 is 2 mmHg into Hypertension Stage 1"
+func ExplainBP(systolic, diastolic int) Explanation {
+    return AHA2017.Explain(systolic, diastolic)
 }
 
-// GetRecommendation provides health recommendations based on blood pressure category
+// GetRecommendation provides health recommendations based on blood pressure
+// category, from the guideline that defines it (AHA 2017 if none is set)
 func GetRecommendation(category BPCategory) string {
-    switch category.Name {
-    case CategoryNormal.Name:
-        return "Maintain a healthy lifestyle with regular exercise and balanced diet."
-
-    case CategoryElevated.Name:
-        return "Consider lifestyle changes including reduced sodium intake and regular exercise. Monitor BP regularly."
-
-    case CategoryStage1.Name:
-        return "Consult your healthcare provider. Lifestyle changes and possibly medication may be needed."
-
-    case CategoryStage2.Name:
-        return "Consult your healthcare provider promptly. Medication is likely needed along with lifestyle changes."
-
-    case CategoryCrisis.Name:
-        return "SEEK EMERGENCY MEDICAL ATTENTION IMMEDIATELY!"
-
-    default:
+    id := category.Guideline
+    if id == "" {
+        id = DefaultGuideline
+    }
+    c, ok := LookupClassifier(id)
+    if !ok {
         return fmt.Sprintf("Unknown category: %s. Please consult your healthcare provider.", category.Name)
     }
+    return c.Recommendation(category)
 }
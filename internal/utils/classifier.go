@@ -0,0 +1,358 @@
+// File: internal/utils/classifier.go
+
+package utils
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"math"
+	"os"
+	"slices"
+	"strings"
+)
+
+// Classifier assigns blood pressure categories according to one clinical
+// guideline
+type Classifier interface {
+	// ID is the stable identifier used by CLASSIFIER and the guideline
+	// parameter, e.g. "aha2017"
+	ID() string
+	// Label names the guideline in stored classifications, e.g.
+	// "AHA/ACC 2017". It must never change once readings use it.
+	Label() string
+	// Version identifies the guideline and its thresholds, e.g.
+	// "aha2017-0e4063e3". It is stored with each classification and
+	// changes whenever the thresholds do, so stale readings can be found.
+	Version() string
+	Classify(systolic, diastolic int) BPCategory
+	// Explain classifies like Classify and says which value triggered the
+	// category, by how much, and how far the next category is
+	Explain(systolic, diastolic int) Explanation
+	Categories() []BPCategory // Lowest to highest
+	Recommendation(category BPCategory) string
+}
+
+// DefaultGuideline is the classifier used when CLASSIFIER is not set, and
+// the one that produced classifications stored without a guideline
+const DefaultGuideline = "aha2017"
+
+// classifiers holds registered classifiers in registration order
+var classifiers []Classifier
+
+// The built-in guidelines
+func init() {
+	RegisterClassifier(AHA2017)
+	RegisterClassifier(ESCESH2018)
+	RegisterClassifier(NICE2019)
+	RegisterClassifier(JNC7)
+}
+
+// RegisterClassifier adds a guideline
+func RegisterClassifier(c Classifier) {
+	if _, ok := LookupClassifier(c.ID()); ok {
+		panic("utils: classifier " + c.ID() + " registered twice")
+	}
+	classifiers = append(classifiers, c)
+}
+
+// LookupClassifier returns the registered classifier with the given ID
+func LookupClassifier(id string) (Classifier, bool) {
+	for _, c := range classifiers {
+		if strings.EqualFold(c.ID(), id) {
+			return c, true
+		}
+	}
+	return nil, false
+}
+
+// Classifiers returns the registered classifiers in registration order
+func Classifiers() []Classifier {
+	return append([]Classifier(nil), classifiers...)
+}
+
+// ClassifierFromEnv returns the deployment's classifier, named by the
+// CLASSIFIER environment variable (default DefaultGuideline)
+func ClassifierFromEnv() (Classifier, error) {
+	id := os.Getenv("CLASSIFIER")
+	if id == "" {
+		id = DefaultGuideline
+	}
+	c, ok := LookupClassifier(id)
+	if !ok {
+		return nil, fmt.Errorf("invalid CLASSIFIER %q (expected %s)", id, strings.Join(ClassifierIDs(), ", "))
+	}
+	return c, nil
+}
+
+// ClassifierIDs returns the registered classifier IDs
+func ClassifierIDs() []string {
+	ids := make([]string, len(classifiers))
+	for i, c := range classifiers {
+		ids[i] = c.ID()
+	}
+	return ids
+}
+
+// FullName is the category name followed by its subtype, if any, e.g.
+// "Hypertension Stage 1, isolated systolic"
+func (c BPCategory) FullName() string {
+	if c.Subtype != "" {
+		return c.Name + ", " + c.Subtype
+	}
+	return c.Name
+}
+
+// Classification returns the value stored in readings.classification: the
+// full category name followed by the guideline that produced it, e.g.
+// "Elevated (AHA/ACC 2017)"
+func (c BPCategory) Classification() string {
+	if cl, ok := LookupClassifier(c.Guideline); ok {
+		return c.FullName() + " (" + cl.Label() + ")"
+	}
+	return c.FullName()
+}
+
+// ParseClassification splits a stored classification into its guideline and
+// category. Values stored before guidelines were recorded are AHA 2017
+// category names. It returns false for values no classifier produced.
+func ParseClassification(stored string) (Classifier, BPCategory, bool) {
+	for _, c := range classifiers {
+		if name, ok := strings.CutSuffix(stored, " ("+c.Label()+")"); ok {
+			if category, ok := categoryNamed(c, name); ok {
+				return c, category, true
+			}
+		}
+	}
+	if category, ok := categoryNamed(AHA2017, stored); ok {
+		return AHA2017, category, true
+	}
+	return nil, BPCategory{}, false
+}
+
+// ClassificationVariants returns the stored values a classification filter
+// matches. A bare category name such as "Normal" matches that category under
+// every guideline, and legacy values; a full stored value matches only itself.
+// A name without a subtype also matches its subtypes.
+func ClassificationVariants(value string) []string {
+	for _, c := range classifiers {
+		if strings.HasSuffix(value, " ("+c.Label()+")") {
+			return []string{value}
+		}
+	}
+
+	names := []string{value}
+	if _, subtype := splitSubtype(value); subtype == "" {
+		for _, s := range Subtypes {
+			names = append(names, value+", "+s)
+		}
+	}
+
+	variants := []string{value}
+	for _, c := range classifiers {
+		for _, name := range names {
+			variants = append(variants, name+" ("+c.Label()+")")
+		}
+	}
+	return variants
+}
+
+// categoryNamed finds one of c's categories by its full name
+func categoryNamed(c Classifier, name string) (BPCategory, bool) {
+	name, subtype := splitSubtype(name)
+	for _, category := range c.Categories() {
+		if category.Name != name {
+			continue
+		}
+		if subtype == "" {
+			return category, true
+		}
+		if tiered, ok := c.(*TieredClassifier); ok {
+			return tiered.withSubtype(category, subtype), true
+		}
+	}
+	return BPCategory{}, false
+}
+
+// Subtypes of a hypertension category, for readings where only one of the
+// two values is high
+const (
+	SubtypeIsolatedSystolic  = "isolated systolic"
+	SubtypeIsolatedDiastolic = "isolated diastolic"
+)
+
+// Subtypes lists the subtypes
+var Subtypes = []string{SubtypeIsolatedSystolic, SubtypeIsolatedDiastolic}
+
+// subtypeDetails describe each subtype. Isolated systolic hypertension
+// predicts cardiovascular events about as well as combined, so it keeps
+// the category's risk level; isolated diastolic is graded lowerRisk, one
+// level below (see TieredClassifier.withSubtype). The recommendation is
+// added to the category's.
+var subtypeDetails = map[string]struct {
+	description, recommendation string
+	lowerRisk                   bool
+}{
+	SubtypeIsolatedSystolic: {"Systolic blood pressure is high; diastolic is not",
+		"Only the systolic (top) number is high. This is common with age as arteries stiffen, " +
+			"and is treated like other hypertension; do not stop medication because the bottom number is normal.",
+		false},
+	SubtypeIsolatedDiastolic: {"Diastolic blood pressure is high; systolic is not",
+		"Only the diastolic (bottom) number is high. This is more common in younger adults " +
+			"and is often helped by weight loss, less alcohol and more exercise; recheck within a few months.",
+		true},
+}
+
+// RiskLevels are the category risk levels, lowest first
+var RiskLevels = []string{"low", "moderate", "high", "very high", "severe"}
+
+// splitSubtype splits "Name, subtype" into the name and subtype
+func splitSubtype(fullName string) (string, string) {
+	for _, s := range Subtypes {
+		if name, ok := strings.CutSuffix(fullName, ", "+s); ok {
+			return name, s
+		}
+	}
+	return fullName, ""
+}
+
+// Hypotension thresholds, in mmHg. The guidelines define high blood
+// pressure only; 90/60 is the commonly used cutoff for low.
+const (
+	HypotensionSystolic  = 90
+	HypotensionDiastolic = 60
+)
+
+// hypotensionRecommendation flags low readings rather than praising them,
+// since they are often caused by blood pressure medication
+const hypotensionRecommendation = "Your blood pressure is low. If you take blood pressure medication, " +
+	"or feel dizzy, lightheaded or faint, contact your healthcare provider: your dose may need adjusting. " +
+	"Stand up slowly and drink enough fluids. Seek emergency care if you faint or have chest pain or confusion."
+
+// NoThreshold marks a tier that one of the two values cannot reach alone
+const NoThreshold = math.MaxInt
+
+// Tier is one category of a TieredClassifier
+type Tier struct {
+	Category BPCategory
+	// Systolic and Diastolic are the lowest values, in mmHg, that put a
+	// reading in this tier. Either one is enough.
+	Systolic, Diastolic int
+	// Isolated marks a hypertension tier whose readings get a subtype when
+	// only one value reaches the thresholds of the lowest Isolated tier
+	Isolated       bool
+	Recommendation string
+}
+
+// TieredClassifier implements the usual guideline table: a reading belongs
+// to the highest tier that its systolic or its diastolic value reaches.
+// Readings in the first tier that are below HypotensionSystolic or
+// HypotensionDiastolic are CategoryHypotension instead.
+type TieredClassifier struct {
+	id, label, version string
+	tiers              []Tier
+	hypotension        BPCategory
+	isolated           *Tier // Lowest Isolated tier, if any
+}
+
+// NewTieredClassifier returns a classifier for tiers, lowest first. The
+// first tier's thresholds should be 0 so every reading has a category.
+func NewTieredClassifier(id, label string, tiers []Tier) *TieredClassifier {
+	c := &TieredClassifier{id: id, label: label, tiers: make([]Tier, len(tiers)), hypotension: CategoryHypotension}
+	c.hypotension.Guideline = id
+
+	hash := sha256.New()
+	fmt.Fprintf(hash, "%s\x00%d\x00%d\x00", c.hypotension.Name, HypotensionSystolic, HypotensionDiastolic)
+	for i, t := range tiers {
+		t.Category.Guideline = id
+		c.tiers[i] = t
+		if t.Isolated && c.isolated == nil {
+			c.isolated = &c.tiers[i]
+		}
+		fmt.Fprintf(hash, "%s\x00%d\x00%d\x00%t\x00", t.Category.Name, t.Systolic, t.Diastolic, t.Isolated)
+	}
+	c.version = id + "-" + hex.EncodeToString(hash.Sum(nil))[:8]
+	return c
+}
+
+func (c *TieredClassifier) ID() string    { return c.id }
+func (c *TieredClassifier) Label() string { return c.label }
+
+// Version is the ID followed by a hash of the category names and
+// thresholds; recommendations and descriptions do not affect it
+func (c *TieredClassifier) Version() string { return c.version }
+
+// Tiers returns the tiers, lowest first
+func (c *TieredClassifier) Tiers() []Tier {
+	return append([]Tier(nil), c.tiers...)
+}
+
+// Hypotension returns the low blood pressure category
+func (c *TieredClassifier) Hypotension() BPCategory {
+	return c.hypotension
+}
+
+func (c *TieredClassifier) Classify(systolic, diastolic int) BPCategory {
+	for i := len(c.tiers) - 1; i > 0; i-- {
+		if t := c.tiers[i]; systolic >= t.Systolic || diastolic >= t.Diastolic {
+			category := t.Category
+			if t.Isolated {
+				high := c.isolated
+				switch {
+				case systolic >= high.Systolic && diastolic < high.Diastolic:
+					category = c.withSubtype(category, SubtypeIsolatedSystolic)
+				case diastolic >= high.Diastolic && systolic < high.Systolic:
+					category = c.withSubtype(category, SubtypeIsolatedDiastolic)
+				}
+			}
+			return category
+		}
+	}
+	if systolic < HypotensionSystolic || diastolic < HypotensionDiastolic {
+		return c.hypotension
+	}
+	return c.tiers[0].Category
+}
+
+// withSubtype returns category qualified by subtype. A subtype that lowers
+// the risk takes it one level down, but never below the lowest Isolated
+// tier's, and never in the highest tier, so a subtype cannot make a
+// severe reading look like a milder category.
+func (c *TieredClassifier) withSubtype(category BPCategory, subtype string) BPCategory {
+	details, ok := subtypeDetails[subtype]
+	if !ok {
+		return category
+	}
+	category.Subtype = subtype
+	category.Description = details.description
+	if details.lowerRisk && c.isolated != nil && category.Name != c.tiers[len(c.tiers)-1].Category.Name {
+		level := slices.Index(RiskLevels, category.Risk)
+		if level > slices.Index(RiskLevels, c.isolated.Category.Risk) {
+			category.Risk = RiskLevels[level-1]
+		}
+	}
+	return category
+}
+
+func (c *TieredClassifier) Categories() []BPCategory {
+	categories := []BPCategory{c.hypotension}
+	for _, t := range c.tiers {
+		categories = append(categories, t.Category)
+	}
+	return categories
+}
+
+func (c *TieredClassifier) Recommendation(category BPCategory) string {
+	if category.Name == c.hypotension.Name {
+		return hypotensionRecommendation
+	}
+	for _, t := range c.tiers {
+		if t.Category.Name == category.Name {
+			if details, ok := subtypeDetails[category.Subtype]; ok {
+				return t.Recommendation + " " + details.recommendation
+			}
+			return t.Recommendation
+		}
+	}
+	return fmt.Sprintf("Unknown category: %s. Please consult your healthcare provider.", category.Name)
+}
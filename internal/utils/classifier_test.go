@@ -0,0 +1,177 @@
+// File: internal/utils/classifier_test.go
+
+package utils
+
+import "testing"
+
+// classifyCases are threshold edges for each registered guideline: each
+// category's lowest values, the values just below them, isolated subtypes
+// at the exact thresholds, and hypotension against the tiers above it
+var classifyCases = map[string][]struct {
+	systolic, diastolic int
+	want                string // FullName
+}{
+	"aha2017": {
+		{85, 50, "Hypotension"},
+		{89, 70, "Hypotension"},
+		{100, 59, "Hypotension"},
+		{90, 60, "Normal"},
+		{119, 79, "Normal"},
+		{100, 79, "Normal"}, // Elevated has no diastolic threshold
+		{125, 55, "Elevated"},
+		{120, 79, "Elevated"},
+		{129, 79, "Elevated"},
+		{130, 79, "Hypertension Stage 1, isolated systolic"},
+		{129, 80, "Hypertension Stage 1, isolated diastolic"},
+		{130, 80, "Hypertension Stage 1"},
+		{139, 89, "Hypertension Stage 1"},
+		{140, 79, "Hypertension Stage 2, isolated systolic"},
+		{140, 80, "Hypertension Stage 2"},
+		{125, 90, "Hypertension Stage 2, isolated diastolic"},
+		{180, 120, "Hypertension Stage 2"},
+		{181, 80, "Hypertensive Crisis"},
+		{120, 121, "Hypertensive Crisis"},
+	},
+	"esc2018": {
+		{89, 59, "Hypotension"},
+		{90, 60, "Optimal"},
+		{119, 79, "Optimal"},
+		{120, 79, "Normal"},
+		{119, 80, "Normal"},
+		{130, 84, "High Normal"},
+		{129, 85, "High Normal"},
+		{140, 89, "Grade 1 Hypertension, isolated systolic"},
+		{139, 90, "Grade 1 Hypertension, isolated diastolic"},
+		{85, 95, "Grade 1 Hypertension, isolated diastolic"},
+		{140, 90, "Grade 1 Hypertension"},
+		{160, 89, "Grade 2 Hypertension, isolated systolic"},
+		{139, 100, "Grade 2 Hypertension, isolated diastolic"},
+		{159, 100, "Grade 2 Hypertension"},
+		{180, 109, "Grade 3 Hypertension"},
+		{180, 89, "Grade 3 Hypertension, isolated systolic"},
+		{139, 110, "Grade 3 Hypertension, isolated diastolic"},
+	},
+	"nice2019": {
+		{130, 59, "Hypotension"},
+		{90, 60, "Normal"},
+		{134, 84, "Normal"},
+		{135, 84, "Stage 1 Hypertension, isolated systolic"},
+		{134, 85, "Stage 1 Hypertension, isolated diastolic"},
+		{135, 85, "Stage 1 Hypertension"},
+		{150, 84, "Stage 2 Hypertension, isolated systolic"},
+		{150, 85, "Stage 2 Hypertension"},
+		{134, 95, "Stage 2 Hypertension, isolated diastolic"},
+		{179, 119, "Stage 2 Hypertension"},
+		{180, 80, "Severe Hypertension"},
+		{120, 120, "Severe Hypertension"},
+	},
+	"jnc7": {
+		{85, 50, "Hypotension"},
+		{119, 79, "Normal"},
+		{120, 79, "Prehypertension"},
+		{119, 80, "Prehypertension"},
+		{139, 89, "Prehypertension"},
+		{140, 89, "Stage 1 Hypertension, isolated systolic"},
+		{139, 90, "Stage 1 Hypertension, isolated diastolic"},
+		{140, 90, "Stage 1 Hypertension"},
+		{160, 89, "Stage 2 Hypertension, isolated systolic"},
+		{160, 99, "Stage 2 Hypertension"},
+		{139, 100, "Stage 2 Hypertension, isolated diastolic"},
+		{220, 130, "Stage 2 Hypertension"},
+	},
+}
+
+func TestClassifyThresholds(t *testing.T) {
+	for _, c := range Classifiers() {
+		cases, ok := classifyCases[c.ID()]
+		if !ok {
+			t.Errorf("no threshold cases for registered guideline %q", c.ID())
+			continue
+		}
+		t.Run(c.ID(), func(t *testing.T) {
+			for _, tt := range cases {
+				got := c.Classify(tt.systolic, tt.diastolic)
+				if got.FullName() != tt.want {
+					t.Errorf("Classify(%d, %d) = %q, want %q", tt.systolic, tt.diastolic, got.FullName(), tt.want)
+				}
+				if got.Guideline != c.ID() {
+					t.Errorf("Classify(%d, %d).Guideline = %q, want %q", tt.systolic, tt.diastolic, got.Guideline, c.ID())
+				}
+
+				// The stored value must parse back to the same category
+				if parsed, category, ok := ParseClassification(got.Classification()); !ok || parsed.ID() != c.ID() || category.FullName() != tt.want {
+					t.Errorf("ParseClassification(%q) = %q, %t; want %q by %s", got.Classification(), category.FullName(), ok, tt.want, c.ID())
+				}
+			}
+		})
+	}
+}
+
+func TestClassifyBPIsAHA2017(t *testing.T) {
+	for _, tt := range classifyCases["aha2017"] {
+		if got := ClassifyBP(tt.systolic, tt.diastolic); got.FullName() != tt.want {
+			t.Errorf("ClassifyBP(%d, %d) = %q, want %q", tt.systolic, tt.diastolic, got.FullName(), tt.want)
+		}
+	}
+}
+
+func TestSubtypeRisk(t *testing.T) {
+	tests := []struct {
+		c                   Classifier
+		systolic, diastolic int
+		want                string
+	}{
+		{AHA2017, 130, 79, "high"},       // Stage 1, isolated systolic
+		{AHA2017, 129, 80, "high"},       // Stage 1, isolated diastolic, not below Stage 1
+		{AHA2017, 140, 79, "very high"},  // Stage 2, isolated systolic
+		{AHA2017, 125, 95, "high"},       // Stage 2, isolated diastolic, one level down
+		{AHA2017, 140, 80, "very high"},  // Stage 2
+		{ESCESH2018, 139, 90, "high"},    // Grade 1, isolated diastolic
+		{ESCESH2018, 139, 100, "high"},   // Grade 2, isolated diastolic
+		{ESCESH2018, 180, 89, "severe"},  // Grade 3, isolated systolic
+		{ESCESH2018, 185, 85, "severe"},  // Grade 3, isolated systolic
+		{ESCESH2018, 139, 110, "severe"}, // Grade 3 is the highest tier
+		{ESCESH2018, 139, 115, "severe"}, // Grade 3 is the highest tier
+		{NICE2019, 134, 95, "high"},      // Stage 2, isolated diastolic
+		{NICE2019, 150, 84, "very high"}, // Stage 2, isolated systolic
+		{JNC7, 139, 100, "very high"},    // Stage 2 is the highest tier
+	}
+	for _, tt := range tests {
+		got := tt.c.Classify(tt.systolic, tt.diastolic)
+		if got.Risk != tt.want {
+			t.Errorf("%s Classify(%d, %d).Risk = %q, want %q", tt.c.ID(), tt.systolic, tt.diastolic, got.Risk, tt.want)
+		}
+
+		// A stored subtype parses back to the same risk
+		if _, parsed, ok := ParseClassification(got.Classification()); !ok || parsed.Risk != got.Risk {
+			t.Errorf("ParseClassification(%q).Risk = %q, want %q", got.Classification(), parsed.Risk, got.Risk)
+		}
+	}
+}
+
+func TestNoThreshold(t *testing.T) {
+	low := BPCategory{Name: "Low", Risk: "low"}
+	wide := BPCategory{Name: "Wide", Risk: "moderate"}
+	high := BPCategory{Name: "High", Risk: "high"}
+	c := NewTieredClassifier("test", "Test", []Tier{
+		{Category: low},
+		{Category: wide, Systolic: NoThreshold, Diastolic: 85},  // Only diastolic reaches it
+		{Category: high, Systolic: 150, Diastolic: NoThreshold}, // Only systolic reaches it
+	})
+
+	tests := []struct {
+		systolic, diastolic int
+		want                string
+	}{
+		{149, 84, "Low"},
+		{149, 85, "Wide"},
+		{149, 200, "Wide"},
+		{150, 60, "High"},
+		{250, 84, "High"},
+	}
+	for _, tt := range tests {
+		if got := c.Classify(tt.systolic, tt.diastolic); got.Name != tt.want {
+			t.Errorf("Classify(%d, %d) = %q, want %q", tt.systolic, tt.diastolic, got.Name, tt.want)
+		}
+	}
+}
@@ -0,0 +1,135 @@
+// File: internal/utils/explain.go
+
+package utils
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Explanation says why a reading was given its category
+type Explanation struct {
+	Category BPCategory `json:"-"` // Same as Classify returns
+
+	// Triggers are the values that put the reading in its category: one, or
+	// both when both reach it. Empty for the lowest tier, which needs none.
+	Triggers []Trigger `json:"triggers,omitempty"`
+	// Next is the nearest boundary of the next higher category, or nil in
+	// the highest. For Hypotension it is where the reading stops being low.
+	Next *Boundary `json:"next,omitempty"`
+	// Message puts the above in words, e.g. "Diastolic 82 is 2 mmHg into
+	// Hypertension Stage 1 and 8 mmHg below Hypertension Stage 2."
+	Message string `json:"message"`
+}
+
+// Trigger is a value that crossed a category threshold
+type Trigger struct {
+	Component string `json:"component"` // "systolic" or "diastolic"
+	Value     int    `json:"value"`
+	Threshold int    `json:"threshold"` // Lowest value of the category; for Hypotension, the value it is below
+	Margin    int    `json:"margin"`    // mmHg past the threshold, 0 when exactly on it
+}
+
+// Boundary is the threshold of another category for one value
+type Boundary struct {
+	Category  string `json:"category"`
+	Component string `json:"component"`
+	Threshold int    `json:"threshold"`
+	Distance  int    `json:"distance"` // mmHg the value must rise to reach it
+}
+
+// Components of a reading, as named in explanations
+const (
+	ComponentSystolic  = "systolic"
+	ComponentDiastolic = "diastolic"
+)
+
+// Explain classifies a reading like Classify and says why
+func (c *TieredClassifier) Explain(systolic, diastolic int) Explanation {
+	e := Explanation{Category: c.Classify(systolic, diastolic)}
+	values := [2]int{systolic, diastolic}
+	components := [2]string{ComponentSystolic, ComponentDiastolic}
+
+	if e.Category.Name == c.hypotension.Name {
+		lows := [2]int{HypotensionSystolic, HypotensionDiastolic}
+		for i, v := range values {
+			if v < lows[i] {
+				e.Triggers = append(e.Triggers, Trigger{Component: components[i], Value: v, Threshold: lows[i], Margin: lows[i] - v})
+				// The farthest value below decides when the reading is no longer low
+				if e.Next == nil || lows[i]-v > e.Next.Distance {
+					e.Next = &Boundary{Category: c.tiers[0].Category.Name, Component: components[i], Threshold: lows[i], Distance: lows[i] - v}
+				}
+			}
+		}
+		e.Message = e.describe(true)
+		return e
+	}
+
+	tier := 0
+	for i, t := range c.tiers {
+		if t.Category.Name == e.Category.Name {
+			tier = i
+		}
+	}
+	if tier > 0 {
+		t := c.tiers[tier]
+		for i, threshold := range [2]int{t.Systolic, t.Diastolic} {
+			if values[i] >= threshold {
+				e.Triggers = append(e.Triggers, Trigger{Component: components[i], Value: values[i], Threshold: threshold, Margin: values[i] - threshold})
+			}
+		}
+	}
+	if tier+1 < len(c.tiers) {
+		next := c.tiers[tier+1]
+		for i, threshold := range [2]int{next.Systolic, next.Diastolic} {
+			if threshold == NoThreshold {
+				continue
+			}
+			if e.Next == nil || threshold-values[i] < e.Next.Distance {
+				e.Next = &Boundary{Category: next.Category.Name, Component: components[i], Threshold: threshold, Distance: threshold - values[i]}
+			}
+		}
+	}
+	e.Message = e.describe(false)
+	return e
+}
+
+// describe puts the explanation in words; low is set for Hypotension, whose
+// triggers are below their thresholds
+func (e Explanation) describe(low bool) string {
+	var sentences []string
+	next := e.Next
+	if low {
+		next = nil // Triggers already say how far below
+	}
+	for i, t := range e.Triggers {
+		s := fmt.Sprintf("%s %d is %d mmHg into %s", t.Component, t.Value, t.Margin, e.Category.Name)
+		if low {
+			s = fmt.Sprintf("%s %d is %d mmHg below the %s threshold of %d", t.Component, t.Value, t.Margin, e.Category.Name, t.Threshold)
+		} else if t.Margin == 0 {
+			s = fmt.Sprintf("%s %d is at the %s threshold", t.Component, t.Value, e.Category.Name)
+		}
+		if i == len(e.Triggers)-1 && next != nil && next.Component == t.Component {
+			s += fmt.Sprintf(" and %d mmHg below %s", next.Distance, next.Category)
+			next = nil
+		}
+		sentences = append(sentences, capitalize(s)+".")
+	}
+
+	if len(e.Triggers) == 0 {
+		sentences = append(sentences, "Within "+e.Category.Name+".")
+	}
+	if next != nil {
+		sentences = append(sentences, capitalize(fmt.Sprintf("%s %d is %d mmHg below %s.",
+			next.Component, next.Threshold-next.Distance, next.Distance, next.Category)))
+	}
+	return strings.Join(sentences, " ")
+}
+
+// capitalize upper-cases the first letter of s
+func capitalize(s string) string {
+	if s == "" {
+		return s
+	}
+	return strings.ToUpper(s[:1]) + s[1:]
+}
@@ -0,0 +1,107 @@
+// File: internal/utils/explain_test.go
+
+package utils
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestExplainMessages(t *testing.T) {
+	tests := []struct {
+		guideline           *TieredClassifier
+		systolic, diastolic int
+		want                string
+	}{
+		{AHA2017, 125, 82, "Diastolic 82 is 2 mmHg into Hypertension Stage 1 and 8 mmHg below Hypertension Stage 2."},
+		{AHA2017, 115, 75, "Within Normal. Systolic 115 is 5 mmHg below Elevated."},
+		{AHA2017, 120, 80, "Diastolic 80 is at the Hypertension Stage 1 threshold and 10 mmHg below Hypertension Stage 2."},
+		{AHA2017, 145, 75, "Systolic 145 is 5 mmHg into Hypertension Stage 2 and 36 mmHg below Hypertensive Crisis."},
+		{AHA2017, 185, 100, "Systolic 185 is 4 mmHg into Hypertensive Crisis."},
+		{AHA2017, 210, 130, "Systolic 210 is 29 mmHg into Hypertensive Crisis. Diastolic 130 is 9 mmHg into Hypertensive Crisis."},
+		{AHA2017, 85, 70, "Systolic 85 is 5 mmHg below the Hypotension threshold of 90."},
+		{AHA2017, 85, 50, "Systolic 85 is 5 mmHg below the Hypotension threshold of 90. Diastolic 50 is 10 mmHg below the Hypotension threshold of 60."},
+		{ESCESH2018, 125, 82, "Systolic 125 is 5 mmHg into Normal. Diastolic 82 is 2 mmHg into Normal and 3 mmHg below High Normal."},
+		{NICE2019, 125, 82, "Within Normal. Diastolic 82 is 3 mmHg below Stage 1 Hypertension."},
+		{JNC7, 185, 100, "Systolic 185 is 25 mmHg into Stage 2 Hypertension. Diastolic 100 is at the Stage 2 Hypertension threshold."},
+	}
+	for _, tt := range tests {
+		if got := tt.guideline.Explain(tt.systolic, tt.diastolic).Message; got != tt.want {
+			t.Errorf("%s Explain(%d, %d).Message = %q, want %q", tt.guideline.ID(), tt.systolic, tt.diastolic, got, tt.want)
+		}
+	}
+}
+
+func TestExplainFields(t *testing.T) {
+	e := ExplainBP(125, 82)
+	wantTriggers := []Trigger{{Component: ComponentDiastolic, Value: 82, Threshold: 80, Margin: 2}}
+	if !reflect.DeepEqual(e.Triggers, wantTriggers) {
+		t.Errorf("Triggers = %+v, want %+v", e.Triggers, wantTriggers)
+	}
+	wantNext := &Boundary{Category: "Hypertension Stage 2", Component: ComponentDiastolic, Threshold: 90, Distance: 8}
+	if !reflect.DeepEqual(e.Next, wantNext) {
+		t.Errorf("Next = %+v, want %+v", e.Next, wantNext)
+	}
+
+	// Hypotension's boundary is the threshold of the value farthest below
+	e = ExplainBP(85, 50)
+	wantNext = &Boundary{Category: "Normal", Component: ComponentDiastolic, Threshold: 60, Distance: 10}
+	if !reflect.DeepEqual(e.Next, wantNext) {
+		t.Errorf("hypotension Next = %+v, want %+v", e.Next, wantNext)
+	}
+
+	if e := ExplainBP(200, 100); e.Next != nil {
+		t.Errorf("highest category Next = %+v, want nil", e.Next)
+	}
+}
+
+// TestExplainAgreesWithClassify checks every registered guideline over a
+// grid of readings: the explanation's category is Classify's, triggers
+// have crossed their thresholds, and reaching Next moves the reading up
+func TestExplainAgreesWithClassify(t *testing.T) {
+	for _, c := range Classifiers() {
+		tiered, ok := c.(*TieredClassifier)
+		if !ok {
+			continue
+		}
+		for sys := 70; sys <= 220; sys++ {
+			for dia := 40; dia <= 140; dia += 3 {
+				e := tiered.Explain(sys, dia)
+				category := c.Classify(sys, dia)
+				if e.Category != category {
+					t.Fatalf("%s Explain(%d, %d).Category = %q, Classify = %q", c.ID(), sys, dia, e.Category.FullName(), category.FullName())
+				}
+				if e.Message == "" {
+					t.Fatalf("%s Explain(%d, %d) has no message", c.ID(), sys, dia)
+				}
+
+				low := category.Name == CategoryHypotension.Name
+				for _, tr := range e.Triggers {
+					crossed := tr.Value >= tr.Threshold && tr.Margin == tr.Value-tr.Threshold
+					if low {
+						crossed = tr.Value < tr.Threshold && tr.Margin == tr.Threshold-tr.Value
+					}
+					if !crossed {
+						t.Fatalf("%s Explain(%d, %d) trigger %+v has not crossed its threshold", c.ID(), sys, dia, tr)
+					}
+				}
+				if e.Next == nil || low {
+					continue
+				}
+
+				if e.Next.Distance <= 0 {
+					t.Fatalf("%s Explain(%d, %d).Next = %+v, want a positive distance", c.ID(), sys, dia, e.Next)
+				}
+				up := [2]int{sys, dia}
+				if e.Next.Component == ComponentSystolic {
+					up[0] += e.Next.Distance
+				} else {
+					up[1] += e.Next.Distance
+				}
+				if got := c.Classify(up[0], up[1]).Name; got != e.Next.Category {
+					t.Fatalf("%s Explain(%d, %d).Next = %+v, but %d/%d is %q", c.ID(), sys, dia, e.Next, up[0], up[1], got)
+				}
+			}
+		}
+	}
+}
@@ -0,0 +1,48 @@
+// File: internal/utils/guidelines.go
+
+package utils
+
+// ESCESH2018 classifies by the 2018 ESC/ESH guideline used in most of
+// Europe, where 130-139/85-89 is still "High normal" and hypertension
+// starts at 140/90
+var ESCESH2018 = NewTieredClassifier("esc2018", "ESC/ESH 2018", []Tier{
+	{Category: BPCategory{Name: "Optimal", Description: "Blood pressure is optimal", Risk: "low"},
+		Recommendation: "Maintain a healthy lifestyle with regular exercise and balanced diet."},
+	{Category: BPCategory{Name: "Normal", Description: "Blood pressure in normal range", Risk: "low"}, Systolic: 120, Diastolic: 80,
+		Recommendation: "Maintain a healthy lifestyle and check your blood pressure at least every 3 years."},
+	{Category: BPCategory{Name: "High Normal", Description: "Blood pressure is at the top of the normal range", Risk: "moderate"}, Systolic: 130, Diastolic: 85,
+		Recommendation: "Consider lifestyle changes including reduced salt and alcohol intake and regular exercise. Check your blood pressure every year."},
+	{Category: BPCategory{Name: "Grade 1 Hypertension", Description: "Blood pressure is high", Risk: "high"}, Systolic: 140, Diastolic: 90, Isolated: true,
+		Recommendation: "Consult your healthcare provider. Lifestyle changes and possibly medication may be needed."},
+	{Category: BPCategory{Name: "Grade 2 Hypertension", Description: "Blood pressure is very high", Risk: "very high"}, Systolic: 160, Diastolic: 100, Isolated: true,
+		Recommendation: "Consult your healthcare provider promptly. Medication is likely needed along with lifestyle changes."},
+	{Category: BPCategory{Name: "Grade 3 Hypertension", Description: "Blood pressure is severely high", Risk: "severe"}, Systolic: 180, Diastolic: 110, Isolated: true,
+		Recommendation: "Consult your healthcare provider today. Seek emergency care if you have chest pain, breathlessness, headache or vision changes."},
+})
+
+// NICE2019 classifies by the NICE NG136 (2019) thresholds for home
+// monitoring, which sit 5 mmHg below the clinic ones. Severe hypertension
+// uses the clinic threshold, which NICE applies to any measurement.
+var NICE2019 = NewTieredClassifier("nice2019", "NICE NG136", []Tier{
+	{Category: BPCategory{Name: "Normal", Description: "Blood pressure is below the home monitoring threshold", Risk: "low"},
+		Recommendation: "Maintain a healthy lifestyle with regular exercise and balanced diet."},
+	{Category: BPCategory{Name: "Stage 1 Hypertension", Description: "Home blood pressure is high", Risk: "high"}, Systolic: 135, Diastolic: 85, Isolated: true,
+		Recommendation: "Discuss your readings with your GP. Lifestyle changes are advised and medication may be offered."},
+	{Category: BPCategory{Name: "Stage 2 Hypertension", Description: "Home blood pressure is very high", Risk: "very high"}, Systolic: 150, Diastolic: 95, Isolated: true,
+		Recommendation: "See your GP promptly. Medication is recommended along with lifestyle changes."},
+	{Category: BPCategory{Name: "Severe Hypertension", Description: "Seek same-day medical advice", Risk: "severe"}, Systolic: 180, Diastolic: 120,
+		Recommendation: "Seek same-day medical assessment, or emergency care if you have symptoms such as chest pain or confusion."},
+})
+
+// JNC7 classifies by the 2003 JNC 7 report, still used by some clinics
+// and insurers, with "Prehypertension" for 120-139/80-89
+var JNC7 = NewTieredClassifier("jnc7", "JNC 7", []Tier{
+	{Category: BPCategory{Name: "Normal", Description: "Blood pressure in normal range", Risk: "low"},
+		Recommendation: "Maintain a healthy lifestyle with regular exercise and balanced diet."},
+	{Category: BPCategory{Name: "Prehypertension", Description: "Blood pressure is above normal", Risk: "moderate"}, Systolic: 120, Diastolic: 80,
+		Recommendation: "Consider lifestyle changes including reduced sodium intake and regular exercise. Monitor BP regularly."},
+	{Category: BPCategory{Name: "Stage 1 Hypertension", Description: "Blood pressure is high", Risk: "high"}, Systolic: 140, Diastolic: 90, Isolated: true,
+		Recommendation: "Consult your healthcare provider. Lifestyle changes and likely medication are needed."},
+	{Category: BPCategory{Name: "Stage 2 Hypertension", Description: "Blood pressure is very high", Risk: "very high"}, Systolic: 160, Diastolic: 100, Isolated: true,
+		Recommendation: "Consult your healthcare provider promptly. Medication is usually needed along with lifestyle changes."},
+})
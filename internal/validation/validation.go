@@ -43,6 +43,10 @@ const (
 
     // Maximum allowed difference between readings
     MaxReadingDiff = 15
+
+    // Number of readings allowed in one session
+    MinMeasurements = 1
+    MaxMeasurements = 5
 )
 
 // ValidateReading checks if a single set of readings is within acceptable ranges
@@ -84,48 +88,62 @@ func validateSingleReading(systolic, diastolic, pulse int, readingNum int) Valid
     return errors
 }
 
-// ValidateReadings validates all three readings
+// ValidateReading checks a single reading, such as an imported row, against
+// the same ranges as a submitted session
+func ValidateReading(systolic, diastolic, pulse int) error {
+    if errs := validateSingleReading(systolic, diastolic, pulse, 1); len(errs) > 0 {
+        return errs
+    }
+    return nil
+}
+
+// ValidateReadings validates a session of MinMeasurements to MaxMeasurements readings
 func ValidateReadings(input *models.ReadingInput) error {
     var allErrors ValidationErrors
 
-    // Validate each reading individually
-    readings := []struct {
-        systolic  int
-        diastolic int
-        pulse     int
-        num       int
-    }{
-        {input.Systolic1, input.Diastolic1, input.Pulse1, 1},
-        {input.Systolic2, input.Diastolic2, input.Pulse2, 2},
-        {input.Systolic3, input.Diastolic3, input.Pulse3, 3},
+    measurements := input.SessionMeasurements()
+    if len(measurements) < MinMeasurements || len(measurements) > MaxMeasurements {
+        return ValidationErrors{{
+            Field:   "Readings",
+            Message: fmt.Sprintf("a session must have between %d and %d readings, got %d", MinMeasurements, MaxMeasurements, len(measurements)),
+        }}
     }
 
-    for _, r := range readings {
-        if errs := validateSingleReading(r.systolic, r.diastolic, r.pulse, r.num); len(errs) > 0 {
+    // Validate each reading individually
+    for _, m := range measurements {
+        if errs := validateSingleReading(m.Systolic, m.Diastolic, m.Pulse, m.Seq); len(errs) > 0 {
             allErrors = append(allErrors, errs...)
         }
     }
 
-    // Check consistency between readings
+    // Check consistency between the readings that make up the average.
+    // An excluded (discarded) first reading is not held to the spread limit.
     if len(allErrors) == 0 {
-        // Check systolic consistency
-        maxSys := max(input.Systolic1, input.Systolic2, input.Systolic3)
-        minSys := min(input.Systolic1, input.Systolic2, input.Systolic3)
-        if maxSys-minSys > MaxReadingDiff {
-            allErrors = append(allErrors, ValidationError{
-                Field:   "Systolic Readings",
-                Message: fmt.Sprintf("difference between readings cannot exceed %d mmHg", MaxReadingDiff),
-            })
+        var systolics, diastolics []int
+        for _, m := range measurements {
+            if m.Excluded {
+                continue
+            }
+            systolics = append(systolics, m.Systolic)
+            diastolics = append(diastolics, m.Diastolic)
         }
 
-        // Check diastolic consistency
-        maxDia := max(input.Diastolic1, input.Diastolic2, input.Diastolic3)
-        minDia := min(input.Diastolic1, input.Diastolic2, input.Diastolic3)
-        if maxDia-minDia > MaxReadingDiff {
-            allErrors = append(allErrors, ValidationError{
-                Field:   "Diastolic Readings",
-                Message: fmt.Sprintf("difference between readings cannot exceed %d mmHg", MaxReadingDiff),
-            })
+        if len(systolics) > 1 {
+            // Check systolic consistency
+            if max(systolics...)-min(systolics...) > MaxReadingDiff {
+                allErrors = append(allErrors, ValidationError{
+                    Field:   "Systolic Readings",
+                    Message: fmt.Sprintf("difference between readings cannot exceed %d mmHg", MaxReadingDiff),
+                })
+            }
+
+            // Check diastolic consistency
+            if max(diastolics...)-min(diastolics...) > MaxReadingDiff {
+                allErrors = append(allErrors, ValidationError{
+                    Field:   "Diastolic Readings",
+                    Message: fmt.Sprintf("difference between readings cannot exceed %d mmHg", MaxReadingDiff),
+                })
+            }
         }
     }
 
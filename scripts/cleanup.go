@@ -1,5 +1,7 @@
 // File: scripts/cleanup.go
 
+//go:build ignore
+
 package main
 
 import (
@@ -15,10 +17,16 @@ func main() {
     dbPath := flag.String("db", "bp.db", "Path to SQLite database")
     mode := flag.String("mode", "all", "Cleanup mode: all, before-date, after-date")
     date := flag.String("date", "", "Date for cleanup (format: YYYY-MM-DD)")
+    tz := flag.String("tz", "Local", "Timezone used to interpret -date (e.g. America/Denver)")
     flag.Parse()
 
+    loc, err := time.LoadLocation(*tz)
+    if err != nil {
+        log.Fatalf("Invalid timezone %q: %v", *tz, err)
+    }
+
     // Initialize database
-    db, err := database.New(*dbPath)
+    db, err := database.NewSQLite(*dbPath)
     if err != nil {
         log.Fatalf("Failed to initialize database: %v", err)
     }
@@ -38,7 +46,7 @@ func main() {
         }
 
         // Parse date
-        targetDate, err := time.Parse("2006-01-02", *date)
+        targetDate, err := time.ParseInLocation("2006-01-02", *date, loc)
         if err != nil {
             log.Fatalf("Invalid date format. Use YYYY-MM-DD: %v", err)
         }
@@ -56,14 +64,15 @@ func main() {
 }
 
 // cleanAll removes all readings from the database
-func cleanAll(db *database.DB) error {
+func cleanAll(db *database.SQLiteDB) error {
     query := `DELETE FROM readings`
     _, err := db.Exec(query)
     return err
 }
 
-// cleanByDate removes readings before or after the specified date
-func cleanByDate(db *database.DB, date time.Time, before bool) error {
+// cleanByDate removes readings before or after the specified date.
+// Timestamps are stored as Unix seconds in SQLite.
+func cleanByDate(db *database.SQLiteDB, date time.Time, before bool) error {
     var query string
     if before {
         query = `DELETE FROM readings WHERE timestamp < ?`
@@ -0,0 +1,99 @@
+// File: scripts/hl7_export.go
+
+//go:build ignore
+
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"time"
+
+	"bp-tracker/internal/database"
+	"bp-tracker/internal/hl7"
+)
+
+func main() {
+	if err := run(); err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(1)
+	}
+}
+
+// run exports the readings; returning rather than exiting lets the
+// deferred store.Close run
+func run() error {
+	id := flag.Int64("id", 0, "Export this reading as a single message instead of a batch")
+	from := flag.String("from", "", "Start date, YYYY-MM-DD (inclusive)")
+	to := flag.String("to", "", "End date, YYYY-MM-DD (inclusive)")
+	tz := flag.String("tz", envOr("TIMEZONE", "America/Denver"), "Timezone for dates and message timestamps")
+	out := flag.String("o", "", "Output file (default: stdout)")
+	flag.Parse()
+
+	loc, err := time.LoadLocation(*tz)
+	if err != nil {
+		return fmt.Errorf("invalid timezone %q: %w", *tz, err)
+	}
+
+	// Same settings as GET /export/hl7: DB_DRIVER, HL7_* and FHIR_PATIENT_ID
+	store, err := database.NewStore()
+	if err != nil {
+		return fmt.Errorf("error opening database: %w", err)
+	}
+	defer store.Close()
+
+	opts := hl7.OptionsFromEnv()
+	opts.Location = loc
+
+	var messages []byte
+	if *id != 0 {
+		reading, err := store.GetReading(*id)
+		if err != nil {
+			return fmt.Errorf("error fetching reading %d: %w", *id, err)
+		}
+		messages = hl7.ORU(reading, opts)
+	} else {
+		q := database.ReadingQuery{Order: database.SortAsc}
+		if q.From, err = parseDate(*from, loc); err != nil {
+			return fmt.Errorf("invalid -from: %w", err)
+		}
+		if q.To, err = parseDate(*to, loc); err != nil {
+			return fmt.Errorf("invalid -to: %w", err)
+		}
+		if !q.To.IsZero() {
+			q.To = q.To.AddDate(0, 0, 1) // Include the whole last day
+		}
+
+		readings, err := database.QueryAllReadings(store, q)
+		if err != nil {
+			return fmt.Errorf("error fetching readings: %w", err)
+		}
+		fmt.Fprintf(os.Stderr, "Exporting %d readings\n", len(readings))
+		messages = hl7.Batch(readings, opts)
+	}
+
+	if *out == "" {
+		_, err := os.Stdout.Write(messages)
+		return err
+	}
+	if err := os.WriteFile(*out, messages, 0o644); err != nil {
+		return fmt.Errorf("error writing %s: %w", *out, err)
+	}
+	return nil
+}
+
+// parseDate parses a YYYY-MM-DD flag as midnight in loc; empty means no bound
+func parseDate(value string, loc *time.Location) (time.Time, error) {
+	if value == "" {
+		return time.Time{}, nil
+	}
+	return time.ParseInLocation("2006-01-02", value, loc)
+}
+
+func envOr(key, fallback string) string {
+	if v := os.Getenv(key); v != "" {
+		return v
+	}
+	return fallback
+}
@@ -1,23 +1,98 @@
 // File: scripts/migrate_schema.go
 
+//go:build ignore
+
 package main
 
 import (
 	"context"
 	"database/sql"
+	"encoding/json"
+	"flag"
 	"fmt"
-	"io/ioutil"
 	"os"
-	"path/filepath"
 	"time"
 
+	"bp-tracker/internal/database"
+
 	_ "github.com/jackc/pgx/v5/stdlib" // PostgreSQL driver
 )
 
 func main() {
-	fmt.Println("Starting schema migration...")
+	driver := flag.String("driver", envOr("DB_DRIVER", "postgres"), "Database driver: postgres or sqlite")
+	sqlitePath := flag.String("db", envOr("SQLITE_PATH", "bp.db"), "Path to SQLite database (sqlite driver only)")
+	status := flag.Bool("status", false, "Print migration status and exit")
+	dryRun := flag.Bool("dry-run", false, "Show which migrations would run without applying them")
+	down := flag.Bool("down", false, "Roll back migrations instead of applying them")
+	steps := flag.Int("steps", 0, "Number of migrations to run (default: all pending for up, 1 for down)")
+	flag.Parse()
+
+	var db *sql.DB
+	switch *driver {
+	case "postgres":
+		db = openPostgres()
+	case "sqlite":
+		// OpenSQLite (not NewSQLite) so that -status and -dry-run never change the schema
+		sqliteDB, err := database.OpenSQLite(*sqlitePath)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error opening SQLite database: %v\n", err)
+			os.Exit(1)
+		}
+		db = sqliteDB.DB
+	default:
+		fmt.Fprintf(os.Stderr, "Error: unsupported driver %q (expected postgres or sqlite)\n", *driver)
+		os.Exit(1)
+	}
+	defer db.Close()
+
+	migrator, err := database.NewMigrator(db, *driver)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error loading migrations: %v\n", err)
+		os.Exit(1)
+	}
 
-	// --- Database Connection (mirrors internal/database/db.go New() logic) ---
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Minute)
+	defer cancel()
+
+	if *status {
+		states, err := migrator.Status(ctx)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error reading migration status: %v\n", err)
+			os.Exit(1)
+		}
+		for _, s := range states {
+			applied := "pending"
+			if s.Applied {
+				applied = "applied " + s.AppliedAt.Format(time.RFC3339)
+			}
+			fmt.Printf("%04d  %-30s  %s\n", s.Version, s.Name, applied)
+		}
+		return
+	}
+
+	opts := database.MigrateOptions{Direction: database.MigrateUp, Steps: *steps, DryRun: *dryRun}
+	if *down {
+		opts.Direction = database.MigrateDown
+	}
+
+	report, err := migrator.Migrate(ctx, opts)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Migration failed: %v\n", err)
+		os.Exit(1)
+	}
+
+	out, _ := json.MarshalIndent(report, "", "  ")
+	fmt.Println(string(out))
+	if *dryRun {
+		fmt.Println("Dry run: no changes were made.")
+	} else {
+		fmt.Printf("Schema is now at version %d\n", report.EndVersion)
+	}
+}
+
+// openPostgres connects using DB_* environment variables. Unlike the Lambda,
+// the password is read from DB_PASSWORD rather than Secrets Manager.
+func openPostgres() *sql.DB {
 	dbHost := os.Getenv("DB_HOST")
 	dbPort := os.Getenv("DB_PORT")
 	dbUser := os.Getenv("DB_USER")
@@ -39,9 +114,8 @@ func main() {
 		fmt.Fprintf(os.Stderr, "Error opening database connection: %v\n", err)
 		os.Exit(1)
 	}
-	defer db.Close()
 
-	ctx, cancel := context.WithTimeout(context.Background(), 15*time.Second) // Increased timeout for potential network latency
+	ctx, cancel := context.WithTimeout(context.Background(), 15*time.Second) // Allow for network latency
 	defer cancel()
 	if err := db.PingContext(ctx); err != nil {
 		fmt.Fprintf(os.Stderr, "Error connecting to database: %v\n", err)
@@ -49,39 +123,12 @@ func main() {
 	}
 
 	fmt.Printf("Successfully connected to database %s@%s:%s\n", dbUser, dbHost, dbPort)
+	return db
+}
 
-	// --- Read Schema File ---
-	// Assumes script is run from the project root (bp-tracker/)
-	schemaPath := filepath.Join("internal", "database", "schema.sql")
-	schemaBytes, err := ioutil.ReadFile(schemaPath)
-	if err != nil {
-		fmt.Fprintf(os.Stderr, "Error reading schema file %s: %v\n", schemaPath, err)
-		os.Exit(1)
-	}
-	schemaSQL := string(schemaBytes)
-
-	// --- Execute Schema ---
-	// Using a transaction is good practice, although this simple schema might not strictly need it.
-	tx, err := db.BeginTx(ctx, nil)
-	if err != nil {
-		fmt.Fprintf(os.Stderr, "Error starting transaction: %v\n", err)
-		os.Exit(1)
-	}
-
-	_, err = tx.ExecContext(ctx, schemaSQL)
-	if err != nil {
-		fmt.Fprintf(os.Stderr, "Error executing schema: %v\n", err)
-		// Attempt to rollback
-		if rollbackErr := tx.Rollback(); rollbackErr != nil {
-			fmt.Fprintf(os.Stderr, "Error rolling back transaction: %v\n", rollbackErr)
-		}
-		os.Exit(1)
-	}
-
-	if err := tx.Commit(); err != nil {
-		fmt.Fprintf(os.Stderr, "Error committing transaction: %v\n", err)
-		os.Exit(1)
+func envOr(key, fallback string) string {
+	if v := os.Getenv(key); v != "" {
+		return v
 	}
-
-	fmt.Println("Schema migration applied successfully!")
+	return fallback
 }
@@ -0,0 +1,86 @@
+// File: scripts/reclassify.go
+
+//go:build ignore
+
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"os"
+	"os/signal"
+	"strings"
+
+	"bp-tracker/internal/database"
+	"bp-tracker/internal/reclassify"
+	"bp-tracker/internal/utils"
+)
+
+func main() {
+	guideline := flag.String("guideline", "", "Reclassify every reading by this guideline (default: each reading's own)")
+	batch := flag.Int("batch", reclassify.DefaultBatchSize, "Readings per transaction")
+	dryRun := flag.Bool("dry-run", false, "Report drift without saving")
+	asJSON := flag.Bool("json", false, "Print the full report as JSON")
+	driftLimit := flag.Int("drift-limit", -1, "Drifted readings to list, -1 for all")
+	flag.Parse()
+
+	// Readings in an unknown format are classified by CLASSIFIER, as by the server
+	opts := reclassify.Options{BatchSize: *batch, DryRun: *dryRun, DriftLimit: *driftLimit}
+	var err error
+	if opts.Default, err = utils.ClassifierFromEnv(); err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(1)
+	}
+	if *guideline != "" {
+		c, ok := utils.LookupClassifier(*guideline)
+		if !ok {
+			fmt.Fprintf(os.Stderr, "Error: unknown guideline %q (expected %s)\n", *guideline, strings.Join(utils.ClassifierIDs(), ", "))
+			os.Exit(1)
+		}
+		opts.Guideline = c
+	}
+
+	// Same database as the server: DB_DRIVER, SQLITE_PATH or DB_*
+	store, err := database.NewStore()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error opening database: %v\n", err)
+		os.Exit(1)
+	}
+	defer store.Close()
+
+	// Stop between batches on Ctrl-C; saved batches are kept
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+
+	result, err := reclassify.Run(ctx, store, opts)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		if result == nil {
+			os.Exit(1)
+		}
+	}
+
+	if *asJSON {
+		enc := json.NewEncoder(os.Stdout)
+		enc.SetIndent("", "  ")
+		enc.Encode(result)
+	} else {
+		for _, c := range result.Drift {
+			fmt.Printf("%d\t%s\t%d/%d\t%s -> %s\n", c.ID, c.Timestamp.Format("2006-01-02 15:04"), c.Systolic, c.Diastolic, c.Stored, c.Computed)
+		}
+		if result.Truncated {
+			fmt.Printf("... %d more drifted readings not listed\n", result.Drifted-len(result.Drift))
+		}
+		fmt.Printf("Checked %d readings: %d drifted, %d stale.", result.Checked, result.Drifted, result.Stale)
+		if result.DryRun {
+			fmt.Println(" Dry run: no changes were made.")
+		} else {
+			fmt.Printf(" Updated %d in %d batches, %d skipped as edited meanwhile.\n", result.Updated, result.Batches, result.Conflicts)
+		}
+	}
+	if err != nil {
+		os.Exit(1)
+	}
+}
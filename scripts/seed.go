@@ -1,5 +1,7 @@
 // File: scripts/seed.go
 
+//go:build ignore
+
 package main
 
 import (
@@ -20,12 +22,18 @@ func main() {
     flag.Parse()
 
     // Initialize database
-    db, err := database.New(*dbPath)
+    db, err := database.NewSQLite(*dbPath)
     if err != nil {
         log.Fatalf("Failed to initialize database: %v", err)
     }
     defer db.Close()
 
+    // Classify with the deployment's guideline, as the server does
+    classifier, err := utils.ClassifierFromEnv()
+    if err != nil {
+        log.Fatalf("Failed to select classifier: %v", err)
+    }
+
     // Generate readings for each day
     startDate := time.Now().AddDate(0, 0, -(*days))
     for day := 0; day < *days; day++ {
@@ -44,7 +52,8 @@ func main() {
                 Systolic:   systolic,
                 Diastolic:  diastolic,
                 Pulse:      pulse,
-                Classification: utils.ClassifyBP(systolic, diastolic).Name,
+                Classification: classifier.Classify(systolic, diastolic).Classification(),
+                ClassificationVersion: classifier.Version(),
             }
 
             // Save to database
@@ -3,6 +3,63 @@
 document.addEventListener('DOMContentLoaded', function() {
     const form = document.getElementById('readingForm');
     const resultDiv = document.getElementById('result');
+    const readingsGrid = document.getElementById('readingsGrid');
+    const readingTemplate = document.getElementById('readingTemplate');
+    const addReadingBtn = document.getElementById('addReadingBtn');
+    const discardFirst = document.getElementById('discardFirst');
+    const guideline = document.getElementById('guideline');
+
+    const minReadings = parseInt(readingsGrid.dataset.min, 10);
+    const maxReadings = parseInt(readingsGrid.dataset.max, 10);
+    const initialReadings = parseInt(readingsGrid.dataset.initial, 10);
+
+    // Add one reading group from the template
+    function addReadingGroup() {
+        const group = readingTemplate.content.firstElementChild.cloneNode(true);
+        group.querySelector('.remove-reading-btn').addEventListener('click', function() {
+            group.remove();
+            renumberReadingGroups();
+        });
+        readingsGrid.appendChild(group);
+        renumberReadingGroups();
+    }
+
+    // Keep headings, input ids and button states in sync with the group count
+    function renumberReadingGroups() {
+        const groups = readingsGrid.querySelectorAll('.reading-group');
+        groups.forEach((group, i) => {
+            const n = i + 1;
+            group.querySelector('.reading-number').textContent = n;
+            group.querySelectorAll('input[data-field]').forEach(input => {
+                input.id = `${input.dataset.field}${n}`;
+            });
+            group.querySelectorAll('label[data-field]').forEach(label => {
+                label.htmlFor = `${label.dataset.field}${n}`;
+            });
+            group.querySelector('.remove-reading-btn').disabled = groups.length <= minReadings;
+        });
+        addReadingBtn.disabled = groups.length >= maxReadings;
+    }
+
+    function resetReadingGroups() {
+        readingsGrid.innerHTML = '';
+        for (let i = 0; i < initialReadings; i++) {
+            addReadingGroup();
+        }
+    }
+
+    // Remember the chosen guideline on this device; the server default
+    // applies until one is picked
+    const savedGuideline = localStorage.getItem('guideline');
+    if (savedGuideline && guideline.querySelector(`option[value="${savedGuideline}"]`)) {
+        guideline.value = savedGuideline;
+    }
+    guideline.addEventListener('change', function() {
+        localStorage.setItem('guideline', guideline.value);
+    });
+
+    addReadingBtn.addEventListener('click', addReadingGroup);
+    resetReadingGroups();
 
     form.addEventListener('submit', async function(e) {
         e.preventDefault();
@@ -13,12 +70,21 @@ document.addEventListener('DOMContentLoaded', function() {
         submitBtn.textContent = 'Saving...';
 
         try {
-            // Convert form data to JSON
-            const formData = new FormData(form);
-            const data = {};
-            for (let [key, value] of formData.entries()) {
-                data[key] = parseInt(value, 10);
-            }
+            // Convert the reading groups to a measurements array
+            const measurements = [];
+            readingsGrid.querySelectorAll('.reading-group').forEach(group => {
+                const value = field => parseInt(group.querySelector(`input[data-field="${field}"]`).value, 10);
+                measurements.push({
+                    systolic: value('systolic'),
+                    diastolic: value('diastolic'),
+                    pulse: value('pulse')
+                });
+            });
+            const data = {
+                measurements: measurements,
+                discard_first: discardFirst.checked,
+                guideline: guideline.value
+            };
 
             const response = await fetch('/submit', {
                 method: 'POST',
@@ -34,7 +100,12 @@ document.addEventListener('DOMContentLoaded', function() {
                 // Show success message and classification
                 displayResult(result, false);
                 updateStatsDisplay(result.stats);
+                const keepDiscardFirst = discardFirst.checked;
+                const keepGuideline = guideline.value;
                 form.reset();
+                discardFirst.checked = keepDiscardFirst;
+                guideline.value = keepGuideline;
+                resetReadingGroups();
 
                 // Refresh the stats section
                 const statsSection = document.querySelector('.stats-grid');
@@ -92,6 +163,25 @@ document.addEventListener('DOMContentLoaded', function() {
             updateAverageCard('30-Day Average', stats.thirty_day_avg);
             updateAverageCard('All-Time Average', stats.all_time_avg);
         }
+        updateStatusDisplay(stats.current_status);
+    }
+
+    function updateStatusDisplay(status) {
+        const card = document.querySelector('.current-status');
+        if (!card || !status) return;
+        card.className = `current-status confidence-${status.confidence}`;
+        const categoryEl = card.querySelector('.status-category');
+        if (status.category) {
+            const category = status.category;
+            const name = category.Subtype ? `${category.Name}, ${category.Subtype}` : category.Name;
+            categoryEl.textContent = `${name}: ${status.summary.systolic.avg}/${status.summary.diastolic.avg} mmHg`;
+        } else {
+            categoryEl.textContent = 'No status yet';
+        }
+        card.querySelector('.status-explanation').textContent = status.explanation ? status.explanation.message : '';
+        let detail = `Confidence: ${status.confidence}${status.reason ? ` (${status.reason})` : ''}. ${status.readings} readings over ${status.days} days`;
+        if (status.skipped_day) detail += `, first day ${status.skipped_day} left out`;
+        card.querySelector('.status-confidence').textContent = `${detail}.`;
     }
 
     function updateAverageCard(title, data) {
@@ -112,15 +202,22 @@ document.addEventListener('DOMContentLoaded', function() {
     function displayResult(result, isError) {
         resultDiv.classList.remove('hidden');
         const classificationEl = resultDiv.querySelector('.classification');
+        const explanationEl = resultDiv.querySelector('.explanation');
         const recommendationEl = resultDiv.querySelector('.recommendation');
 
         if (isError) {
             classificationEl.textContent = `Error: ${result.error}`;
             classificationEl.className = 'classification crisis';
+            explanationEl.textContent = '';
             recommendationEl.textContent = '';
         } else {
-            classificationEl.textContent = `Classification: ${result.classification.Name}`;
-            classificationEl.className = `classification ${result.classification.Name.toLowerCase().replace(' ', '')}`;
+            const category = result.classification;
+            const name = category.Subtype ? `${category.Name}, ${category.Subtype}` : category.Name;
+            classificationEl.textContent = `Classification: ${name}`;
+            // Colored by risk, so every guideline (and low readings) is shown alike
+            classificationEl.className = `classification risk-${category.Risk.replace(' ', '-')}`;
+            // Which value put the reading there, e.g. "Diastolic 82 is 2 mmHg into Hypertension Stage 1"
+            explanationEl.textContent = result.explanation ? result.explanation.message : '';
             recommendationEl.textContent = result.recommendation;
         }
     }